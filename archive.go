@@ -0,0 +1,112 @@
+package sitemap
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// WaybackMachine is the URL of the Wayback Machine of the Internet Archive.
+const WaybackMachine = "https://archive.org"
+
+// MetadataArchived is the metadata key of the time of the archived copy of
+// the sitemap of an entry, see WithArchiveFallback.
+const MetadataArchived = "archived"
+
+// archiveLayout is the layout of timestamps of the Wayback Machine.
+const archiveLayout = "20060102150405"
+
+// WithArchiveFallback makes walks fetch the latest archived copy of a sitemap
+// from the archive, e.g. WaybackMachine, when the live sitemap is unreachable:
+// on network errors like dead DNS, on 401, 403, 429, 451 and 5xx statuses and
+// on challenges of WithChallengeDetector. It is useful for research and
+// competitive analysis of sites which are down or walled.
+//
+// Entries of archived copies are stale, they carry the time of the copy as
+// MetadataArchived, see ArchivedAt, and the report of the sitemap has it as
+// Archived. Archived copies aren't stored for conditional fetching. If there
+// is no archived copy, the error of the live sitemap is returned.
+func WithArchiveFallback(archive string) Option {
+	return func(o *options) {
+		o.archive = strings.TrimSuffix(archive, "/")
+	}
+}
+
+// ArchivedAt returns the time of the archived copy of the sitemap which had
+// the entry, see WithArchiveFallback. It reports false for live entries.
+func ArchivedAt(e Entry) (time.Time, bool) {
+	archived, ok := MetadataOf(e)[MetadataArchived].(time.Time)
+	return archived, ok
+}
+
+// archiveAvailability is a response of the availability API of the Wayback Machine.
+type archiveAvailability struct {
+	Snapshots struct {
+		Closest struct {
+			Available bool   `json:"available"`
+			URL       string `json:"url"`
+			Timestamp string `json:"timestamp"`
+		} `json:"closest"`
+	} `json:"archived_snapshots"`
+}
+
+// unreachable reports whether the error of a download makes the archived copy
+// fetched instead.
+func (o *options) unreachable(ctx context.Context, err error) bool {
+	if o.archive == "" || err == nil || ctx.Err() != nil {
+		return false
+	}
+
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		switch status := httpErr.StatusCode; {
+		case status == http.StatusUnauthorized, status == http.StatusForbidden,
+			status == http.StatusTooManyRequests, status == http.StatusUnavailableForLegalReasons:
+			return true
+		default:
+			return status >= 500
+		}
+	}
+	var challengeErr *ChallengeError
+	var netErr net.Error
+	return errors.As(err, &challengeErr) || errors.As(err, &netErr)
+}
+
+// fetchArchived downloads the latest archived copy of the document like fetch
+// does. Its URL is the URL of the copy.
+func (o *options) fetchArchived(ctx context.Context, location string) (*download, error) {
+	res, err := o.get(ctx, o.archive+"/wayback/available?url="+url.QueryEscape(location))
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if err = checkStatus(res, o.archive); err != nil {
+		return nil, err
+	}
+
+	var availability archiveAvailability
+	if err = json.NewDecoder(res.Body).Decode(&availability); err != nil {
+		return nil, fmt.Errorf("sitemap: invalid response of archive: %v", err)
+	}
+	closest := availability.Snapshots.Closest
+	archived, err := time.Parse(archiveLayout, closest.Timestamp)
+	if !closest.Available || err != nil || !strings.Contains(closest.URL, "/"+closest.Timestamp+"/") {
+		return nil, fmt.Errorf("sitemap: no archived copy of %s", location)
+	}
+
+	// The id_ suffix of the timestamp requests the copy as it was archived,
+	// without rewritten links and the toolbar.
+	raw := strings.Replace(closest.URL, "/"+closest.Timestamp+"/", "/"+closest.Timestamp+"id_/", 1)
+	body, err := o.fetch(ctx, raw)
+	if err != nil {
+		return nil, err
+	}
+	body.archived = archived
+	return body, nil
+}
@@ -0,0 +1,77 @@
+package sitemap
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWithArchiveFallback(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	mux.HandleFunc("/index.xml", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "<sitemapindex><sitemap><loc>%[1]s/walled.xml</loc></sitemap>"+
+			"<sitemap><loc>%[1]s/lost.xml</loc></sitemap></sitemapindex>", server.URL)
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	})
+
+	var requested []string
+	archive := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.Path)
+		switch r.URL.Path {
+		case "/wayback/available":
+			if r.URL.Query().Get("url") != server.URL+"/walled.xml" {
+				fmt.Fprint(w, `{"archived_snapshots":{}}`)
+				return
+			}
+			fmt.Fprintf(w, `{"archived_snapshots":{"closest":{"available":true,"status":"200",`+
+				`"url":"http://%s/web/20240102030405/%s/walled.xml","timestamp":"20240102030405"}}}`, r.Host, server.URL)
+		case "/web/20240102030405id_/" + server.URL + "/walled.xml":
+			fmt.Fprint(w, "<urlset><url><loc>http://example.com/archived</loc></url></urlset>")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer archive.Close()
+
+	var entries []Entry
+	report, err := NewCrawler(WithArchiveFallback(archive.URL)).Crawl(context.Background(), server.URL+"/index.xml", func(e Entry) error {
+		entries = append(entries, e)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Crawling failed with error %s", err)
+	}
+
+	archived := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	if len(entries) != 1 || entries[0].GetLocation() != "http://example.com/archived" {
+		t.Fatalf("Unexpected entries %v, requests %v", entries, requested)
+	}
+	if at, ok := ArchivedAt(entries[0]); !ok || !at.Equal(archived) {
+		t.Errorf("Unexpected archived time %v of entry", at)
+	}
+	if !report.Sitemaps[0].Archived.IsZero() || !report.Sitemaps[1].Archived.Equal(archived) {
+		t.Errorf("Unexpected archived times %v and %v", report.Sitemaps[0].Archived, report.Sitemaps[1].Archived)
+	}
+	if lost := report.Sitemaps[2]; lost.Err == nil || !lost.Archived.IsZero() {
+		t.Errorf("Unexpected report %+v of sitemap without archived copy", lost)
+	}
+}
+
+func TestWithArchiveFallback_Disabled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	report, _ := NewCrawler().Crawl(context.Background(), server.URL+"/sitemap.xml", func(e Entry) error { return nil })
+	if report.Sitemaps[0].Err == nil || !report.Sitemaps[0].Archived.IsZero() {
+		t.Errorf("Unexpected report %+v", report.Sitemaps[0])
+	}
+}
@@ -0,0 +1,119 @@
+package sitemap
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sync/atomic"
+)
+
+// bytesPerGB is the count of bytes of a GB of proxy traffic.
+const bytesPerGB = 1e9
+
+// Budget limits requests and traffic of a call, e.g. of a crawl, for metered
+// proxies and egress. Zero values mean no limit.
+//
+// MaxBytes limits received bytes of response bodies. CostPerGB is the price of
+// a GB (10^9 bytes) of traffic through proxies, MaxCost limits the total price.
+type Budget struct {
+	MaxRequests int64   `json:"max_requests,omitempty"`
+	MaxBytes    int64   `json:"max_bytes,omitempty"`
+	MaxCost     float64 `json:"max_cost,omitempty"`
+	CostPerGB   float64 `json:"cost_per_gb,omitempty"`
+}
+
+// Usage is a tally of requests and traffic of a call. Requests include retries,
+// ProxyBytes is the part of Bytes received through proxies, Cost is its price
+// by Budget.CostPerGB.
+type Usage struct {
+	Requests   int64
+	Bytes      int64
+	ProxyBytes int64
+	Cost       float64
+}
+
+// BudgetExceededError is returned when the budget set by WithBudget is exceeded.
+// It can be wrapped by ParseError, check it by errors.As.
+type BudgetExceededError struct {
+	Budget Budget
+	Usage  Usage
+}
+
+func (e *BudgetExceededError) Error() string {
+	return fmt.Sprintf("sitemap: budget is exceeded by %d requests, %d bytes and cost %.4f",
+		e.Usage.Requests, e.Usage.Bytes, e.Usage.Cost)
+}
+
+// WithBudget sets the budget of requests and traffic. Requests which exceed it
+// aren't sent and downloads which exceed it are aborted with BudgetExceededError.
+// Crawl reports include the usage, see CrawlReport.
+func WithBudget(budget Budget) Option {
+	return func(o *options) {
+		o.budget = budget
+	}
+}
+
+// meter counts requests and traffic of options.
+type meter struct {
+	requests   int64
+	bytes      int64
+	proxyBytes int64
+}
+
+func (o *options) usage() Usage {
+	u := Usage{
+		Requests:   atomic.LoadInt64(&o.meter.requests),
+		Bytes:      atomic.LoadInt64(&o.meter.bytes),
+		ProxyBytes: atomic.LoadInt64(&o.meter.proxyBytes),
+	}
+	u.Cost = float64(u.ProxyBytes) / bytesPerGB * o.budget.CostPerGB
+	return u
+}
+
+// countRequest counts a request which is going to be sent. It fails if there
+// are no requests left in the budget or the budget is already exceeded.
+func (o *options) countRequest() error {
+	requests := atomic.AddInt64(&o.meter.requests, 1)
+	if max := o.budget.MaxRequests; max > 0 && requests > max {
+		atomic.AddInt64(&o.meter.requests, -1)
+		return o.budgetExceeded()
+	}
+	return o.checkBudget()
+}
+
+// checkBudget returns BudgetExceededError if bytes or cost are over the budget.
+func (o *options) checkBudget() error {
+	u := o.usage()
+	if (o.budget.MaxBytes > 0 && u.Bytes > o.budget.MaxBytes) || (o.budget.MaxCost > 0 && u.Cost > o.budget.MaxCost) {
+		return o.budgetExceeded()
+	}
+	return nil
+}
+
+func (o *options) budgetExceeded() error {
+	return &BudgetExceededError{Budget: o.budget, Usage: o.usage()}
+}
+
+// meterResponse counts bytes of the body of the response.
+func (o *options) meterResponse(res *http.Response, proxied bool) {
+	res.Body = &readCloser{Reader: &meteredReader{reader: res.Body, o: o, proxied: proxied}, Closer: res.Body}
+}
+
+type meteredReader struct {
+	reader  io.Reader
+	o       *options
+	proxied bool
+}
+
+func (r *meteredReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	atomic.AddInt64(&r.o.meter.bytes, int64(n))
+	r.o.count(CounterBytes, int64(n))
+	if r.proxied {
+		atomic.AddInt64(&r.o.meter.proxyBytes, int64(n))
+	}
+	if err == nil {
+		err = r.o.checkBudget()
+	}
+	return n, err
+}
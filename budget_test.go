@@ -0,0 +1,66 @@
+package sitemap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCrawler_Usage(t *testing.T) {
+	server := newCrawlServer()
+	defer server.Close()
+
+	report, err := NewCrawler().Crawl(context.Background(), server.URL+"/index.xml", func(e Entry) error {
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Crawling failed with error %s", err)
+	}
+
+	if report.Usage.Requests != 4 || report.Usage.Bytes == 0 || report.Usage.ProxyBytes != 0 {
+		t.Errorf("Unexpected usage %+v", report.Usage)
+	}
+}
+
+func TestCrawler_BudgetExceeded(t *testing.T) {
+	server := newCrawlServer()
+	defer server.Close()
+
+	report, err := NewCrawler(WithBudget(Budget{MaxRequests: 2}), WithWorkers(1)).Crawl(context.Background(), server.URL+"/index.xml", func(e Entry) error {
+		return nil
+	})
+
+	var budgetErr *BudgetExceededError
+	if !errors.As(err, &budgetErr) {
+		t.Fatalf("Expected BudgetExceededError, but given %v", err)
+	}
+	if report.Usage.Requests != 2 || len(report.Sitemaps) != 3 {
+		t.Errorf("Expected crawling to stop after 2 requests, but given %+v", report)
+	}
+}
+
+func TestParseFromSite_ProxyCost(t *testing.T) {
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<urlset>")
+		for i := 0; i < 100; i++ {
+			fmt.Fprintf(w, "<url><loc>http://HOST/%d</loc></url>", i)
+		}
+		fmt.Fprint(w, "</urlset>")
+	}))
+	defer proxy.Close()
+
+	err := ParseFromSite("http://sitemap.test/sitemap.xml", func(e Entry) error {
+		return nil
+	}, WithProxies(proxy.URL), WithBudget(Budget{CostPerGB: 1e6, MaxCost: 1}))
+
+	var budgetErr *BudgetExceededError
+	if !errors.As(err, &budgetErr) {
+		t.Fatalf("Expected BudgetExceededError, but given %v", err)
+	}
+	if usage := budgetErr.Usage; usage.ProxyBytes != usage.Bytes || usage.Cost <= 1 {
+		t.Errorf("Unexpected usage %+v", usage)
+	}
+}
@@ -0,0 +1,149 @@
+package sitemap
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// CanonicalResult is a result of the rel=canonical check of a single page.
+//
+// Location is URL of the page from the sitemap.
+// Canonical is the absolute URL from rel=canonical link of the page, it is empty
+// when the page doesn't declare a canonical URL.
+// Mismatch is true when the page declares a canonical URL which differs from Location.
+// Err is not nil when the page can't be fetched or read, it is HTTPError for
+// non-2xx statuses.
+type CanonicalResult struct {
+	Location  string
+	Canonical string
+	Mismatch  bool
+	Err       error
+}
+
+// CanonicalConsumer is a type represents consumer of canonical check results.
+type CanonicalConsumer func(CanonicalResult) error
+
+// CheckCanonical parses the sitemap which provides by the reader, fetches a sample
+// of its pages (see WithSample) and for each fetched page calls the consumer's
+// function with the result of comparison of the page rel=canonical and the sitemap loc.
+func CheckCanonical(reader io.Reader, consumer CanonicalConsumer, opts ...Option) error {
+	o := newOptions(opts)
+	index, taken := 0, 0
+
+	return Parse(reader, func(e Entry) error {
+		index++
+		if !o.sampled(index-1, taken) {
+			return nil
+		}
+		taken++
+
+		return consumer(checkCanonical(e.GetLocation(), o))
+	}, opts...)
+}
+
+// CheckCanonicalFromSite downloads sitemap from a site and checks its pages
+// like CheckCanonical does.
+func CheckCanonicalFromSite(sitemapURL string, consumer CanonicalConsumer, opts ...Option) error {
+	o := newOptions(opts)
+	res, err := o.getSitemap(context.Background(), sitemapURL)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	return withSource(CheckCanonical(res.Body, consumer, opts...), sitemapURL)
+}
+
+func checkCanonical(location string, o *options) CanonicalResult {
+	result := CanonicalResult{Location: location}
+
+	res, err := o.get(context.Background(), location)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	defer res.Body.Close()
+	if err = checkStatus(res, location); err != nil {
+		result.Err = err
+		return result
+	}
+
+	href, err := findCanonical(res.Body)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	if href == "" {
+		return result
+	}
+
+	canonical, err := res.Request.URL.Parse(href)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	result.Canonical = canonical.String()
+	result.Mismatch = !sameURL(location, canonical)
+	return result
+}
+
+// findCanonical returns href of the first <link rel="canonical"> in the document head.
+func findCanonical(reader io.Reader) (string, error) {
+	tokenizer := html.NewTokenizer(reader)
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			if errors.Is(tokenizer.Err(), io.EOF) {
+				return "", nil
+			}
+			return "", tokenizer.Err()
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := tokenizer.Token()
+			if token.Data == "body" {
+				return "", nil
+			}
+			if token.Data == "link" && hasLinkRel(token, "canonical") {
+				return tokenAttr(token, "href"), nil
+			}
+		case html.EndTagToken:
+			if tokenizer.Token().Data == "head" {
+				return "", nil
+			}
+		}
+	}
+}
+
+func hasLinkRel(token html.Token, rel string) bool {
+	for _, value := range strings.Fields(tokenAttr(token, "rel")) {
+		if strings.EqualFold(value, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+func tokenAttr(token html.Token, name string) string {
+	for _, attr := range token.Attr {
+		if attr.Key == name {
+			return strings.TrimSpace(attr.Val)
+		}
+	}
+	return ""
+}
+
+func sameURL(location string, canonical *url.URL) bool {
+	loc, err := url.Parse(location)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(loc.Scheme, canonical.Scheme) &&
+		strings.EqualFold(loc.Host, canonical.Host) &&
+		loc.EscapedPath() == canonical.EscapedPath() &&
+		loc.RawQuery == canonical.RawQuery
+}
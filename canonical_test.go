@@ -0,0 +1,107 @@
+package sitemap
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCheckCanonical(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/same", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><head><link rel="canonical" href="/same"></head><body></body></html>`)
+	})
+	mux.HandleFunc("/other", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><head><link rel="Canonical" href="https://example.com/x"/></head></html>`)
+	})
+	mux.HandleFunc("/missed", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `<html><head><link rel="canonical" href="/"></head></html>`)
+	})
+	mux.HandleFunc("/none", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><head></head><body><link rel="canonical" href="/late"></body></html>`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	sitemap := `<urlset>
+		<url><loc>` + server.URL + `/same</loc></url>
+		<url><loc>` + server.URL + `/other</loc></url>
+		<url><loc>` + server.URL + `/none</loc></url>
+		<url><loc>` + server.URL + `/missed</loc></url>
+	</urlset>`
+
+	results := make(map[string]CanonicalResult)
+	err := CheckCanonical(strings.NewReader(sitemap), func(r CanonicalResult) error {
+		results[strings.TrimPrefix(r.Location, server.URL)] = r
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Check failed with error %s", err)
+	}
+
+	if r := results["/same"]; r.Err != nil || r.Mismatch || r.Canonical != server.URL+"/same" {
+		t.Errorf("Unexpected result for the same canonical %+v", r)
+	}
+	if r := results["/other"]; r.Err != nil || !r.Mismatch || r.Canonical != "https://example.com/x" {
+		t.Errorf("Unexpected result for the other canonical %+v", r)
+	}
+	if r := results["/none"]; r.Err != nil || r.Mismatch || r.Canonical != "" {
+		t.Errorf("Unexpected result for the missed canonical %+v", r)
+	}
+	var httpErr *HTTPError
+	if r := results["/missed"]; !errors.As(r.Err, &httpErr) || httpErr.StatusCode != http.StatusNotFound || r.Canonical != "" {
+		t.Errorf("Unexpected result for the missed page %+v", r)
+	}
+}
+
+func TestCheckCanonical_Sample(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	var sb strings.Builder
+	sb.WriteString("<urlset>")
+	for i := 0; i < 10; i++ {
+		fmt.Fprintf(&sb, "<url><loc>%s/%d</loc></url>", server.URL, i)
+	}
+	sb.WriteString("</urlset>")
+
+	var checked []string
+	err := CheckCanonical(strings.NewReader(sb.String()), func(r CanonicalResult) error {
+		checked = append(checked, strings.TrimPrefix(r.Location, server.URL))
+		return nil
+	}, WithSample(3, 2))
+	if err != nil {
+		t.Fatalf("Check failed with error %s", err)
+	}
+
+	if strings.Join(checked, ",") != "/0,/3" {
+		t.Errorf("Unexpected sample %v", checked)
+	}
+}
+
+func TestCheckCanonical_Limits(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	var sb strings.Builder
+	sb.WriteString("<urlset>")
+	for i := 0; i < 10; i++ {
+		fmt.Fprintf(&sb, "<url><loc>%s/%d</loc></url>", server.URL, i)
+	}
+	sb.WriteString("</urlset>")
+
+	checked := 0
+	err := CheckCanonical(strings.NewReader(sb.String()), func(r CanonicalResult) error {
+		checked++
+		return nil
+	}, WithLimits(Limits{MaxEntries: 2}))
+
+	var limitErr *LimitExceededError
+	if !errors.As(err, &limitErr) || limitErr.Limit != LimitEntries || checked != 2 {
+		t.Errorf("Expected limit of entries after 2 pages, but given %v after %d", err, checked)
+	}
+}
@@ -0,0 +1,90 @@
+package sitemap
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+)
+
+// ChallengeResponse is a response which is passed to ChallengeDetector. Attempt
+// counts attempts of the request from 0, Head is the beginning of the body up
+// to 512 bytes.
+type ChallengeResponse struct {
+	URL        string
+	Attempt    int
+	StatusCode int
+	Header     http.Header
+	Head       []byte
+}
+
+// Challenge describes a detected challenge of an anti-bot layer. Vendor names
+// the layer for errors and events, e.g. "cloudflare". Retry makes the request
+// retried by the policy of WithRetry, so the next attempt goes through the next
+// proxy of WithProxies. UserAgent replaces the user agent of next attempts.
+type Challenge struct {
+	Vendor    string
+	Retry     bool
+	UserAgent string
+}
+
+// ChallengeDetector is a type represents a classifier of responses. It returns
+// nil if the response isn't a challenge.
+type ChallengeDetector func(res ChallengeResponse) *Challenge
+
+// ChallengeError is returned when the response of the last attempt, or of an
+// attempt which isn't retried, is a challenge. Snippet is the beginning of the
+// response body.
+type ChallengeError struct {
+	URL        string
+	Vendor     string
+	StatusCode int
+	Snippet    string
+}
+
+func (e *ChallengeError) Error() string {
+	if e.Vendor == "" {
+		return fmt.Sprintf("sitemap: challenge of %s with status %d", e.URL, e.StatusCode)
+	}
+	return fmt.Sprintf("sitemap: %s challenge of %s with status %d", e.Vendor, e.URL, e.StatusCode)
+}
+
+// WithChallengeDetector sets the detector which is called for each response,
+// including retried ones and responses of page checks, to classify challenges
+// of anti-bot layers like Cloudflare or Akamai. The library doesn't hard-code
+// heuristics of vendors, they change too often, but see SoftError for a generic
+// detection of captchas. Detected challenges are ChallengeError.
+//
+//	sitemap.WithChallengeDetector(func(res sitemap.ChallengeResponse) *sitemap.Challenge {
+//		if res.Header.Get("Cf-Mitigated") == "challenge" {
+//			return &sitemap.Challenge{Vendor: "cloudflare", Retry: true}
+//		}
+//		return nil
+//	})
+func WithChallengeDetector(detector ChallengeDetector) Option {
+	return func(o *options) {
+		o.challenges = detector
+	}
+}
+
+// detectChallenge passes the response to the detector and returns the detected
+// challenge and its error. The body of the response stays readable.
+func (o *options) detectChallenge(location string, attempt int, res *http.Response) (*Challenge, error) {
+	if o.challenges == nil || res == nil {
+		return nil, nil
+	}
+
+	buffered := bufio.NewReaderSize(res.Body, snippetSize)
+	head, _ := buffered.Peek(snippetSize)
+	res.Body = &readCloser{Reader: buffered, Closer: res.Body}
+	challenge := o.challenges(ChallengeResponse{
+		URL:        location,
+		Attempt:    attempt,
+		StatusCode: res.StatusCode,
+		Header:     res.Header,
+		Head:       head,
+	})
+	if challenge == nil {
+		return nil, nil
+	}
+	return challenge, &ChallengeError{URL: location, Vendor: challenge.Vendor, StatusCode: res.StatusCode, Snippet: string(head)}
+}
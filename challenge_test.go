@@ -0,0 +1,69 @@
+package sitemap
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func detectTestChallenge(res ChallengeResponse) *Challenge {
+	if res.Header.Get("Cf-Mitigated") == "challenge" || strings.Contains(string(res.Head), "challenge-form") {
+		return &Challenge{Vendor: "cloudflare", Retry: true, UserAgent: fmt.Sprintf("agent-%d", res.Attempt+1)}
+	}
+	return nil
+}
+
+func TestWithChallengeDetector(t *testing.T) {
+	var requested []string
+	newProxy := func(name string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requested = append(requested, name+" "+r.UserAgent())
+			if name == "blocked" {
+				w.Header().Set("Cf-Mitigated", "challenge")
+				w.WriteHeader(http.StatusForbidden)
+				fmt.Fprint(w, "<html><body><form id=\"challenge-form\"></form></body></html>")
+				return
+			}
+			fmt.Fprint(w, "<urlset><url><loc>http://sitemap.test/</loc></url></urlset>")
+		}))
+	}
+	blocked := newProxy("blocked")
+	defer blocked.Close()
+	working := newProxy("working")
+	defer working.Close()
+
+	counter := 0
+	err := ParseFromSite("http://sitemap.test/sitemap.xml", func(e Entry) error {
+		counter++
+		return nil
+	}, WithProxies(blocked.URL, working.URL), WithRetry(testRetryPolicy()),
+		WithUserAgent("agent-0"), WithChallengeDetector(detectTestChallenge))
+	if err != nil {
+		t.Fatalf("Parsing failed with error %s", err)
+	}
+	if counter != 1 || strings.Join(requested, ", ") != "blocked agent-0, working agent-1" {
+		t.Errorf("Unexpected entries %d and requests %v", counter, requested)
+	}
+}
+
+func TestWithChallengeDetector_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<html><body><form id=\"challenge-form\"></form></body></html>")
+	}))
+	defer server.Close()
+
+	err := ParseFromSite(server.URL+"/sitemap.xml", func(e Entry) error {
+		return nil
+	}, WithChallengeDetector(detectTestChallenge))
+	var challengeErr *ChallengeError
+	if !errors.As(err, &challengeErr) {
+		t.Fatalf("Expected ChallengeError, but given %v", err)
+	}
+	if challengeErr.Vendor != "cloudflare" || challengeErr.StatusCode != http.StatusOK ||
+		!strings.Contains(challengeErr.Snippet, "challenge-form") {
+		t.Errorf("Unexpected ChallengeError %+v", challengeErr)
+	}
+}
@@ -0,0 +1,114 @@
+package sitemap
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+)
+
+const (
+	historyPrefix = "history/"
+	// maxHistory is the max count of lastmod values kept per URL.
+	maxHistory = 32
+)
+
+// RecordHistory returns a transform which appends lastmod of each entry to the
+// history of its URL in the store, if it differs from the latest recorded one.
+// Entries are passed through unchanged. Run it on each crawl to collect histories.
+func RecordHistory(store StateStore) Transform {
+	return func(e Entry) (Entry, error) {
+		lastmod := e.GetLastModified()
+		if lastmod == nil {
+			return e, nil
+		}
+
+		history, err := History(store, e.GetLocation())
+		if err != nil {
+			return nil, err
+		}
+
+		n := len(history)
+		if n > 0 && !lastmod.After(history[n-1]) {
+			return e, nil
+		}
+
+		history = append(history, lastmod.UTC())
+		if len(history) > maxHistory {
+			history = history[len(history)-maxHistory:]
+		}
+
+		return e, putHistory(store, e.GetLocation(), history)
+	}
+}
+
+// History returns recorded distinct lastmod values of the URL in chronological order.
+func History(store StateStore, location string) ([]time.Time, error) {
+	data, err := store.Get(historyPrefix + location)
+	if err != nil || data == nil {
+		return nil, err
+	}
+
+	var history []time.Time
+	if err = json.Unmarshal(data, &history); err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+func putHistory(store StateStore, location string, history []time.Time) error {
+	data, err := json.Marshal(history)
+	if err != nil {
+		return err
+	}
+	return store.Put(historyPrefix+location, data)
+}
+
+// InferChangeFrequency returns a change frequency of the URL inferred from the
+// median interval between its recorded modifications. It returns false if the
+// history has less than two modifications.
+func InferChangeFrequency(store StateStore, location string) (Frequency, bool, error) {
+	history, err := History(store, location)
+	if err != nil || len(history) < 2 {
+		return "", false, err
+	}
+
+	intervals := make([]time.Duration, 0, len(history)-1)
+	for i := 1; i < len(history); i++ {
+		intervals = append(intervals, history[i].Sub(history[i-1]))
+	}
+	sort.Slice(intervals, func(i, j int) bool { return intervals[i] < intervals[j] })
+
+	return frequencyOf(intervals[len(intervals)/2]), true, nil
+}
+
+// ApplyInferredChangeFrequency returns a transform which replaces change frequency
+// of each entry by the value inferred from its history. Entries without enough
+// history are passed through unchanged.
+func ApplyInferredChangeFrequency(store StateStore) Transform {
+	return func(e Entry) (Entry, error) {
+		frequency, ok, err := InferChangeFrequency(store, e.GetLocation())
+		if err != nil || !ok {
+			return e, err
+		}
+
+		c := copyEntry(e)
+		c.ChangeFrequency, c.hasChangeFrequency = frequency, true
+		return c, nil
+	}
+}
+
+func frequencyOf(interval time.Duration) Frequency {
+	const day = 24 * time.Hour
+
+	switch {
+	case interval <= time.Hour:
+		return Hourly
+	case interval <= day:
+		return Daily
+	case interval <= 7*day:
+		return Weekly
+	case interval <= 31*day:
+		return Monthly
+	}
+	return Yearly
+}
@@ -0,0 +1,75 @@
+package sitemap
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func crawlSitemap(day int) string {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	return fmt.Sprintf(`<urlset>
+		<url><loc>http://HOST/daily</loc><lastmod>%s</lastmod></url>
+		<url><loc>http://HOST/monthly</loc><lastmod>%s</lastmod></url>
+		<url><loc>http://HOST/new</loc></url>
+	</urlset>`,
+		start.AddDate(0, 0, day).Format(time.RFC3339),
+		start.AddDate(0, 0, day/30*30).Format(time.RFC3339))
+}
+
+func TestInferChangeFrequency(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sitemap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "state.json")
+	for day := 0; day <= 90; day++ {
+		store, err := OpenFileStateStore(path)
+		if err != nil {
+			t.Fatalf("Can't open state due to %s", err)
+		}
+		err = Parse(strings.NewReader(crawlSitemap(day)), func(e Entry) error {
+			_, err := RecordHistory(store)(e)
+			return err
+		})
+		if err != nil {
+			t.Fatalf("Recording failed with error %s", err)
+		}
+		if err = store.Save(); err != nil {
+			t.Fatalf("Can't save state due to %s", err)
+		}
+	}
+
+	store, err := OpenFileStateStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	err = Pipe(strings.NewReader(crawlSitemap(91)), w, WithTransform(ApplyInferredChangeFrequency(store)))
+	if err != nil {
+		t.Fatalf("Pipe failed with error %s", err)
+	}
+	w.Close()
+
+	var result []string
+	Parse(&buf, func(e Entry) error {
+		if !e.(PresenceProvider).HasChangeFrequency() {
+			result = append(result, "-")
+			return nil
+		}
+		result = append(result, e.GetChangeFrequency())
+		return nil
+	})
+	if strings.Join(result, " ") != "daily monthly -" {
+		t.Errorf("Unexpected frequencies %v", result)
+	}
+}
@@ -0,0 +1,26 @@
+package sitemap
+
+// Classifier is a type represents a function which gives a domain-specific
+// label to an URL, e.g. "product", "category" or "article". An empty label
+// means the URL isn't classified.
+type Classifier func(location string) string
+
+// ClassifiedEntry is an interface describes an entry with a label given by
+// the classifier set by WithClassifier. Each Entry passed to EntryConsumer
+// implements it, so you can get the label by a type assertion.
+//
+// GetLabel returns the label or an empty string if no classifier is set.
+//
+// You shouldn't implement this interface in your types.
+type ClassifiedEntry interface {
+	Entry
+	GetLabel() string
+}
+
+// WithClassifier sets the classifier which labels entries while parsing.
+// Labels are attached to entries and aggregated by StatsCollector.
+func WithClassifier(classifier Classifier) Option {
+	return func(o *options) {
+		o.classifier = classifier
+	}
+}
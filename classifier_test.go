@@ -0,0 +1,55 @@
+package sitemap
+
+import (
+	"strings"
+	"testing"
+)
+
+func classifyByPath(location string) string {
+	switch {
+	case strings.Contains(location, "/p/"):
+		return "product"
+	case strings.Contains(location, "/c/"):
+		return "category"
+	}
+	return ""
+}
+
+const classifiedSitemap = `<urlset>
+<url><loc>http://example.com/p/1</loc><lastmod>2019-03-01</lastmod></url>
+<url><loc>http://example.com/p/2</loc></url>
+<url><loc>http://example.com/c/shoes</loc></url>
+<url><loc>http://example.com/about</loc></url>
+</urlset>`
+
+func TestParse_Classifier(t *testing.T) {
+	var labels []string
+	err := Parse(strings.NewReader(classifiedSitemap), func(e Entry) error {
+		labels = append(labels, e.(ClassifiedEntry).GetLabel())
+		return nil
+	}, WithClassifier(classifyByPath))
+	if err != nil {
+		t.Fatalf("Parsing failed with error %s", err)
+	}
+
+	if strings.Join(labels, ",") != "product,product,category," {
+		t.Errorf("Unexpected labels %v", labels)
+	}
+}
+
+func TestCollectStats_Labels(t *testing.T) {
+	stats, err := CollectStats(strings.NewReader(classifiedSitemap), WithClassifier(classifyByPath))
+	if err != nil {
+		t.Fatalf("Collecting failed with error %s", err)
+	}
+
+	if len(stats.Labels) != 2 {
+		t.Fatalf("Expected 2 labels, but given %d", len(stats.Labels))
+	}
+	if p := stats.Labels["product"]; p.Entries != 2 || p.WithLastModified != 1 {
+		t.Errorf("Unexpected stats of products %+v", p)
+	}
+	if c := stats.Labels["category"]; c.Entries != 1 {
+		t.Errorf("Unexpected stats of categories %+v", c)
+	}
+}
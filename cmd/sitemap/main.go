@@ -0,0 +1,199 @@
+// Command sitemap crawls sitemaps and sitemap indexes and writes their entries.
+//
+// Usage:
+//
+//	sitemap [-config crawl.json] [-out path ...] [-v] [url ...]
+//
+// URLs are crawled after sitemaps listed in the configuration file, see
+// sitemap.Config for its format. Settings can be also set by SITEMAP_*
+// environment variables, see sitemap.OptionsFromEnv, the configuration
+// file overrides them. Outputs of the configuration are paths of
+// files prefixed by a format, "text:" (the default) writes an URL per line,
+// "ndjson:" writes an entry per line as JSON. The "-" path is the standard
+// output, which is used when no outputs are configured.
+//
+// The -out flag can be repeated, e.g. -out urls.txt -out ndjson:entries.ndjson,
+// its outputs replace outputs of the configuration. Entries are written to all
+// outputs in one run, so sitemaps are downloaded and parsed once.
+//
+// A summary of each crawl is printed to the standard error, -v adds a line per
+// sitemap with compressed and uncompressed sizes, download and parse times.
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	sitemap "github.com/frase-io/gopher-parse-sitemap"
+)
+
+func main() {
+	configPath := flag.String("config", "", "path of a JSON configuration file")
+	verbose := flag.Bool("v", false, "print sizes and timings of each sitemap")
+	var outputs stringsFlag
+	flag.Var(&outputs, "out", "output `path` prefixed by a format, can be repeated")
+	flag.Parse()
+
+	if err := run(*configPath, outputs, *verbose, flag.Args()); err != nil {
+		fmt.Fprintln(os.Stderr, "sitemap:", err)
+		os.Exit(1)
+	}
+}
+
+func run(configPath string, outputs []string, verbose bool, urls []string) error {
+	config := new(sitemap.Config)
+	if configPath != "" {
+		var err error
+		if config, err = sitemap.LoadConfig(configPath); err != nil {
+			return err
+		}
+	}
+
+	sitemaps := append(config.Sitemaps, urls...)
+	if len(sitemaps) == 0 {
+		return fmt.Errorf("no sitemaps to crawl")
+	}
+
+	specs := config.Outputs
+	if len(outputs) > 0 {
+		specs = outputs
+	}
+	if len(specs) == 0 {
+		specs = []string{"-"}
+	}
+	outs, err := openOutputs(specs)
+	if err != nil {
+		return err
+	}
+
+	env, err := sitemap.OptionsFromEnv(sitemap.EnvPrefix)
+	if err != nil {
+		return err
+	}
+	crawler, err := config.NewCrawler(env...)
+	if err != nil {
+		return err
+	}
+
+	consumers := make([]sitemap.EntryConsumer, len(outs))
+	for i, out := range outs {
+		consumers[i] = out.write
+	}
+	consumer := sitemap.Tee(consumers...)
+
+	for _, sitemapURL := range sitemaps {
+		report, err := crawler.Crawl(context.Background(), sitemapURL, consumer)
+
+		fmt.Fprintf(os.Stderr, "%s: %d entries in %d sitemaps, %d failed, %d requests, %d bytes, %s\n", sitemapURL,
+			report.Entries, len(report.Sitemaps), report.Failed, report.Usage.Requests, report.Usage.Bytes,
+			report.Finished.Sub(report.Started).Round(time.Millisecond))
+		for _, r := range report.Sitemaps {
+			if verbose {
+				fmt.Fprintf(os.Stderr, "  %s: %d entries, %d bytes, %d uncompressed (x%.1f), download %s, parse %s\n",
+					r.URL, r.Entries, r.CompressedSize, r.UncompressedSize, r.CompressionRatio(),
+					r.DownloadTime.Round(time.Millisecond), r.ParseTime.Round(time.Millisecond))
+			}
+			if r.Err != nil {
+				fmt.Fprintf(os.Stderr, "  %s: %v\n", r.URL, r.Err)
+			}
+		}
+		if err != nil {
+			closeOutputs(outs)
+			return err
+		}
+	}
+
+	return closeOutputs(outs)
+}
+
+// output writes entries to a file in a format.
+type output struct {
+	file   io.WriteCloser
+	buffer *bufio.Writer
+	write  func(sitemap.Entry) error
+}
+
+func openOutputs(specs []string) ([]*output, error) {
+	var outs []*output
+	for _, spec := range specs {
+		out, err := openOutput(spec)
+		if err != nil {
+			closeOutputs(outs)
+			return nil, err
+		}
+		outs = append(outs, out)
+	}
+	return outs, nil
+}
+
+func openOutput(spec string) (*output, error) {
+	format, path := "text", spec
+	if i := strings.IndexByte(spec, ':'); i >= 0 {
+		format, path = spec[:i], spec[i+1:]
+	}
+
+	var file io.WriteCloser = nopCloser{os.Stdout}
+	if path != "-" {
+		f, err := os.Create(path)
+		if err != nil {
+			return nil, err
+		}
+		file = f
+	}
+
+	out := &output{file: file, buffer: bufio.NewWriter(file)}
+	switch format {
+	case "text":
+		out.write = func(e sitemap.Entry) error {
+			_, err := fmt.Fprintln(out.buffer, e.GetLocation())
+			return err
+		}
+	case "ndjson":
+		writer := sitemap.NewJSONWriter(out.buffer)
+		out.write = writer.Add
+	default:
+		file.Close()
+		return nil, fmt.Errorf("unknown output format %q of %s", format, spec)
+	}
+	return out, nil
+}
+
+func closeOutputs(outs []*output) error {
+	var first error
+	for _, out := range outs {
+		err := out.buffer.Flush()
+		if closeErr := out.file.Close(); err == nil {
+			err = closeErr
+		}
+		if first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// stringsFlag is a flag which can be repeated.
+type stringsFlag []string
+
+func (f *stringsFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *stringsFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
@@ -0,0 +1,55 @@
+package sitemap
+
+import "io"
+
+// WithCompact makes writers write the smallest spec-compliant files for very
+// large sites: without indentation and line breaks, and with TagsOmit policy
+// unless WithTagPolicy or WithTagDefaults is given. Only the namespace of the
+// protocol is declared in any mode. The XML declaration is kept, since its
+// absence makes encodings ambiguous, see Validate. Combine it with
+// WithGzipLevel and compare sizes of Stats of writers.
+func WithCompact() Option {
+	return func(o *options) {
+		o.compact = true
+	}
+}
+
+// WithGzipLevel enables gzip compression of files of writers like WithGzip
+// with the level of the compress/gzip package, e.g. gzip.BestCompression.
+// Writers return the error of an invalid level on writing.
+func WithGzipLevel(level int) Option {
+	return func(o *options) {
+		o.gzip, o.gzipLevel = true, level
+	}
+}
+
+// WriteStats is a type represents sizes of files of a writer in bytes.
+//
+// Size is the size of the written XML before compression. Original is the
+// size of the same entries in the default layout: with indentation and line
+// breaks, and with changefreq and priority tags which are omitted by the
+// tag policy. Compressed is the size of gzip data, it is zero without gzip.
+type WriteStats struct {
+	Entries    int
+	Size       int64
+	Original   int64
+	Compressed int64
+}
+
+func (s *WriteStats) add(other WriteStats) {
+	s.Entries += other.Entries
+	s.Size += other.Size
+	s.Original += other.Original
+	s.Compressed += other.Compressed
+}
+
+type countingWriter struct {
+	writer io.Writer
+	count  int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.writer.Write(p)
+	w.count += int64(n)
+	return n, err
+}
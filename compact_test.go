@@ -0,0 +1,56 @@
+package sitemap
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+func TestWithCompact(t *testing.T) {
+	lastmod := time.Date(2015, 5, 7, 19, 13, 9, 0, time.UTC)
+	write := func(opts ...Option) (*bytes.Buffer, WriteStats) {
+		var buf bytes.Buffer
+		w := NewWriter(&buf, opts...)
+		if err := w.WriteEntry("http://HOST/?a=1&b=2", &lastmod, Monthly, Priority(0.9)); err != nil {
+			t.Fatalf("Writing failed with error %s", err)
+		}
+		if err := w.WriteEntry("http://HOST/tools/", nil, "", nil); err != nil {
+			t.Fatalf("Writing failed with error %s", err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("Closing failed with error %s", err)
+		}
+		return &buf, w.Stats()
+	}
+
+	pretty, prettyStats := write()
+	if prettyStats.Size != int64(pretty.Len()) || prettyStats.Original != prettyStats.Size || prettyStats.Entries != 2 {
+		t.Errorf("Unexpected stats %+v of %d bytes", prettyStats, pretty.Len())
+	}
+
+	compact, stats := write(WithCompact())
+	expected := `<?xml version="1.0" encoding="UTF-8"?><urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">` +
+		`<url><loc>http://HOST/?a=1&amp;b=2</loc><lastmod>2015-05-07T19:13:09Z</lastmod></url>` +
+		`<url><loc>http://HOST/tools/</loc></url></urlset>`
+	if compact.String() != expected {
+		t.Errorf("Unexpected result\n%s", compact.String())
+	}
+	if stats.Size != int64(compact.Len()) || stats.Original != prettyStats.Size || stats.Compressed != 0 {
+		t.Errorf("Unexpected stats %+v, expected original %d", stats, prettyStats.Size)
+	}
+	counter := 0
+	if err := Parse(compact, func(e Entry) error { counter++; return nil }); err != nil || counter != 2 {
+		t.Errorf("Compact sitemap was parsed wrong %d %v", counter, err)
+	}
+
+	compressed, stats := write(WithCompact(), WithGzipLevel(gzip.BestCompression))
+	if stats.Compressed != int64(compressed.Len()) || stats.Size != int64(len(expected)) {
+		t.Errorf("Unexpected stats %+v of %d compressed bytes", stats, compressed.Len())
+	}
+
+	if NewWriter(ioutil.Discard, WithGzipLevel(42)).WriteEntry("http://HOST/", nil, "", nil) == nil {
+		t.Error("Entry was written with invalid gzip level")
+	}
+}
@@ -0,0 +1,209 @@
+package sitemap
+
+import (
+	"context"
+	"sync"
+)
+
+const (
+	defaultWorkers = 4
+	// childBuffer is the count of entries which a worker can parse ahead of
+	// the consumer, it bounds memory used per worker.
+	childBuffer = 256
+)
+
+// WithWorkers sets count of sitemaps which are downloaded and parsed concurrently.
+// By default it is 4.
+func WithWorkers(n int) Option {
+	return func(o *options) {
+		if n > 0 {
+			o.workers = n
+		}
+	}
+}
+
+// WithHostConcurrency limits count of concurrent downloads from a single host.
+// By default only the count of workers limits it.
+func WithHostConcurrency(n int) Option {
+	return func(o *options) {
+		o.hostConcurrency = n
+	}
+}
+
+// WithEntryBuffer sets the count of entries which a worker of
+// ParseIndexConcurrent or the goroutine of ParseToChannel can parse ahead of
+// the consumer. Bodies are parsed while they are read from the network, so
+// when buffers are full a slow consumer pauses reading, and memory is bounded
+// by buffers instead of sizes of sitemaps (except WithContentHash, which keeps
+// documents up to MaxDecompressedSize of WithLimits in memory). By default a worker buffers 256 entries and
+// ParseToChannel doesn't buffer them. Zero disables buffering.
+func WithEntryBuffer(n int) Option {
+	return func(o *options) {
+		if n >= 0 {
+			o.entryBuffer = n
+		}
+	}
+}
+
+// entryBufferOr returns the size of WithEntryBuffer or the default size if
+// it isn't set.
+func (o *options) entryBufferOr(size int) int {
+	if o.entryBuffer >= 0 {
+		return o.entryBuffer
+	}
+	return size
+}
+
+// WithOrderedDelivery makes concurrent parsing pass entries to the consumer in
+// order of sitemaps in the index, as ParseFromRobots does. By default entries
+// are passed as soon as they are parsed.
+func WithOrderedDelivery() Option {
+	return func(o *options) {
+		o.ordered = true
+	}
+}
+
+// ParseIndexConcurrent downloads the sitemap index, then downloads and parses its
+// sitemaps by a pool of workers (see WithWorkers, WithHostConcurrency and
+// WithOrderedDelivery) and for each sitemap entry calls the consumer's function.
+//
+// Calls of the consumer are serialized, it is always called from the goroutine
+// which called ParseIndexConcurrent, so it doesn't need to be thread-safe.
+// The first error of a download, of parsing or of the consumer stops all workers
+// and is returned. If the URL refers a sitemap, not an index, it is parsed as is.
+func ParseIndexConcurrent(sitemapURL string, consumer EntryConsumer, opts ...Option) error {
+	o := newOptions(opts)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	w := newWalker(ctx, o, consumer)
+	children, err := w.fetch(sitemapURL)
+	if err != nil || len(children) == 0 {
+		return err
+	}
+
+	p := &pool{
+		ctx:     ctx,
+		o:       o,
+		limiter: newHostLimiter(o.hostConcurrency),
+		jobs:    make(chan job),
+		slots:   make(chan chan item, o.workers),
+	}
+	return p.run(children, consumer)
+}
+
+type item struct {
+	entry Entry
+	err   error
+}
+
+type job struct {
+	url   string
+	items chan item
+}
+
+// pool passes children of an index to workers and delivers their entries
+// to the consumer.
+type pool struct {
+	ctx     context.Context
+	o       *options
+	limiter *hostLimiter
+	jobs    chan job
+	// slots contains per-sitemap channels in order of sitemaps for ordered delivery
+	// or the single shared channel otherwise.
+	slots chan chan item
+}
+
+func (p *pool) run(children []string, consumer EntryConsumer) error {
+	var workers sync.WaitGroup
+	for i := 0; i < p.o.workers; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			p.work()
+		}()
+	}
+
+	if p.o.ordered {
+		go p.dispatchOrdered(children)
+	} else {
+		shared := make(chan item, p.o.entryBufferOr(childBuffer)*p.o.workers)
+		p.slots <- shared
+		close(p.slots)
+		go func() {
+			p.dispatch(children, shared)
+			workers.Wait()
+			close(shared)
+		}()
+	}
+
+	for items := range p.slots {
+		for it := range items {
+			err := it.err
+			if err == nil {
+				err = consumer(it.entry)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return p.ctx.Err()
+}
+
+func (p *pool) dispatch(children []string, items chan item) {
+	defer close(p.jobs)
+
+	for _, child := range children {
+		select {
+		case p.jobs <- job{url: child, items: items}:
+		case <-p.ctx.Done():
+			return
+		}
+	}
+}
+
+func (p *pool) dispatchOrdered(children []string) {
+	defer close(p.jobs)
+	defer close(p.slots)
+
+	for _, child := range children {
+		items := make(chan item, p.o.entryBufferOr(childBuffer))
+		select {
+		case p.slots <- items:
+		case <-p.ctx.Done():
+			return
+		}
+		select {
+		case p.jobs <- job{url: child, items: items}:
+		case <-p.ctx.Done():
+			return
+		}
+	}
+}
+
+func (p *pool) work() {
+	for j := range p.jobs {
+		w := newWalker(p.ctx, p.o, func(e Entry) error {
+			return p.send(j.items, item{entry: e})
+		})
+		w.limiter = p.limiter
+
+		if err := w.walk(j.url, 1); err != nil {
+			p.send(j.items, item{err: err})
+		}
+		if p.o.ordered {
+			close(j.items)
+		}
+	}
+}
+
+func (p *pool) send(items chan item, it item) error {
+	select {
+	case items <- it:
+		return nil
+	case <-p.ctx.Done():
+		return p.ctx.Err()
+	}
+}
@@ -0,0 +1,195 @@
+package sitemap
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type indexServer struct {
+	*httptest.Server
+	mu        sync.Mutex
+	active    int
+	maxActive int
+}
+
+func newIndexServer(children, entries int) *indexServer {
+	s := new(indexServer)
+	mux := http.NewServeMux()
+	s.Server = httptest.NewServer(mux)
+
+	mux.HandleFunc("/index.xml", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<sitemapindex>")
+		for i := 0; i < children; i++ {
+			fmt.Fprintf(w, "<sitemap><loc>%s/sitemap-%d.xml</loc></sitemap>", s.URL, i)
+		}
+		fmt.Fprint(w, "</sitemapindex>")
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		var child int
+		if _, err := fmt.Sscanf(r.URL.Path, "/sitemap-%d.xml", &child); err != nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		s.mu.Lock()
+		s.active++
+		if s.active > s.maxActive {
+			s.maxActive = s.active
+		}
+		s.mu.Unlock()
+		defer func() {
+			s.mu.Lock()
+			s.active--
+			s.mu.Unlock()
+		}()
+
+		// later sitemaps are faster to make ordering matter
+		time.Sleep(time.Duration(children-child) * time.Millisecond)
+		fmt.Fprint(w, "<urlset>")
+		for i := 0; i < entries; i++ {
+			fmt.Fprintf(w, "<url><loc>%s/%d/%d</loc></url>", s.URL, child, i)
+		}
+		fmt.Fprint(w, "</urlset>")
+	})
+
+	return s
+}
+
+func TestParseIndexConcurrent_Ordered(t *testing.T) {
+	s := newIndexServer(10, 300)
+	defer s.Close()
+
+	var result []string
+	err := ParseIndexConcurrent(s.URL+"/index.xml", func(e Entry) error {
+		result = append(result, strings.TrimPrefix(e.GetLocation(), s.URL))
+		return nil
+	}, WithWorkers(4), WithOrderedDelivery())
+	if err != nil {
+		t.Fatalf("Parsing failed with error %s", err)
+	}
+
+	if len(result) != 3000 {
+		t.Fatalf("Expected 3000 entries, but given %d", len(result))
+	}
+	for i, location := range result {
+		if expected := fmt.Sprintf("/%d/%d", i/300, i%300); location != expected {
+			t.Fatalf("Expected %s at %d, but given %s", expected, i, location)
+		}
+	}
+}
+
+func TestParseIndexConcurrent_HostConcurrency(t *testing.T) {
+	s := newIndexServer(20, 10)
+	defer s.Close()
+
+	seen := make(map[string]bool)
+	err := ParseIndexConcurrent(s.URL+"/index.xml", func(e Entry) error {
+		seen[e.GetLocation()] = true
+		return nil
+	}, WithWorkers(8), WithHostConcurrency(2))
+	if err != nil {
+		t.Fatalf("Parsing failed with error %s", err)
+	}
+
+	if len(seen) != 200 {
+		t.Errorf("Expected 200 entries, but given %d", len(seen))
+	}
+	if s.maxActive > 2 {
+		t.Errorf("Expected at most 2 concurrent downloads, but given %d", s.maxActive)
+	}
+}
+
+func TestParseIndexConcurrent_BreakingOnError(t *testing.T) {
+	s := newIndexServer(10, 100)
+	defer s.Close()
+
+	counter := 0
+	breakErr := fmt.Errorf("break error")
+	err := ParseIndexConcurrent(s.URL+"/index.xml", func(e Entry) error {
+		counter++
+		return breakErr
+	}, WithWorkers(4))
+
+	if counter != 1 || err != breakErr {
+		t.Errorf("Consumer error didn't break parsing: %d %v", counter, err)
+	}
+
+	err = ParseIndexConcurrent(s.URL+"/missed.xml", func(e Entry) error { return nil })
+	if err == nil {
+		t.Error("Download error wasn't returned")
+	}
+}
+
+// endlessSitemap is a body of a sitemap which never ends, it counts read bytes.
+type endlessSitemap struct {
+	mu      sync.Mutex
+	pending []byte
+	read    int
+	entries int
+}
+
+func (s *endlessSitemap) Read(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.pending) == 0 {
+		if s.entries == 0 {
+			s.pending = append(s.pending, "<urlset>"...)
+		}
+		s.pending = append(s.pending, fmt.Sprintf("<url><loc>http://example.com/%d</loc></url>", s.entries)...)
+		s.entries++
+	}
+	n := copy(p, s.pending)
+	s.pending = s.pending[n:]
+	s.read += n
+	return n, nil
+}
+
+func (s *endlessSitemap) Close() error {
+	return nil
+}
+
+func (s *endlessSitemap) bytes() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.read
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestParseIndexConcurrent_Backpressure(t *testing.T) {
+	body := new(endlessSitemap)
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		res := &http.Response{StatusCode: http.StatusOK, Header: make(http.Header), Request: r}
+		if r.URL.Path == "/index.xml" {
+			res.Body = ioutil.NopCloser(strings.NewReader("<sitemapindex><sitemap><loc>http://example.com/sitemap.xml</loc></sitemap></sitemapindex>"))
+		} else {
+			res.Body = body
+		}
+		return res, nil
+	})}
+
+	errSlow := fmt.Errorf("slow consumer")
+	read := 0
+	err := ParseIndexConcurrent("http://example.com/index.xml", func(e Entry) error {
+		time.Sleep(50 * time.Millisecond)
+		read = body.bytes()
+		return errSlow
+	}, WithHTTPClient(client), WithWorkers(1), WithEntryBuffer(10))
+	if err != errSlow {
+		t.Fatalf("Expected error of the consumer, but given %v", err)
+	}
+	// The decoder reads ahead by its buffer of 4KB and the worker by 10 entries.
+	if read == 0 || read > 8192 {
+		t.Errorf("Expected reading paused by the consumer, but given %d bytes read", read)
+	}
+}
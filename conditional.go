@@ -0,0 +1,195 @@
+package sitemap
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+const (
+	validatorsPrefix = "validators/"
+	childrenPrefix   = "children/"
+	hashPrefix       = "hash/"
+)
+
+// ErrNotModified is returned by *FromSite functions when conditional fetching
+// is enabled and the server responds that the sitemap isn't modified, so there
+// is nothing to parse.
+var ErrNotModified = errors.New("sitemap: not modified")
+
+// Validators are values of ETag and Last-Modified headers of a response,
+// which are sent back in If-None-Match and If-Modified-Since headers.
+type Validators struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+// IsZero reports whether there are no validators.
+func (v Validators) IsZero() bool {
+	return v.ETag == "" && v.LastModified == ""
+}
+
+// WithValidators enables conditional fetching by the validators. After a
+// successful parsing the validators are replaced by ones of the response,
+// so keep them to the next fetching of the same sitemap.
+func WithValidators(validators *Validators) Option {
+	return func(o *options) {
+		o.validators = validators
+	}
+}
+
+// WithConditional enables conditional fetching by validators which are kept
+// in the store per URL. Validators are stored after a successful parsing.
+//
+// Walkers like ParseIndexConcurrent and Crawler.Crawl fetch each sitemap of
+// indexes conditionally, so unchanged ones are skipped. Sitemaps of unchanged
+// indexes are taken from the store and still walked.
+func WithConditional(store StateStore) Option {
+	return func(o *options) {
+		o.validatorStore = store
+	}
+}
+
+// LoadValidators returns validators of the URL stored by WithConditional.
+func LoadValidators(store StateStore, location string) (Validators, error) {
+	var validators Validators
+	data, err := store.Get(validatorsPrefix + location)
+	if err != nil || data == nil {
+		return validators, err
+	}
+
+	err = json.Unmarshal(data, &validators)
+	return validators, err
+}
+
+// getConditional downloads a sitemap like getSitemap does, but sends validators
+// if conditional fetching is enabled. It returns ErrNotModified for 304 responses.
+func (o *options) getConditional(ctx context.Context, location string) (*http.Response, error) {
+	validators, err := o.loadValidators(location)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := o.doHeader(ctx, http.MethodGet, location, validators.header())
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode == http.StatusNotModified {
+		res.Body.Close()
+		return nil, ErrNotModified
+	}
+	return checkSitemap(res, location)
+}
+
+// storeValidators keeps validators of the successful response if conditional
+// fetching is enabled.
+func (o *options) storeValidators(location string, res *http.Response) error {
+	validators := responseValidators(res.Header)
+	if o.validators != nil {
+		*o.validators = validators
+	}
+	if o.validatorStore == nil {
+		return nil
+	}
+	return putValidators(o.validatorStore, location, validators)
+}
+
+// fetchConditional downloads a document of a walk like fetch does, but sends
+// validators of the store if conditional fetching is enabled. It returns
+// ErrNotModified for 304 responses.
+func (o *options) fetchConditional(ctx context.Context, location string) (*download, error) {
+	if o.validatorStore == nil {
+		return o.fetch(ctx, location)
+	}
+
+	validators, err := LoadValidators(o.validatorStore, location)
+	if err != nil {
+		return nil, err
+	}
+	return o.fetchHeader(ctx, location, validators.header())
+}
+
+// storeWalked keeps validators and the content hash of the walked document and
+// children of an index if conditional fetching is enabled. Empty hash removes
+// the stored one.
+func (o *options) storeWalked(location string, header http.Header, hash string, children []string) error {
+	if o.validatorStore == nil {
+		return nil
+	}
+
+	if hash == "" {
+		if err := o.validatorStore.Delete(hashPrefix + location); err != nil {
+			return err
+		}
+	} else if err := o.validatorStore.Put(hashPrefix+location, []byte(hash)); err != nil {
+		return err
+	}
+
+	if len(children) == 0 {
+		if err := o.validatorStore.Delete(childrenPrefix + location); err != nil {
+			return err
+		}
+	} else {
+		data, err := json.Marshal(children)
+		if err != nil {
+			return err
+		}
+		if err = o.validatorStore.Put(childrenPrefix+location, data); err != nil {
+			return err
+		}
+	}
+	return putValidators(o.validatorStore, location, responseValidators(header))
+}
+
+// loadChildren returns stored children of an unchanged index.
+func (o *options) loadChildren(location string) ([]string, error) {
+	var children []string
+	data, err := o.validatorStore.Get(childrenPrefix + location)
+	if err != nil || data == nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(data, &children)
+	return children, err
+}
+
+func responseValidators(header http.Header) Validators {
+	return Validators{ETag: header.Get("ETag"), LastModified: header.Get("Last-Modified")}
+}
+
+func putValidators(store StateStore, location string, validators Validators) error {
+	if validators.IsZero() {
+		return store.Delete(validatorsPrefix + location)
+	}
+	data, err := json.Marshal(validators)
+	if err != nil {
+		return err
+	}
+	return store.Put(validatorsPrefix+location, data)
+}
+
+func (o *options) loadValidators(location string) (Validators, error) {
+	if o.validators != nil {
+		return *o.validators, nil
+	}
+	if o.validatorStore != nil {
+		return LoadValidators(o.validatorStore, location)
+	}
+	return Validators{}, nil
+}
+
+func (v Validators) header() http.Header {
+	if v.IsZero() {
+		return nil
+	}
+
+	header := make(http.Header)
+	if v.ETag != "" {
+		header.Set("If-None-Match", v.ETag)
+	}
+	if v.LastModified != "" {
+		header.Set("If-Modified-Since", v.LastModified)
+	}
+	return header
+}
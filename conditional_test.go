@@ -0,0 +1,123 @@
+package sitemap
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newConditionalServer(etag string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Last-Modified", "Fri, 01 Mar 2019 10:30:00 GMT")
+		fmt.Fprint(w, "<urlset><url><loc>http://HOST/</loc></url></urlset>")
+	}))
+}
+
+func TestParseFromSite_Conditional(t *testing.T) {
+	server := newConditionalServer(`"v1"`)
+	defer server.Close()
+
+	store := NewMemoryStateStore()
+	counter := 0
+	consumer := func(e Entry) error {
+		counter++
+		return nil
+	}
+
+	if err := ParseFromSite(server.URL, consumer, WithConditional(store)); err != nil {
+		t.Fatalf("Parsing failed with error %s", err)
+	}
+	validators, err := LoadValidators(store, server.URL)
+	if err != nil || validators.ETag != `"v1"` || validators.LastModified != "Fri, 01 Mar 2019 10:30:00 GMT" {
+		t.Errorf("Unexpected stored validators %+v, error %v", validators, err)
+	}
+
+	err = ParseFromSite(server.URL, consumer, WithConditional(store))
+	if err != ErrNotModified || counter != 1 {
+		t.Errorf("Expected ErrNotModified and 1 entry, but given %v and %d", err, counter)
+	}
+}
+
+func TestParseIndexFromSite_Validators(t *testing.T) {
+	server := newConditionalServer(`"v2"`)
+	defer server.Close()
+
+	validators := &Validators{ETag: `"v1"`}
+	err := ParseIndexFromSite(server.URL, func(e IndexEntry) error {
+		return nil
+	}, WithValidators(validators))
+	if err != nil {
+		t.Fatalf("Parsing failed with error %s", err)
+	}
+	if validators.ETag != `"v2"` {
+		t.Errorf("Expected updated ETag \"v2\", but given %s", validators.ETag)
+	}
+
+	err = ParseIndexFromSite(server.URL, func(e IndexEntry) error {
+		return nil
+	}, WithValidators(validators))
+	if err != ErrNotModified {
+		t.Errorf("Expected ErrNotModified, but given %v", err)
+	}
+}
+
+func TestCrawler_Conditional(t *testing.T) {
+	etags := map[string]string{"/index.xml": `"i1"`, "/a.xml": `"a1"`, "/b.xml": `"b1"`}
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	conditional := func(w http.ResponseWriter, r *http.Request) bool {
+		etag := etags[r.URL.Path]
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return true
+		}
+		w.Header().Set("ETag", etag)
+		return false
+	}
+	mux.HandleFunc("/index.xml", func(w http.ResponseWriter, r *http.Request) {
+		if !conditional(w, r) {
+			fmt.Fprintf(w, "<sitemapindex><sitemap><loc>%[1]s/a.xml</loc></sitemap>"+
+				"<sitemap><loc>%[1]s/b.xml</loc></sitemap></sitemapindex>", server.URL)
+		}
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if !conditional(w, r) {
+			fmt.Fprintf(w, "<urlset><url><loc>http://HOST%s</loc></url></urlset>", r.URL.Path)
+		}
+	})
+
+	crawler := NewCrawler(WithConditional(NewMemoryStateStore()), WithWorkers(1))
+	crawl := func() ([]string, *CrawlReport) {
+		var locations []string
+		report, err := crawler.Crawl(context.Background(), server.URL+"/index.xml", func(e Entry) error {
+			locations = append(locations, e.GetLocation())
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Crawling failed with error %s", err)
+		}
+		return locations, report
+	}
+
+	if locations, _ := crawl(); len(locations) != 2 {
+		t.Fatalf("Expected 2 entries of the first crawl, but given %v", locations)
+	}
+
+	etags["/b.xml"] = `"b2"`
+	locations, report := crawl()
+	if len(locations) != 1 || locations[0] != "http://HOST/b.xml" {
+		t.Errorf("Expected only the entry of the changed sitemap, but given %v", locations)
+	}
+	if len(report.Sitemaps) != 3 || !report.Sitemaps[0].NotModified || !report.Sitemaps[1].NotModified ||
+		report.Sitemaps[2].NotModified {
+		t.Errorf("Unexpected reports %+v", report.Sitemaps)
+	}
+}
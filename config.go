@@ -0,0 +1,332 @@
+package sitemap
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// Config is a configuration of crawls which can be kept in a JSON file, so
+// recurring crawls are reproducible and reviewable:
+//
+//	{
+//		"sitemaps": ["https://example.com/sitemap.xml"],
+//		"proxies": ["http://proxy-1:3128", "http://proxy-2:3128"],
+//		"proxy_list": "/etc/sitemap/proxies.txt",
+//		"proxy_refresh": "5m",
+//		"resolve": ["staging.example.com:443:10.0.0.5"],
+//		"request_rate": 10,
+//		"retry": {"attempts": 4, "base_delay": "500ms", "max_delay": "30s"},
+//		"filters": {"include": ["*/blog/*"], "modified_since": "2019-01-01"},
+//		"budget": {"max_requests": 10000, "cost_per_gb": 4.5, "max_cost": 20},
+//		"schedule": ["01:00-05:00", "Sat,Sun 00:00-24:00"],
+//		"timezone": "Europe/Berlin",
+//		"outputs": ["urls.txt", "ndjson:entries.ndjson"],
+//		"domains": {
+//			"slow.example.org": {"request_rate": 1, "host_concurrency": 1}
+//		}
+//	}
+//
+// Durations are strings like "1m30s", dates are RFC 3339 datetimes or dates.
+// A proxy list is a file path or an http(s) URL, see ProxyListFile and ProxyListURL.
+// Resolve entries pin hosts to addresses, see WithResolve.
+// Schedule windows are in the format of ParseWindow in the timezone, UTC by default.
+// Outputs aren't used by the package, they are for tools like the sitemap command.
+type Config struct {
+	Sitemaps []string `json:"sitemaps,omitempty"`
+	Outputs  []string `json:"outputs,omitempty"`
+
+	DomainConfig
+	Workers int `json:"workers,omitempty"`
+
+	// Domains contains overrides of settings per host.
+	Domains map[string]DomainConfig `json:"domains,omitempty"`
+}
+
+// DomainConfig contains settings which can be overridden per domain.
+// Zero values mean settings aren't set.
+type DomainConfig struct {
+	Proxies         []string      `json:"proxies,omitempty"`
+	ProxyList       string        `json:"proxy_list,omitempty"`
+	ProxyRefresh    string        `json:"proxy_refresh,omitempty"`
+	Resolve         []string      `json:"resolve,omitempty"`
+	RequestRate     float64       `json:"request_rate,omitempty"`
+	HostConcurrency int           `json:"host_concurrency,omitempty"`
+	Retry           *RetryConfig  `json:"retry,omitempty"`
+	Filters         *FilterConfig `json:"filters,omitempty"`
+	Budget          *Budget       `json:"budget,omitempty"`
+	Schedule        []string      `json:"schedule,omitempty"`
+	Timezone        string        `json:"timezone,omitempty"`
+}
+
+// RetryConfig is a configuration of RetryPolicy. Omitted fields are taken
+// from DefaultRetryPolicy.
+type RetryConfig struct {
+	Attempts  int     `json:"attempts,omitempty"`
+	BaseDelay string  `json:"base_delay,omitempty"`
+	MaxDelay  string  `json:"max_delay,omitempty"`
+	Jitter    float64 `json:"jitter,omitempty"`
+	Statuses  []int   `json:"statuses,omitempty"`
+}
+
+// FilterConfig is a configuration of entry filters.
+//
+// Include and Exclude are glob patterns of URLs, see WithURLGlob. An entry is
+// kept if it matches any of Include patterns (any entry if Include is empty)
+// and doesn't match any of Exclude patterns.
+type FilterConfig struct {
+	Include       []string `json:"include,omitempty"`
+	Exclude       []string `json:"exclude,omitempty"`
+	ModifiedSince string   `json:"modified_since,omitempty"`
+	MaxEntries    int      `json:"max_entries,omitempty"`
+	Normalize     bool     `json:"normalize,omitempty"`
+}
+
+// LoadConfig reads a configuration from a JSON file.
+func LoadConfig(path string) (*Config, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	config, err := ReadConfig(file)
+	if err != nil {
+		return nil, fmt.Errorf("sitemap: invalid config %s: %v", path, err)
+	}
+	return config, nil
+}
+
+// ReadConfig reads a configuration in JSON which provides by the reader.
+// Unknown fields are errors, so typos don't silently disable settings.
+//
+// Only JSON is supported. YAML would need a third-party decoder, while the
+// module depends on golang.org/x/net only; convert YAML files to JSON first,
+// e.g. by yq -o json.
+func ReadConfig(reader io.Reader) (*Config, error) {
+	decoder := json.NewDecoder(reader)
+	decoder.DisallowUnknownFields()
+
+	config := new(Config)
+	if err := decoder.Decode(config); err != nil {
+		return nil, err
+	}
+
+	if _, err := config.Options(""); err != nil {
+		return nil, err
+	}
+	for host := range config.Domains {
+		if _, err := config.Options(host); err != nil {
+			return nil, fmt.Errorf("domain %s: %v", host, err)
+		}
+	}
+	return config, nil
+}
+
+// Options returns options of the configuration for crawls of the host.
+// Settings of the domain override the common ones.
+func (c *Config) Options(host string) ([]Option, error) {
+	d := c.DomainConfig
+	if override, ok := c.Domains[strings.ToLower(host)]; ok {
+		d = d.merge(override)
+	}
+
+	var opts []Option
+	if c.Workers > 0 {
+		opts = append(opts, WithWorkers(c.Workers))
+	}
+	if len(d.Proxies) > 0 {
+		opts = append(opts, WithProxies(d.Proxies...))
+	}
+	if d.ProxyList != "" {
+		provider, err := d.proxyProvider()
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithProxyProvider(provider))
+	}
+	if len(d.Resolve) > 0 {
+		if _, err := parseResolve(d.Resolve); err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithResolve(d.Resolve...))
+	}
+	if d.RequestRate > 0 {
+		opts = append(opts, WithRequestRate(d.RequestRate))
+	}
+	if d.HostConcurrency > 0 {
+		opts = append(opts, WithHostConcurrency(d.HostConcurrency))
+	}
+
+	if d.Retry != nil {
+		policy, err := d.Retry.policy()
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithRetry(policy))
+	}
+
+	if d.Budget != nil {
+		opts = append(opts, WithBudget(*d.Budget))
+	}
+	if len(d.Schedule) > 0 {
+		schedule, err := d.schedule()
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithSchedule(schedule))
+	}
+
+	if d.Filters != nil {
+		filters, err := d.Filters.options()
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, filters...)
+	}
+	return opts, nil
+}
+
+// NewCrawler creates a crawler with options of the configuration and overrides
+// of its domains. The opts are applied to all crawls before ones of the configuration.
+func (c *Config) NewCrawler(opts ...Option) (*Crawler, error) {
+	crawler := NewCrawler(opts...)
+
+	var err error
+	if crawler.defaults, err = c.Options(""); err != nil {
+		return nil, err
+	}
+	for host := range c.Domains {
+		hostOpts, err := c.Options(host)
+		if err != nil {
+			return nil, err
+		}
+		crawler.SetHostOptions(host, hostOpts...)
+	}
+	return crawler, nil
+}
+
+func (d DomainConfig) merge(override DomainConfig) DomainConfig {
+	if override.Proxies != nil {
+		d.Proxies = override.Proxies
+	}
+	if override.ProxyList != "" {
+		d.ProxyList = override.ProxyList
+	}
+	if override.ProxyRefresh != "" {
+		d.ProxyRefresh = override.ProxyRefresh
+	}
+	if override.Resolve != nil {
+		d.Resolve = override.Resolve
+	}
+	if override.RequestRate != 0 {
+		d.RequestRate = override.RequestRate
+	}
+	if override.HostConcurrency != 0 {
+		d.HostConcurrency = override.HostConcurrency
+	}
+	if override.Retry != nil {
+		d.Retry = override.Retry
+	}
+	if override.Filters != nil {
+		d.Filters = override.Filters
+	}
+	if override.Budget != nil {
+		d.Budget = override.Budget
+	}
+	if override.Schedule != nil {
+		d.Schedule = override.Schedule
+	}
+	if override.Timezone != "" {
+		d.Timezone = override.Timezone
+	}
+	return d
+}
+
+func (d DomainConfig) proxyProvider() (ProxyProvider, error) {
+	refresh := defaultProxyRefresh
+	if d.ProxyRefresh != "" {
+		var err error
+		if refresh, err = time.ParseDuration(d.ProxyRefresh); err != nil {
+			return nil, err
+		}
+	}
+
+	provider := ProxyListFile(d.ProxyList)
+	if strings.HasPrefix(d.ProxyList, "http://") || strings.HasPrefix(d.ProxyList, "https://") {
+		provider = ProxyListURL(d.ProxyList)
+	}
+	return ReloadProxies(provider, refresh), nil
+}
+
+func (d DomainConfig) schedule() (Schedule, error) {
+	location, err := time.LoadLocation(d.Timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	schedule := make(Schedule, len(d.Schedule))
+	for i, value := range d.Schedule {
+		if schedule[i], err = ParseWindow(value, location); err != nil {
+			return nil, err
+		}
+	}
+	return schedule, nil
+}
+
+func (r *RetryConfig) policy() (RetryPolicy, error) {
+	policy := DefaultRetryPolicy()
+	if r.Attempts > 0 {
+		policy.Attempts = r.Attempts
+	}
+	if r.Jitter > 0 {
+		policy.Jitter = r.Jitter
+	}
+	if r.Statuses != nil {
+		policy.Statuses = r.Statuses
+	}
+
+	var err error
+	if r.BaseDelay != "" {
+		if policy.BaseDelay, err = time.ParseDuration(r.BaseDelay); err != nil {
+			return policy, err
+		}
+	}
+	if r.MaxDelay != "" {
+		if policy.MaxDelay, err = time.ParseDuration(r.MaxDelay); err != nil {
+			return policy, err
+		}
+	}
+	return policy, nil
+}
+
+func (f *FilterConfig) options() ([]Option, error) {
+	var opts []Option
+	if f.Normalize {
+		opts = append(opts, WithURLNormalization())
+	}
+	if len(f.Include) > 0 {
+		opts = append(opts, WithURLGlob(f.Include...))
+	}
+	if len(f.Exclude) > 0 {
+		excluded := globMatcher(f.Exclude)
+		opts = append(opts, WithURLFilter(func(location string) bool {
+			return !excluded(location)
+		}))
+	}
+	if f.MaxEntries > 0 {
+		opts = append(opts, WithMaxEntries(f.MaxEntries))
+	}
+
+	if f.ModifiedSince != "" {
+		since := parseDateTime(f.ModifiedSince)
+		if since == nil {
+			return nil, fmt.Errorf("invalid modified_since %q", f.ModifiedSince)
+		}
+		opts = append(opts, WithModifiedSince(*since))
+	}
+	return opts, nil
+}
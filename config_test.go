@@ -0,0 +1,92 @@
+package sitemap
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testConfig = `{
+	"sitemaps": ["https://example.com/sitemap.xml"],
+	"outputs": ["urls.txt"],
+	"request_rate": 10,
+	"retry": {"attempts": 2, "base_delay": "1ms"},
+	"filters": {"include": ["*/blog/*"], "exclude": ["*/blog/drafts/*"], "modified_since": "2019-01-01"},
+	"domains": {
+		"127.0.0.1": {"filters": {"max_entries": 1}}
+	}
+}`
+
+func TestReadConfig(t *testing.T) {
+	config, err := ReadConfig(strings.NewReader(testConfig))
+	if err != nil {
+		t.Fatalf("Reading failed with error %s", err)
+	}
+
+	if len(config.Sitemaps) != 1 || config.RequestRate != 10 || config.Retry.Attempts != 2 {
+		t.Errorf("Unexpected config %+v", config)
+	}
+
+	opts, err := config.Options("example.com")
+	if err != nil {
+		t.Fatalf("Options failed with error %s", err)
+	}
+	o := newOptions(opts)
+	if o.retry.Attempts != 2 || o.retry.BaseDelay != time.Millisecond || o.requestRate != 10 {
+		t.Errorf("Unexpected retry policy %+v or request rate %f", o.retry, o.requestRate)
+	}
+	if !o.modifiedSince.Equal(time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected modified since %s", o.modifiedSince)
+	}
+
+	data := `<urlset>
+<url><loc>http://example.com/blog/a</loc></url>
+<url><loc>http://example.com/blog/drafts/b</loc></url>
+<url><loc>http://example.com/shop/c</loc></url>
+</urlset>`
+	if locations := parseLocations(t, data, opts...); locations != "/blog/a" {
+		t.Errorf("Unexpected filtered locations %q", locations)
+	}
+}
+
+func TestReadConfig_Errors(t *testing.T) {
+	configs := []string{
+		`{"request_rat": 10}`,
+		`{"retry": {"base_delay": "soon"}}`,
+		`{"domains": {"example.com": {"filters": {"modified_since": "yesterday"}}}}`,
+		`{"resolve": ["example.com:443"]}`,
+	}
+	for _, config := range configs {
+		if _, err := ReadConfig(strings.NewReader(config)); err == nil {
+			t.Errorf("Expected error of config %s", config)
+		}
+	}
+}
+
+func TestConfig_NewCrawler(t *testing.T) {
+	server := newCrawlServer()
+	defer server.Close()
+
+	config, err := ReadConfig(strings.NewReader(testConfig))
+	if err != nil {
+		t.Fatalf("Reading failed with error %s", err)
+	}
+	crawler, err := config.NewCrawler()
+	if err != nil {
+		t.Fatalf("Creating crawler failed with error %s", err)
+	}
+
+	// the domain override replaces common filters, so only max_entries is applied
+	var locations []string
+	_, err = crawler.Crawl(context.Background(), server.URL+"/sitemap.xml", func(e Entry) error {
+		locations = append(locations, e.GetLocation())
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Crawling failed with error %s", err)
+	}
+	if len(locations) != 1 || locations[0] != "http://example.com/a" {
+		t.Errorf("Unexpected locations %v", locations)
+	}
+}
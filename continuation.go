@@ -0,0 +1,171 @@
+package sitemap
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Quota caps a single crawl, e.g. a run of a scheduled job which spreads
+// a giant sitemap index across several runs. Zero values mean no limit.
+//
+// MaxEntries limits entries passed to the consumer, MaxSitemaps limits
+// downloaded documents including indexes.
+type Quota struct {
+	MaxEntries  int `json:"max_entries,omitempty"`
+	MaxSitemaps int `json:"max_sitemaps,omitempty"`
+}
+
+// errQuotaExhausted stops downloading and parsing when the quota is exhausted.
+var errQuotaExhausted = errors.New("sitemap: quota is exhausted")
+
+// WithQuota caps crawls of Crawler by the quota. A crawl which exhausts it
+// stops without an error and its report has the continuation token of sitemaps
+// which are left, pass it to Crawler.Resume in the next run. A sitemap which is
+// interrupted in the middle is resumed after its consumed entries.
+//
+// Keep in mind. Deletions (see WithDeletions) aren't reported by crawls which
+// exhaust the quota and by resumed crawls, since they see a part of the site.
+func WithQuota(quota Quota) Option {
+	return func(o *options) {
+		o.quota = quota
+	}
+}
+
+// continuation is the content of a continuation token.
+type continuation struct {
+	Root     string             `json:"root"`
+	Sitemaps []continuedSitemap `json:"sitemaps"`
+}
+
+// continuedSitemap is a sitemap which is left for the next run. Skip is the
+// count of its entries which are already consumed.
+type continuedSitemap struct {
+	URL   string `json:"url"`
+	Depth int    `json:"depth,omitempty"`
+	Skip  int    `json:"skip,omitempty"`
+}
+
+func decodeContinuation(token string) (*continuation, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("sitemap: invalid continuation token: %v", err)
+	}
+	var c continuation
+	if err = json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("sitemap: invalid continuation token: %v", err)
+	}
+	if c.Root == "" || len(c.Sitemaps) == 0 {
+		return nil, fmt.Errorf("sitemap: invalid continuation token: no sitemaps")
+	}
+	return &c, nil
+}
+
+func (c *continuation) encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// Resume continues the crawl by the continuation token of its report, see
+// WithQuota. The crawl is like Crawl, but it walks only sitemaps which are left,
+// its report has the next token if the quota is exhausted again. Use the same
+// options as in the previous run.
+func (c *Crawler) Resume(ctx context.Context, token string, consumer EntryConsumer) (*CrawlReport, error) {
+	cont, err := decodeContinuation(token)
+	if err != nil {
+		return &CrawlReport{}, err
+	}
+	return c.crawlFrom(ctx, cont, consumer, nil)
+}
+
+// quotaTracker counts entries and sitemaps of a walk and collects sitemaps
+// which are postponed when the quota is exhausted.
+type quotaTracker struct {
+	quota Quota
+
+	mu        sync.Mutex
+	entries   int
+	sitemaps  int
+	postponed []postponedSitemap
+}
+
+type postponedSitemap struct {
+	seq     int
+	sitemap continuedSitemap
+}
+
+// newQuotaTracker returns nil if the quota has no limits.
+func newQuotaTracker(quota Quota) *quotaTracker {
+	if quota == (Quota{}) {
+		return nil
+	}
+	return &quotaTracker{quota: quota}
+}
+
+// sitemap counts a document which is going to be downloaded.
+func (t *quotaTracker) sitemap() error {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if (t.quota.MaxSitemaps > 0 && t.sitemaps >= t.quota.MaxSitemaps) ||
+		(t.quota.MaxEntries > 0 && t.entries >= t.quota.MaxEntries) {
+		return errQuotaExhausted
+	}
+	t.sitemaps++
+	return nil
+}
+
+// entry counts an entry which is going to be consumed.
+func (t *quotaTracker) entry() error {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.quota.MaxEntries > 0 && t.entries >= t.quota.MaxEntries {
+		return errQuotaExhausted
+	}
+	t.entries++
+	return nil
+}
+
+// exhausted reports whether the error of a sitemap is caused by the quota.
+func (t *quotaTracker) exhausted(err error) bool {
+	return t != nil && errors.Is(err, errQuotaExhausted)
+}
+
+// postpone leaves the sitemap for the next run. Skip is the count of entries
+// consumed by previous runs, the report is nil if the sitemap isn't downloaded.
+func (t *quotaTracker) postpone(item queuedSitemap, skip int, report *SitemapReport) {
+	if report != nil {
+		skip += report.Entries
+		report.Err = nil
+	}
+	t.mu.Lock()
+	t.postponed = append(t.postponed, postponedSitemap{seq: item.seq,
+		sitemap: continuedSitemap{URL: item.url, Depth: item.depth, Skip: skip}})
+	t.mu.Unlock()
+}
+
+// token returns the continuation token of postponed sitemaps in order of
+// discovery, it is empty if nothing is postponed.
+func (t *quotaTracker) token(root string) string {
+	if t == nil || len(t.postponed) == 0 {
+		return ""
+	}
+	sort.Slice(t.postponed, func(i, j int) bool { return t.postponed[i].seq < t.postponed[j].seq })
+
+	c := continuation{Root: root}
+	for _, p := range t.postponed {
+		c.Sitemaps = append(c.Sitemaps, p.sitemap)
+	}
+	return c.encode()
+}
@@ -0,0 +1,84 @@
+package sitemap
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func newQuotaServer() *httptest.Server {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+
+	mux.HandleFunc("/index.xml", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "<sitemapindex><sitemap><loc>%[1]s/1.xml</loc></sitemap>"+
+			"<sitemap><loc>%[1]s/2.xml</loc></sitemap>"+
+			"<sitemap><loc>%[1]s/3.xml</loc></sitemap></sitemapindex>", server.URL)
+	})
+	for i := 1; i <= 3; i++ {
+		i := i
+		mux.HandleFunc(fmt.Sprintf("/%d.xml", i), func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, "<urlset><url><loc>http://example.com/%[1]d/a</loc></url>"+
+				"<url><loc>http://example.com/%[1]d/b</loc></url></urlset>", i)
+		})
+	}
+	return server
+}
+
+func TestCrawler_Resume(t *testing.T) {
+	server := newQuotaServer()
+	defer server.Close()
+
+	cases := []struct {
+		quota Quota
+		runs  int
+	}{
+		{Quota{MaxEntries: 3}, 2},
+		{Quota{MaxEntries: 1}, 6},
+		{Quota{MaxSitemaps: 2}, 2},
+		{Quota{MaxEntries: 10}, 1},
+	}
+	for _, c := range cases {
+		crawler := NewCrawler(WithQuota(c.quota), WithWorkers(1))
+
+		var locations []string
+		consumer := func(e Entry) error {
+			locations = append(locations, e.GetLocation())
+			return nil
+		}
+		report, err := crawler.Crawl(context.Background(), server.URL+"/index.xml", consumer)
+		runs := 1
+		for err == nil && report.Continuation != "" {
+			if report.Root != server.URL+"/index.xml" || report.Failed != 0 {
+				t.Errorf("Unexpected report %+v of quota %+v", report, c.quota)
+			}
+			if c.quota.MaxEntries > 0 && report.Entries > c.quota.MaxEntries {
+				t.Errorf("Expected at most %d entries, but given %d", c.quota.MaxEntries, report.Entries)
+			}
+			report, err = crawler.Resume(context.Background(), report.Continuation, consumer)
+			runs++
+		}
+		if err != nil {
+			t.Fatalf("Crawling failed with error %s", err)
+		}
+
+		sort.Strings(locations)
+		expected := []string{"http://example.com/1/a", "http://example.com/1/b", "http://example.com/2/a",
+			"http://example.com/2/b", "http://example.com/3/a", "http://example.com/3/b"}
+		if !reflect.DeepEqual(locations, expected) || runs != c.runs {
+			t.Errorf("Expected entries %v in %d runs of quota %+v, but given %v in %d runs", expected, c.runs, c.quota, locations, runs)
+		}
+	}
+}
+
+func TestCrawler_ResumeInvalid(t *testing.T) {
+	for _, token := range []string{"", "!!!", "e30"} {
+		if _, err := NewCrawler().Resume(context.Background(), token, func(e Entry) error { return nil }); err == nil {
+			t.Errorf("Expected an error of token %q", token)
+		}
+	}
+}
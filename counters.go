@@ -0,0 +1,47 @@
+package sitemap
+
+// Counter names constants set of WithCounters.
+const (
+	CounterRequests      = "requests"       // Requests including retries and checks of pages
+	CounterRequestErrors = "request_errors" // Requests without responses
+	CounterRetries       = "retries"        // Retried requests
+	CounterBytes         = "bytes"          // Bytes of received bodies
+	CounterSitemaps      = "sitemaps"       // Documents processed by functions which walk sitemap indexes
+	CounterSitemapErrors = "sitemap_errors" // Walked documents which can't be fetched or parsed
+	CounterNotModified   = "not_modified"   // Walked documents which are skipped as unchanged
+	CounterEntries       = "entries"        // Entries passed to consumers
+)
+
+// CounterFunc is a type represents a sink of counters of WithCounters.
+type CounterFunc func(name string, delta int64)
+
+// WithCounters makes parsing and fetching functions add deltas of cumulative
+// counters to the sink, e.g. to a metrics library of the service. The sink is
+// called concurrently by concurrent functions, so it must be safe for it. See
+// the sitemapexpvar package for publishing of counters by expvar.
+func WithCounters(add CounterFunc) Option {
+	return func(o *options) {
+		o.counters = add
+	}
+}
+
+// count adds the delta to the counter of WithCounters.
+func (o *options) count(name string, delta int64) {
+	if o.counters != nil {
+		o.counters(name, delta)
+	}
+}
+
+// countReport counts the walked document of the report.
+func (o *options) countReport(report *SitemapReport) {
+	if o.counters == nil {
+		return
+	}
+	o.count(CounterSitemaps, 1)
+	if report.Err != nil {
+		o.count(CounterSitemapErrors, 1)
+	}
+	if report.NotModified {
+		o.count(CounterNotModified, 1)
+	}
+}
@@ -0,0 +1,37 @@
+package sitemap
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+func TestWithCounters(t *testing.T) {
+	server := newCrawlServer()
+	defer server.Close()
+
+	var mu sync.Mutex
+	counters := make(map[string]int64)
+	add := func(name string, delta int64) {
+		mu.Lock()
+		counters[name] += delta
+		mu.Unlock()
+	}
+
+	report, _ := NewCrawler(WithCounters(add)).Crawl(context.Background(), server.URL+"/index.xml", func(e Entry) error {
+		return nil
+	})
+
+	expected := map[string]int64{
+		CounterRequests:      4,
+		CounterSitemaps:      4,
+		CounterSitemapErrors: 2,
+		CounterEntries:       int64(report.Entries),
+		CounterBytes:         report.Usage.Bytes,
+	}
+	for name, value := range expected {
+		if counters[name] != value {
+			t.Errorf("Expected %d of %s, but given %d", value, name, counters[name])
+		}
+	}
+}
@@ -0,0 +1,262 @@
+package sitemap
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Issue codes of extensions of Validate.
+const (
+	IssueInvalidImage     IssueCode = "invalid-image"     // An image:image has no valid loc or an URL has too many images
+	IssueInvalidVideo     IssueCode = "invalid-video"     // A video:video misses required values or has invalid ones
+	IssueInvalidNews      IssueCode = "invalid-news"      // A news:news misses required values or has invalid ones
+	IssueInvalidAlternate IssueCode = "invalid-alternate" // An xhtml:link alternate has no hreflang or no absolute href
+	IssueDeprecatedMobile IssueCode = "deprecated-mobile" // A mobile:mobile annotation is ignored by search engines
+)
+
+// Extension is a type represents a Google sitemap extension.
+type Extension = string
+
+// Extensions constants set.
+const (
+	ExtensionImage  Extension = "image"  // image:image elements
+	ExtensionVideo  Extension = "video"  // video:video elements
+	ExtensionNews   Extension = "news"   // news:news elements
+	ExtensionXHTML  Extension = "xhtml"  // xhtml:link elements
+	ExtensionMobile Extension = "mobile" // mobile:mobile elements
+)
+
+// maxImages is the max count of images of an URL.
+const maxImages = 1000
+
+// ExtensionCoverage is the use of an extension by a sitemap. Entries is the
+// count of URLs which have elements of the extension, Elements is the count of
+// the elements, Errors and Warnings are counts of issues of the elements.
+type ExtensionCoverage struct {
+	Extension Extension
+	Entries   int
+	Elements  int
+	Errors    int
+	Warnings  int
+}
+
+// Coverage is the coverage matrix of extensions of a validated sitemap, it is
+// a one-shot overview of complex media sitemaps. Entries is the count of URLs,
+// Extensions has coverages of all extensions in order of Extension constants,
+// Entries of absent extensions are zero.
+type Coverage struct {
+	Entries    int
+	Extensions []ExtensionCoverage
+}
+
+// Get returns the coverage of the extension.
+func (c *Coverage) Get(extension Extension) ExtensionCoverage {
+	for _, ec := range c.Extensions {
+		if ec.Extension == extension {
+			return ec
+		}
+	}
+	return ExtensionCoverage{Extension: extension}
+}
+
+// WithCoverage makes Validate, ValidateFromFile and ValidateFromSite fill the
+// coverage of extensions of the sitemap.
+func WithCoverage(coverage *Coverage) Option {
+	return func(o *options) {
+		o.coverage = coverage
+	}
+}
+
+func newCoverage() *Coverage {
+	return &Coverage{Extensions: []ExtensionCoverage{
+		{Extension: ExtensionImage},
+		{Extension: ExtensionVideo},
+		{Extension: ExtensionNews},
+		{Extension: ExtensionXHTML},
+		{Extension: ExtensionMobile},
+	}}
+}
+
+func (c *Coverage) extension(extension Extension) *ExtensionCoverage {
+	for i := range c.Extensions {
+		if c.Extensions[i].Extension == extension {
+			return &c.Extensions[i]
+		}
+	}
+	return nil
+}
+
+// rawImage, rawVideo, rawNews and rawLink are elements of extensions with
+// values as they are.
+type rawImage struct {
+	Location string `xml:"loc"`
+}
+
+type rawVideo struct {
+	ThumbnailLocation string `xml:"thumbnail_loc"`
+	Title             string `xml:"title"`
+	Description       string `xml:"description"`
+	ContentLocation   string `xml:"content_loc"`
+	PlayerLocation    string `xml:"player_loc"`
+	Duration          string `xml:"duration"`
+	Rating            string `xml:"rating"`
+	PublicationDate   string `xml:"publication_date"`
+	ExpirationDate    string `xml:"expiration_date"`
+}
+
+type rawNews struct {
+	PublicationName     string `xml:"publication>name"`
+	PublicationLanguage string `xml:"publication>language"`
+	PublicationDate     string `xml:"publication_date"`
+	Title               string `xml:"title"`
+}
+
+type rawLink struct {
+	Rel      string `xml:"rel,attr"`
+	Hreflang string `xml:"hreflang,attr"`
+	Href     string `xml:"href,attr"`
+}
+
+// checkExtensions checks elements of extensions of the URL and counts them.
+func (v *validator) checkExtensions(raw *rawElement) {
+	location := ""
+	if raw.Location != nil {
+		location = strings.TrimSpace(*raw.Location)
+	}
+	v.coverage.Entries++
+
+	v.use(ExtensionImage, len(raw.Images))
+	for i, image := range raw.Images {
+		if image.Location == "" || !isAbsoluteURL(strings.TrimSpace(image.Location)) {
+			v.reportExtension(ExtensionImage, Issue{Code: IssueInvalidImage, Severity: SeverityError, Location: location,
+				Message: fmt.Sprintf("image %d has no absolute http or https loc", i+1)})
+		}
+	}
+	if len(raw.Images) > maxImages {
+		v.reportExtension(ExtensionImage, Issue{Code: IssueInvalidImage, Severity: SeverityError, Location: location,
+			Message: fmt.Sprintf("URL has %d images, the limit is %d", len(raw.Images), maxImages)})
+	}
+
+	v.use(ExtensionVideo, len(raw.Videos))
+	for i := range raw.Videos {
+		for _, problem := range videoProblems(&raw.Videos[i]) {
+			v.reportExtension(ExtensionVideo, Issue{Code: IssueInvalidVideo, Severity: SeverityError, Location: location,
+				Message: fmt.Sprintf("video %d %s", i+1, problem)})
+		}
+	}
+
+	v.use(ExtensionNews, len(raw.News))
+	for i := range raw.News {
+		for _, problem := range newsProblems(&raw.News[i]) {
+			v.reportExtension(ExtensionNews, Issue{Code: IssueInvalidNews, Severity: SeverityError, Location: location,
+				Message: "news " + problem})
+		}
+	}
+	if len(raw.News) > 1 {
+		v.reportExtension(ExtensionNews, Issue{Code: IssueInvalidNews, Severity: SeverityError, Location: location,
+			Message: fmt.Sprintf("URL has %d news elements, the limit is 1", len(raw.News))})
+	}
+
+	v.use(ExtensionXHTML, len(raw.Links))
+	for _, link := range raw.Links {
+		if !strings.EqualFold(link.Rel, "alternate") {
+			continue
+		}
+		if link.Hreflang == "" || !isAbsoluteURL(strings.TrimSpace(link.Href)) {
+			v.reportExtension(ExtensionXHTML, Issue{Code: IssueInvalidAlternate, Severity: SeverityError, Location: location,
+				Message: fmt.Sprintf("alternate link %q of %q has no hreflang or no absolute href", link.Href, link.Hreflang)})
+		}
+	}
+
+	v.use(ExtensionMobile, len(raw.Mobile))
+	if len(raw.Mobile) > 0 {
+		v.reportExtension(ExtensionMobile, Issue{Code: IssueDeprecatedMobile, Severity: SeverityWarning, Location: location,
+			Message: "mobile:mobile annotation is deprecated and ignored", Suggestion: "remove the mobile namespace and elements"})
+	}
+}
+
+// use counts elements of the extension of an URL.
+func (v *validator) use(extension Extension, elements int) {
+	if elements == 0 {
+		return
+	}
+	ec := v.coverage.extension(extension)
+	ec.Entries++
+	ec.Elements += elements
+}
+
+// reportExtension reports the issue and counts it for the extension.
+func (v *validator) reportExtension(extension Extension, issue Issue) {
+	v.report(issue)
+	ec := v.coverage.extension(extension)
+	switch issue.Severity {
+	case SeverityError:
+		ec.Errors++
+	case SeverityWarning:
+		ec.Warnings++
+	}
+}
+
+// videoProblems returns descriptions of problems of the video.
+func videoProblems(video *rawVideo) []string {
+	var problems []string
+	for _, required := range []struct{ name, value string }{
+		{"thumbnail_loc", video.ThumbnailLocation},
+		{"title", video.Title},
+		{"description", video.Description},
+	} {
+		if strings.TrimSpace(required.value) == "" {
+			problems = append(problems, "has no "+required.name)
+		}
+	}
+	if strings.TrimSpace(video.ContentLocation) == "" && strings.TrimSpace(video.PlayerLocation) == "" {
+		problems = append(problems, "has neither content_loc nor player_loc")
+	}
+	if value := strings.TrimSpace(video.Duration); value != "" {
+		if duration, err := strconv.Atoi(value); err != nil || duration < 1 || duration > 28800 {
+			problems = append(problems, fmt.Sprintf("duration %q isn't from 1 to 28800 seconds", video.Duration))
+		}
+	}
+	if value := strings.TrimSpace(video.Rating); value != "" {
+		if rating, err := strconv.ParseFloat(value, 32); err != nil || rating < 0 || rating > 5 {
+			problems = append(problems, fmt.Sprintf("rating %q isn't a number from 0.0 to 5.0", video.Rating))
+		}
+	}
+	for _, date := range []struct{ name, value string }{
+		{"publication_date", video.PublicationDate},
+		{"expiration_date", video.ExpirationDate},
+	} {
+		if value := strings.TrimSpace(date.value); value != "" && !isW3CDatetime(value) {
+			problems = append(problems, fmt.Sprintf("%s %q isn't a W3C datetime", date.name, date.value))
+		}
+	}
+	return problems
+}
+
+// newsProblems returns descriptions of problems of the news article.
+func newsProblems(news *rawNews) []string {
+	var problems []string
+	for _, required := range []struct{ name, value string }{
+		{"publication name", news.PublicationName},
+		{"publication language", news.PublicationLanguage},
+		{"title", news.Title},
+	} {
+		if strings.TrimSpace(required.value) == "" {
+			problems = append(problems, "has no "+required.name)
+		}
+	}
+	if value := strings.TrimSpace(news.PublicationDate); value == "" {
+		problems = append(problems, "has no publication_date")
+	} else if !isW3CDatetime(value) {
+		problems = append(problems, fmt.Sprintf("publication_date %q isn't a W3C datetime", news.PublicationDate))
+	}
+	return problems
+}
+
+// isAbsoluteURL reports whether the value is an absolute http or https URL.
+func isAbsoluteURL(value string) bool {
+	u, err := url.Parse(value)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
@@ -0,0 +1,206 @@
+package sitemap
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SitemapReport is a report of a single downloaded sitemap or sitemap index.
+//
+// FinalURL is the URL after redirects, ContentType is the type of the response.
+// Both are empty if the document can't be downloaded.
+// Entries is the count of entries passed to the consumer.
+// Children is the count of sitemaps of an index.
+// NotModified reports whether the document is skipped as unchanged, see WithConditional.
+// Err is the error of downloading or parsing, it is nil for successful ones.
+//
+// CompressedSize is the count of bytes of the body as it is received and
+// UncompressedSize is the count after decompression of gzipped documents, they
+// are equal for plain documents. Keep in mind, bodies with Content-Encoding
+// are decompressed by the HTTP transport, they are counted decompressed.
+// DownloadTime is the time of the request including retries and of receiving
+// the body, ParseTime is the rest of the time of processing the body including
+// decompression and calls of the consumer.
+//
+// Hash is the hex-encoded SHA-256 of the body after decompression, so identical
+// documents and unchanged ones across crawls are detected cheaply, see
+// CrawlReport.Duplicates. It is empty if the document isn't downloaded or
+// processed completely.
+//
+// Archived is the time of the archived copy which is parsed instead of
+// the unreachable document, see WithArchiveFallback. It is zero for live ones.
+type SitemapReport struct {
+	URL         string
+	FinalURL    string
+	ContentType string
+	Index       bool
+	NotModified bool
+	Entries     int
+	Children    int
+	Err         error
+
+	CompressedSize   int64
+	UncompressedSize int64
+	DownloadTime     time.Duration
+	ParseTime        time.Duration
+
+	Hash     string
+	Archived time.Time
+}
+
+// CompressionRatio returns the ratio of uncompressed and compressed sizes of
+// the document, it is 1 for plain documents and 0 if nothing is received.
+func (r *SitemapReport) CompressionRatio() float64 {
+	if r.CompressedSize == 0 {
+		return 0
+	}
+	return float64(r.UncompressedSize) / float64(r.CompressedSize)
+}
+
+// CrawlReport is a report of a crawl.
+//
+// Sitemaps contains reports of all downloaded documents in order of discovery.
+// Failed is the count of documents which can't be downloaded or parsed.
+// Usage is the tally of requests and traffic of the crawl, see WithBudget.
+// Deleted is the count of URLs reported as deleted, see WithDeletions.
+// Continuation is the token of sitemaps which are left if the quota is
+// exhausted, see WithQuota and Resume. It is empty if the crawl is complete.
+type CrawlReport struct {
+	Root     string
+	Started  time.Time
+	Finished time.Time
+	Entries  int
+	Failed   int
+	Usage    Usage
+	Deleted  int
+	Sitemaps []SitemapReport
+
+	Continuation string
+}
+
+// Duplicates returns groups of URLs of sitemaps with identical bodies, e.g.
+// the same sitemap served by several URLs. Groups are in order of discovery of
+// their first sitemaps, URLs of a group are in order of discovery too.
+func (r *CrawlReport) Duplicates() [][]string {
+	groups := make(map[string]int)
+	var duplicates [][]string
+	for _, sitemap := range r.Sitemaps {
+		if sitemap.Hash == "" {
+			continue
+		}
+		i, ok := groups[sitemap.Hash]
+		if !ok {
+			i = len(duplicates)
+			groups[sitemap.Hash] = i
+			duplicates = append(duplicates, nil)
+		}
+		duplicates[i] = append(duplicates[i], sitemap.URL)
+	}
+
+	n := 0
+	for _, group := range duplicates {
+		if len(group) > 1 {
+			duplicates[n] = group
+			n++
+		}
+	}
+	return duplicates[:n]
+}
+
+// Crawler crawls sitemaps and sitemap indexes recursively. Unlike ParseFromRobots
+// it doesn't stop on sitemaps which can't be downloaded or parsed, they are
+// listed in the report instead.
+//
+// Sitemaps are downloaded and parsed concurrently by workers, WithWorkers sets
+// the overall count of them and WithHostConcurrency the count per host, e.g. 64
+// and 4. Sitemaps of a busy host don't hold workers, they crawl other hosts.
+type Crawler struct {
+	opts  []Option
+	hosts map[string][]Option
+	// defaults are applied to hosts without own options, Config sets them.
+	defaults []Option
+
+	mu      sync.Mutex
+	tenants map[string]*tenantState
+}
+
+// NewCrawler creates a new crawler. Options are applied to all crawls.
+func NewCrawler(opts ...Option) *Crawler {
+	return &Crawler{opts: opts, hosts: make(map[string][]Option), tenants: make(map[string]*tenantState)}
+}
+
+// SetHostOptions sets options which are applied after the common ones to crawls
+// of sitemaps of the host, e.g. proxies or limits of a specific site.
+// Set them before crawls, it isn't safe to call it concurrently with Crawl.
+func (c *Crawler) SetHostOptions(host string, opts ...Option) {
+	c.hosts[strings.ToLower(host)] = opts
+}
+
+// Crawl downloads the sitemap or the sitemap index, walks children of indexes and
+// for each sitemap entry calls the consumer's function. It returns an error if
+// the root document can't be downloaded or parsed, if the consumer returns an
+// error or if the context is done. The report is returned in any case.
+// Calls of the consumer are serialized, so it doesn't need to be thread-safe.
+func (c *Crawler) Crawl(ctx context.Context, sitemapURL string, consumer EntryConsumer) (*CrawlReport, error) {
+	return c.crawl(ctx, sitemapURL, consumer, nil)
+}
+
+// crawl crawls like Crawl does, the extra options are applied after all others.
+func (c *Crawler) crawl(ctx context.Context, sitemapURL string, consumer EntryConsumer, extra []Option) (*CrawlReport, error) {
+	return c.crawlFrom(ctx, &continuation{Root: sitemapURL}, consumer, extra)
+}
+
+// crawlFrom crawls sitemaps of the continuation, all of the root if there are none.
+func (c *Crawler) crawlFrom(ctx context.Context, cont *continuation, consumer EntryConsumer, extra []Option) (*CrawlReport, error) {
+	sitemapURL := cont.Root
+	report := &CrawlReport{Root: sitemapURL, Started: time.Now()}
+
+	o := newOptions(append(c.options(sitemapURL), extra...))
+	track, err := newDeletionTracker(o, sitemapURL)
+	if err != nil {
+		return report, err
+	}
+
+	w := newWalker(ctx, o, consumer)
+	w.tolerant = true
+	w.track = track
+	w.quota = newQuotaTracker(o.quota)
+	w.report = func(r SitemapReport) {
+		report.Entries += r.Entries
+		if r.Err != nil {
+			report.Failed++
+		}
+		report.Sitemaps = append(report.Sitemaps, r)
+	}
+
+	items := []queuedSitemap{{url: sitemapURL}}
+	if len(cont.Sitemaps) > 0 {
+		items, w.skips = nil, make(map[string]int)
+		for i, s := range cont.Sitemaps {
+			items = append(items, queuedSitemap{url: s.URL, depth: s.Depth, seq: i})
+			w.skips[s.URL] = s.Skip
+		}
+	}
+
+	err = w.walkQueued(items)
+	report.Continuation = w.quota.token(sitemapURL)
+	if err == nil && track != nil && report.Continuation == "" && len(cont.Sitemaps) == 0 {
+		report.Deleted, err = track.finish(report)
+	}
+	report.Finished = time.Now()
+	report.Usage = o.usage()
+	return report, err
+}
+
+func (c *Crawler) options(sitemapURL string) []Option {
+	host := c.defaults
+	if u, err := url.Parse(sitemapURL); err == nil {
+		if opts, ok := c.hosts[strings.ToLower(u.Hostname())]; ok {
+			host = opts
+		}
+	}
+	return append(append([]Option(nil), c.opts...), host...)
+}
@@ -0,0 +1,170 @@
+package sitemap
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newCrawlServer() *httptest.Server {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+
+	mux.HandleFunc("/index.xml", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "<sitemapindex><sitemap><loc>%[1]s/broken.xml</loc></sitemap>"+
+			"<sitemap><loc>%[1]s/missing.xml</loc></sitemap>"+
+			"<sitemap><loc>%[1]s/sitemap.xml</loc></sitemap></sitemapindex>", server.URL)
+	})
+	mux.HandleFunc("/broken.xml", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<urlset><url><loc>http://example.com/broken</loc></url><url>")
+	})
+	mux.HandleFunc("/sitemap.xml", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<urlset><url><loc>http://example.com/a</loc></url>"+
+			"<url><loc>http://example.com/b</loc></url></urlset>")
+	})
+
+	return server
+}
+
+func TestCrawler_Crawl(t *testing.T) {
+	server := newCrawlServer()
+	defer server.Close()
+
+	var locations []string
+	report, err := NewCrawler().Crawl(context.Background(), server.URL+"/index.xml", func(e Entry) error {
+		locations = append(locations, e.GetLocation())
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Crawling failed with error %s", err)
+	}
+
+	if len(locations) != 3 || report.Entries != 3 || report.Failed != 2 || len(report.Sitemaps) != 4 {
+		t.Errorf("Unexpected report %+v of entries %v", report, locations)
+	}
+	index, broken, missing := report.Sitemaps[0], report.Sitemaps[1], report.Sitemaps[2]
+	if !index.Index || index.Children != 3 || index.Err != nil {
+		t.Errorf("Unexpected report of the index %+v", index)
+	}
+	if _, ok := broken.Err.(*ParseError); !ok || broken.Entries != 1 {
+		t.Errorf("Expected ParseError after 1 entry, but given %+v", broken)
+	}
+	if missing.Err == nil || !strings.Contains(missing.Err.Error(), "404") {
+		t.Errorf("Expected error of 404 status, but given %v", missing.Err)
+	}
+}
+
+func TestCrawler_ConsumerError(t *testing.T) {
+	server := newCrawlServer()
+	defer server.Close()
+
+	expected := errors.New("stop")
+	report, err := NewCrawler(WithWorkers(1)).Crawl(context.Background(), server.URL+"/index.xml", func(e Entry) error {
+		return expected
+	})
+	if err != expected || len(report.Sitemaps) != 2 {
+		t.Errorf("Expected the consumer error after 2 sitemaps, but given %v and %+v", err, report.Sitemaps)
+	}
+}
+
+func TestCrawler_HostOptions(t *testing.T) {
+	server := newCrawlServer()
+	defer server.Close()
+
+	crawler := NewCrawler()
+	crawler.SetHostOptions("127.0.0.1", WithURLGlob("*/a"))
+
+	counter := 0
+	_, err := crawler.Crawl(context.Background(), server.URL+"/sitemap.xml", func(e Entry) error {
+		counter++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Crawling failed with error %s", err)
+	}
+	if counter != 1 {
+		t.Errorf("Expected 1 entry filtered by host options, but given %d", counter)
+	}
+}
+
+func TestCrawler_Sizes(t *testing.T) {
+	document := "<urlset>" + strings.Repeat("<url><loc>http://example.com/</loc></url>", 100) + "</urlset>"
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	gz.Write([]byte(document))
+	gz.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(10 * time.Millisecond)
+		w.Write(compressed.Bytes())
+	}))
+	defer server.Close()
+
+	report, err := NewCrawler().Crawl(context.Background(), server.URL, func(e Entry) error { return nil })
+	if err != nil {
+		t.Fatalf("Crawling failed with error %s", err)
+	}
+
+	r := report.Sitemaps[0]
+	if r.CompressedSize != int64(compressed.Len()) || r.UncompressedSize != int64(len(document)) {
+		t.Errorf("Expected sizes %d and %d, but given %d and %d", compressed.Len(), len(document), r.CompressedSize, r.UncompressedSize)
+	}
+	if ratio := r.CompressionRatio(); ratio <= 1 {
+		t.Errorf("Expected compression ratio above 1, but given %f", ratio)
+	}
+	if r.DownloadTime < 10*time.Millisecond || r.ParseTime <= 0 {
+		t.Errorf("Expected download time of at least 10ms and positive parse time, but given %s and %s", r.DownloadTime, r.ParseTime)
+	}
+}
+
+func TestCrawler_Hash(t *testing.T) {
+	document := "<urlset><url><loc>http://example.com/</loc></url></urlset>\n"
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	mux.HandleFunc("/index.xml", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "<sitemapindex><sitemap><loc>%[1]s/a.xml</loc></sitemap>"+
+			"<sitemap><loc>%[1]s/b.xml</loc></sitemap><sitemap><loc>%[1]s/c.xml</loc></sitemap>"+
+			"<sitemap><loc>%[1]s/broken.xml</loc></sitemap></sitemapindex>", server.URL)
+	})
+	mux.HandleFunc("/a.xml", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, document)
+	})
+	mux.HandleFunc("/b.xml", func(w http.ResponseWriter, r *http.Request) {
+		gz := gzip.NewWriter(w)
+		fmt.Fprint(gz, document)
+		gz.Close()
+	})
+	mux.HandleFunc("/c.xml", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, document+"\n")
+	})
+	mux.HandleFunc("/broken.xml", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<urlset><url>")
+	})
+
+	report, err := NewCrawler().Crawl(context.Background(), server.URL+"/index.xml", func(e Entry) error { return nil })
+	if err != nil {
+		t.Fatalf("Crawling failed with error %s", err)
+	}
+
+	sum := sha256.Sum256([]byte(document))
+	if hash := report.Sitemaps[1].Hash; hash != hex.EncodeToString(sum[:]) {
+		t.Errorf("Unexpected hash %s", hash)
+	}
+	if report.Sitemaps[3].Hash == report.Sitemaps[1].Hash || report.Sitemaps[4].Hash != "" {
+		t.Errorf("Unexpected hashes of %+v and %+v", report.Sitemaps[3], report.Sitemaps[4])
+	}
+	duplicates := report.Duplicates()
+	if len(duplicates) != 1 || strings.Join(duplicates[0], " ") != server.URL+"/a.xml "+server.URL+"/b.xml" {
+		t.Errorf("Unexpected duplicates %v", duplicates)
+	}
+}
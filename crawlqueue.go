@@ -0,0 +1,185 @@
+package sitemap
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sync"
+)
+
+// queuedSitemap is a sitemap waiting for a worker. Seq is the position of its
+// report in order of discovery.
+type queuedSitemap struct {
+	url   string
+	host  string
+	depth int
+	seq   int
+}
+
+// crawlQueue hands out sitemaps to workers, so at most perHost of them are in
+// flight for a single host. Unlike hostLimiter a busy host doesn't block
+// workers, they take sitemaps of other hosts queued after it. Zero perHost
+// means no limit.
+type crawlQueue struct {
+	perHost int
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	pending []queuedSitemap
+	active  int
+	hosts   map[string]int
+	closed  bool
+}
+
+func newCrawlQueue(perHost int) *crawlQueue {
+	q := &crawlQueue{perHost: perHost, hosts: make(map[string]int)}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+func (q *crawlQueue) push(items ...queuedSitemap) {
+	q.mu.Lock()
+	for _, item := range items {
+		item.host = item.url
+		if u, err := url.Parse(item.url); err == nil {
+			item.host = u.Host
+		}
+		q.pending = append(q.pending, item)
+	}
+	q.mu.Unlock()
+	q.cond.Broadcast()
+}
+
+// next waits for a sitemap of a host with a free slot. It returns false when
+// the queue is closed or there is nothing to do and nothing in flight.
+func (q *crawlQueue) next() (queuedSitemap, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for !q.closed {
+		for i, item := range q.pending {
+			if q.perHost > 0 && q.hosts[item.host] >= q.perHost {
+				continue
+			}
+			q.pending = append(q.pending[:i], q.pending[i+1:]...)
+			q.hosts[item.host]++
+			q.active++
+			return item, true
+		}
+		if len(q.pending) == 0 && q.active == 0 {
+			return queuedSitemap{}, false
+		}
+		q.cond.Wait()
+	}
+	return queuedSitemap{}, false
+}
+
+// done frees the slot of the sitemap returned by next.
+func (q *crawlQueue) done(item queuedSitemap) {
+	q.mu.Lock()
+	q.hosts[item.host]--
+	q.active--
+	q.mu.Unlock()
+	q.cond.Broadcast()
+}
+
+func (q *crawlQueue) close() {
+	q.mu.Lock()
+	q.closed = true
+	q.mu.Unlock()
+	q.cond.Broadcast()
+}
+
+// walkQueued walks the queued sitemaps like walk does, but documents are
+// downloaded and parsed by o.workers goroutines, at most o.hostConcurrency of
+// them for a single host. Calls of the consumer are serialized, reports are
+// passed in order of discovery after walking. Seq of the items must be their
+// positions.
+func (w *walker) walkQueued(items []queuedSitemap) error {
+	parent := w.ctx
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+	w.ctx = ctx
+
+	var consuming sync.Mutex
+	consume := w.consume
+	w.consume = func(e Entry) error {
+		consuming.Lock()
+		defer consuming.Unlock()
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		return consume(e)
+	}
+
+	q := newCrawlQueue(w.o.hostConcurrency)
+	q.push(items...)
+	stop := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			q.close()
+		case <-stop:
+		}
+	}()
+
+	var mu sync.Mutex
+	var walkErr error
+	reports := make([]*SitemapReport, len(items))
+
+	var workers sync.WaitGroup
+	for i := 0; i < w.o.workers; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for {
+				item, ok := q.next()
+				if !ok {
+					return
+				}
+
+				children, report, err := w.download(item.url)
+				if w.quota.exhausted(err) {
+					w.quota.postpone(item, w.skips[item.url], report)
+					err = nil
+				}
+				if err == nil && len(children) > 0 && item.depth >= maxIndexDepth {
+					err, children = fmt.Errorf("sitemap: indexes of %s are nested too deep", item.url), nil
+				}
+
+				mu.Lock()
+				reports[item.seq] = report
+				if err != nil && (item.depth == 0 || w.fatal(err)) && walkErr == nil {
+					walkErr = err
+					cancel()
+					q.close()
+				}
+				var queued []queuedSitemap
+				if err == nil {
+					for _, child := range children {
+						queued = append(queued, queuedSitemap{url: child, depth: item.depth + 1, seq: len(reports)})
+						reports = append(reports, nil)
+					}
+				}
+				mu.Unlock()
+
+				q.push(queued...)
+				q.done(item)
+			}
+		}()
+	}
+	workers.Wait()
+	close(stop)
+
+	if w.report != nil {
+		for _, report := range reports {
+			if report != nil {
+				w.report(*report)
+			}
+		}
+	}
+	if walkErr == nil {
+		walkErr = parent.Err()
+	}
+	return walkErr
+}
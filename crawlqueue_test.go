@@ -0,0 +1,74 @@
+package sitemap
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCrawler_HostConcurrency(t *testing.T) {
+	var mu sync.Mutex
+	var requested []string
+	active := make(map[string]int)
+	maxActive := 0
+
+	newHost := func(name string, delay time.Duration) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			requested = append(requested, name)
+			active[name]++
+			if active[name] > maxActive {
+				maxActive = active[name]
+			}
+			mu.Unlock()
+			defer func() {
+				mu.Lock()
+				active[name]--
+				mu.Unlock()
+			}()
+
+			time.Sleep(delay)
+			fmt.Fprintf(w, "<urlset><url><loc>http://%s%s</loc></url></urlset>", name, r.URL.Path)
+		}))
+	}
+	slow := newHost("slow", 10*time.Millisecond)
+	defer slow.Close()
+	fast := newHost("fast", 0)
+	defer fast.Close()
+
+	index := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<sitemapindex>")
+		for i := 0; i < 6; i++ {
+			fmt.Fprintf(w, "<sitemap><loc>%s/%d.xml</loc></sitemap>", slow.URL, i)
+		}
+		fmt.Fprintf(w, "<sitemap><loc>%s/0.xml</loc></sitemap></sitemapindex>", fast.URL)
+	}))
+	defer index.Close()
+
+	counter := 0
+	report, err := NewCrawler(WithWorkers(4), WithHostConcurrency(1)).Crawl(context.Background(), index.URL, func(e Entry) error {
+		counter++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Crawling failed with error %s", err)
+	}
+
+	if counter != 7 || len(report.Sitemaps) != 8 {
+		t.Errorf("Expected 7 entries of 8 sitemaps, but given %d of %d", counter, len(report.Sitemaps))
+	}
+	if maxActive != 1 {
+		t.Errorf("Expected at most 1 concurrent download per host, but given %d", maxActive)
+	}
+	if order := strings.Join(requested, " "); strings.HasSuffix(order, "fast") {
+		t.Errorf("Expected the fast host not to wait for the slow one, but given %s", order)
+	}
+	if report.Sitemaps[7].URL != fast.URL+"/0.xml" {
+		t.Errorf("Expected reports in order of discovery, but given %s last", report.Sitemaps[7].URL)
+	}
+}
@@ -0,0 +1,159 @@
+package sitemap
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+const (
+	seenPrefix   = "seen/"
+	crawlsPrefix = "crawls/"
+)
+
+// Deletion describes an URL which was consumed by the previous crawl of the
+// same root, but is absent now. Sitemap is the sitemap which had the URL.
+type Deletion struct {
+	Location string
+	Sitemap  string
+	LastSeen time.Time
+}
+
+// DeletionConsumer is a type represents consumer of deletion events.
+type DeletionConsumer func(Deletion) error
+
+// WithDeletions makes crawls keep consumed URLs in the store and pass URLs
+// which disappeared since the previous crawl of the same root to the consumer
+// after crawling, so downstream indexes can purge them.
+//
+// URLs of sitemaps which can't be downloaded or parsed and of sitemaps skipped
+// as unchanged (see WithConditional) aren't reported. Deletions aren't reported
+// if crawling fails. Entries skipped by filters are absent for deletions.
+func WithDeletions(store StateStore, consumer DeletionConsumer) Option {
+	return func(o *options) {
+		o.deletionStore = store
+		o.deletions = consumer
+	}
+}
+
+// WithDeletionGrace makes crawls report an URL as deleted only after it is absent
+// in the count of consecutive crawls, since generators of sharded sitemaps often
+// omit URLs transiently. By default URLs are reported after the first crawl
+// they are absent in.
+func WithDeletionGrace(crawls int) Option {
+	return func(o *options) {
+		o.deletionGrace = crawls
+	}
+}
+
+// seenEntry is a stored record of a consumed URL. Missed is the count of
+// crawls the URL is absent in.
+type seenEntry struct {
+	Sitemap string    `json:"sitemap"`
+	Crawl   int       `json:"crawl"`
+	Seen    time.Time `json:"seen"`
+	Missed  int       `json:"missed,omitempty"`
+}
+
+// missedKey is a record of an absent URL which isn't reported yet.
+type missedKey struct {
+	key   string
+	entry seenEntry
+}
+
+// deletionTracker records consumed URLs of a crawl and finds disappeared ones.
+type deletionTracker struct {
+	o      *options
+	store  StateStore
+	root   string
+	prefix string
+	crawl  int
+}
+
+func newDeletionTracker(o *options, root string) (*deletionTracker, error) {
+	if o.deletionStore == nil {
+		return nil, nil
+	}
+
+	t := &deletionTracker{o: o, store: o.deletionStore, root: root, prefix: seenPrefix + root + " "}
+	data, err := t.store.Get(crawlsPrefix + root)
+	if err != nil {
+		return nil, err
+	}
+	if data != nil {
+		if t.crawl, err = strconv.Atoi(string(data)); err != nil {
+			return nil, err
+		}
+	}
+	t.crawl++
+	return t, nil
+}
+
+// see records the URL consumed from the sitemap. A nil tracker doesn't record anything.
+func (t *deletionTracker) see(sitemap, location string) error {
+	if t == nil {
+		return nil
+	}
+	data, err := json.Marshal(seenEntry{Sitemap: sitemap, Crawl: t.crawl, Seen: t.o.now()})
+	if err != nil {
+		return err
+	}
+	return t.store.Put(t.prefix+location, data)
+}
+
+// finish passes URLs which weren't seen by the crawl to the consumer and returns
+// their count. URLs are deleted if their sitemaps are parsed, or if the crawl
+// is complete and their sitemaps are absent, so URLs of failed and unchanged
+// sitemaps are kept. URLs within the grace period are only counted as missed.
+func (t *deletionTracker) finish(report *CrawlReport) (int, error) {
+	parsed := make(map[string]bool)
+	present := make(map[string]bool)
+	for _, r := range report.Sitemaps {
+		present[r.URL] = true
+		parsed[r.URL] = r.Err == nil && !r.NotModified
+	}
+	complete := report.Failed == 0
+
+	var deletions []Deletion
+	var missed []missedKey
+	err := t.store.Scan(t.prefix, "", func(key string, value []byte) error {
+		var seen seenEntry
+		if err := json.Unmarshal(value, &seen); err != nil {
+			return err
+		}
+		if seen.Crawl >= t.crawl || !(parsed[seen.Sitemap] || (complete && !present[seen.Sitemap])) {
+			return nil
+		}
+
+		seen.Missed++
+		if seen.Missed < t.o.deletionGrace {
+			missed = append(missed, missedKey{key: key, entry: seen})
+			return nil
+		}
+		deletions = append(deletions, Deletion{Location: key[len(t.prefix):], Sitemap: seen.Sitemap, LastSeen: seen.Seen})
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, m := range missed {
+		data, err := json.Marshal(m.entry)
+		if err != nil {
+			return 0, err
+		}
+		if err = t.store.Put(m.key, data); err != nil {
+			return 0, err
+		}
+	}
+
+	for i, d := range deletions {
+		if err = t.o.deletions(d); err != nil {
+			return i, err
+		}
+		if err = t.store.Delete(t.prefix + d.Location); err != nil {
+			return i, err
+		}
+	}
+	return len(deletions), t.store.Put(crawlsPrefix+t.root, []byte(strconv.Itoa(t.crawl)))
+}
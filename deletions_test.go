@@ -0,0 +1,121 @@
+package sitemap
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCrawler_Deletions(t *testing.T) {
+	sitemaps := map[string][]string{"/a.xml": {"1", "2"}, "/b.xml": {"3"}}
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	mux.HandleFunc("/index.xml", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<sitemapindex>")
+		for _, path := range []string{"/a.xml", "/b.xml"} {
+			if _, ok := sitemaps[path]; ok {
+				fmt.Fprintf(w, "<sitemap><loc>%s%s</loc></sitemap>", server.URL, path)
+			}
+		}
+		fmt.Fprint(w, "</sitemapindex>")
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		locations, ok := sitemaps[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, "<urlset>")
+		for _, location := range locations {
+			fmt.Fprintf(w, "<url><loc>http://HOST/%s</loc></url>", location)
+		}
+		fmt.Fprint(w, "</urlset>")
+	})
+
+	store := NewMemoryStateStore()
+	var deleted []string
+	crawler := NewCrawler(WithDeletions(store, func(d Deletion) error {
+		deleted = append(deleted, strings.TrimPrefix(d.Location, "http://HOST/"))
+		return nil
+	}))
+	crawl := func() *CrawlReport {
+		deleted = nil
+		report, err := crawler.Crawl(context.Background(), server.URL+"/index.xml", func(e Entry) error {
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Crawling failed with error %s", err)
+		}
+		return report
+	}
+
+	if crawl(); len(deleted) != 0 {
+		t.Errorf("Expected no deletions of the first crawl, but given %v", deleted)
+	}
+
+	sitemaps["/a.xml"] = []string{"1"}
+	if report := crawl(); !reflect.DeepEqual(deleted, []string{"2"}) || report.Deleted != 1 {
+		t.Errorf("Expected deletion of 2, but given %v", deleted)
+	}
+
+	// a failed sitemap keeps its entries
+	mux.HandleFunc("/b.xml", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	if crawl(); len(deleted) != 0 {
+		t.Errorf("Expected no deletions of the failed sitemap, but given %v", deleted)
+	}
+
+	// an absent sitemap deletes its entries
+	delete(sitemaps, "/b.xml")
+	if crawl(); !reflect.DeepEqual(deleted, []string{"3"}) {
+		t.Errorf("Expected deletion of 3, but given %v", deleted)
+	}
+}
+
+func TestCrawler_DeletionGrace(t *testing.T) {
+	locations := []string{"1", "2"}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<urlset>")
+		for _, location := range locations {
+			fmt.Fprintf(w, "<url><loc>http://HOST/%s</loc></url>", location)
+		}
+		fmt.Fprint(w, "</urlset>")
+	}))
+	defer server.Close()
+
+	var deleted []string
+	crawler := NewCrawler(WithDeletionGrace(2), WithDeletions(NewMemoryStateStore(), func(d Deletion) error {
+		deleted = append(deleted, strings.TrimPrefix(d.Location, "http://HOST/"))
+		return nil
+	}))
+
+	steps := []struct {
+		locations []string
+		deleted   []string
+	}{
+		{[]string{"1", "2"}, nil},
+		{[]string{"1"}, nil}, // 2 is missed once
+		{[]string{"1", "2"}, nil},
+		{[]string{"1"}, nil}, // the counter of 2 is reset
+		{[]string{"1"}, []string{"2"}},
+		{[]string{"1"}, nil},
+	}
+	for i, step := range steps {
+		locations, deleted = step.locations, nil
+		_, err := crawler.Crawl(context.Background(), server.URL, func(e Entry) error {
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Crawling failed with error %s", err)
+		}
+		if !reflect.DeepEqual(deleted, step.deleted) {
+			t.Errorf("Step %d: expected deletions %v, but given %v", i, step.deleted, deleted)
+		}
+	}
+}
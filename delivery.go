@@ -0,0 +1,146 @@
+package sitemap
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+const deliveredPrefix = "delivered/"
+
+// MetadataDeliveryAttempt is the metadata key of the delivery attempt of an
+// entry, see WithDelivery.
+const MetadataDeliveryAttempt = "delivery_attempt"
+
+// DeliveryMode is a type represents a delivery guarantee of WithDelivery.
+type DeliveryMode = string
+
+// Delivery modes constants set.
+const (
+	AtLeastOnce DeliveryMode = "at-least-once" // Entries are delivered again by retried or resumed crawls
+	ExactlyOnce DeliveryMode = "exactly-once"  // An URL is delivered once a run
+)
+
+// WithDelivery makes crawls of Crawler and ParseFromRobots keep delivery
+// markers of consumed URLs of the run in the store. Retried crawls of a failed run, resumed crawls (see
+// Crawler.Resume) and duplicates of URLs in several sitemaps can deliver an
+// URL again, with ExactlyOnce an URL which is delivered by the run is skipped,
+// with AtLeastOnce it is delivered again. The run is a name of a logical run,
+// e.g. an ID of a scheduled job, crawls with the same run share markers.
+//
+// In both modes the entry carries the count of attempts of its delivery by
+// the run as MetadataDeliveryAttempt, see DeliveryAttempt, so downstream writes
+// can be made idempotent, e.g. upserts for attempts after the first one.
+// An attempt is counted before the entry is passed to the consumer, and the
+// URL is marked as delivered when the consumer returns nil, so an URL is
+// redelivered if its consumer fails or the process crashes in the middle.
+//
+// Markers must be removed by ClearDeliveries when the run is complete.
+func WithDelivery(mode DeliveryMode, store StateStore, run string) Option {
+	return func(o *options) {
+		o.delivery = &deliveryTracker{mode: mode, store: store, prefix: deliveredPrefix + run + " "}
+	}
+}
+
+// DeliveryAttempt returns the delivery attempt of the entry starting from
+// 1, or 0 if the entry isn't delivered by a walk with WithDelivery.
+func DeliveryAttempt(e Entry) int {
+	attempt, _ := MetadataOf(e)[MetadataDeliveryAttempt].(int)
+	return attempt
+}
+
+// ClearDeliveries removes delivery markers of the run from the store.
+func ClearDeliveries(store StateStore, run string) error {
+	var keys []string
+	err := store.Scan(deliveredPrefix+run+" ", "", func(key string, value []byte) error {
+		keys = append(keys, key)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if err = store.Delete(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deliveryTracker keeps delivery markers of a run. A nil tracker delivers
+// everything without markers.
+type deliveryTracker struct {
+	mode   DeliveryMode
+	store  StateStore
+	prefix string
+
+	// mu serializes deliveries, so concurrent walks deliver duplicates once.
+	mu sync.Mutex
+}
+
+// deliveryMarker is a stored record of a delivery of an URL.
+type deliveryMarker struct {
+	Attempts  int  `json:"attempts"`
+	Delivered bool `json:"delivered,omitempty"`
+}
+
+// deliver passes the entry to the consumer unless it is already delivered by
+// the run with ExactlyOnce, then it reports that the entry is skipped.
+func (t *deliveryTracker) deliver(e Entry, consume EntryConsumer) (bool, error) {
+	if t == nil {
+		return false, consume(e)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	marker, skip, err := t.load(e.GetLocation())
+	if err != nil || skip {
+		return skip, err
+	}
+	location := e.GetLocation()
+	if e, err = t.start(e, marker); err != nil {
+		return false, err
+	}
+	if err = consume(e); err != nil {
+		return false, err
+	}
+	return false, t.finish(location, marker)
+}
+
+// load returns the marker of the URL and reports whether the URL is skipped
+// as already delivered.
+func (t *deliveryTracker) load(location string) (*deliveryMarker, bool, error) {
+	data, err := t.store.Get(t.prefix + location)
+	if err != nil {
+		return nil, false, err
+	}
+	marker := new(deliveryMarker)
+	if data != nil {
+		if err = json.Unmarshal(data, marker); err != nil {
+			return nil, false, err
+		}
+	}
+	return marker, t.mode == ExactlyOnce && marker.Delivered, nil
+}
+
+// start counts the attempt of the delivery and returns the entry with it.
+func (t *deliveryTracker) start(e Entry, marker *deliveryMarker) (Entry, error) {
+	marker.Attempts++
+	if err := t.put(e.GetLocation(), marker); err != nil {
+		return nil, err
+	}
+	return AttachMetadata(e, MetadataDeliveryAttempt, marker.Attempts), nil
+}
+
+// finish marks the URL as delivered.
+func (t *deliveryTracker) finish(location string, marker *deliveryMarker) error {
+	marker.Delivered = true
+	return t.put(location, marker)
+}
+
+func (t *deliveryTracker) put(location string, marker *deliveryMarker) error {
+	data, err := json.Marshal(marker)
+	if err != nil {
+		return err
+	}
+	return t.store.Put(t.prefix+location, data)
+}
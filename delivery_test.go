@@ -0,0 +1,61 @@
+package sitemap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestWithDelivery(t *testing.T) {
+	server := newCrawlServer()
+	defer server.Close()
+
+	store := NewMemoryStateStore()
+	errFailed := errors.New("failed")
+	crawl := func(mode DeliveryMode, fail string) ([]string, error) {
+		var delivered []string
+		crawler := NewCrawler(WithWorkers(1), WithDelivery(mode, store, "run-1"))
+		_, err := crawler.Crawl(context.Background(), server.URL+"/index.xml", func(e Entry) error {
+			if e.GetLocation() == fail {
+				return errFailed
+			}
+			delivered = append(delivered, fmt.Sprintf("%s %d", e.GetLocation(), DeliveryAttempt(e)))
+			return nil
+		})
+		return delivered, err
+	}
+
+	delivered, err := crawl(ExactlyOnce, "http://example.com/b")
+	if err != errFailed {
+		t.Fatalf("Expected the error of the consumer, but given %v", err)
+	}
+	if strings.Join(delivered, ",") != "http://example.com/broken 1,http://example.com/a 1" {
+		t.Errorf("Unexpected delivered entries %v", delivered)
+	}
+
+	delivered, err = crawl(ExactlyOnce, "")
+	if err != nil {
+		t.Fatalf("Crawling failed with error %s", err)
+	}
+	if strings.Join(delivered, ",") != "http://example.com/b 2" {
+		t.Errorf("Expected only the failed entry redelivered, but given %v", delivered)
+	}
+
+	delivered, err = crawl(AtLeastOnce, "")
+	if err != nil {
+		t.Fatalf("Crawling failed with error %s", err)
+	}
+	if strings.Join(delivered, ",") != "http://example.com/broken 2,http://example.com/a 2,http://example.com/b 3" {
+		t.Errorf("Unexpected delivered entries %v", delivered)
+	}
+
+	if err = ClearDeliveries(store, "run-1"); err != nil {
+		t.Fatal(err)
+	}
+	store.Scan(deliveredPrefix, "", func(key string, value []byte) error {
+		t.Errorf("Unexpected marker %s after clearing", key)
+		return nil
+	})
+}
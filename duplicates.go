@@ -0,0 +1,96 @@
+package sitemap
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Issue codes of DuplicateRule.
+const (
+	IssueDuplicate     IssueCode = "duplicate-url"      // The same URL is listed twice
+	IssueNearDuplicate IssueCode = "near-duplicate-url" // An URL likely refers the same page as another one
+)
+
+// DuplicateRule is a lint rule which detects duplicated URLs and URLs differing
+// only by letter case, trailing slash, utm_* tracking parameters or http/https
+// scheme. The first occurrence is considered canonical, later ones are reported
+// with a suggested canonical form.
+//
+// Keep in mind. It keeps a normalized form of each URL in memory.
+type DuplicateRule struct {
+	seen map[string]string
+}
+
+// NewDuplicateRule creates a new duplicates detection rule.
+func NewDuplicateRule() *DuplicateRule {
+	return &DuplicateRule{seen: make(map[string]string)}
+}
+
+// Check reports the entry if its URL duplicates an already checked one.
+func (r *DuplicateRule) Check(e Entry) []Issue {
+	location := e.GetLocation()
+	key := nearDuplicateKey(location)
+
+	first, ok := r.seen[key]
+	if !ok {
+		r.seen[key] = location
+		return nil
+	}
+
+	if first == location {
+		return []Issue{{
+			Code:     IssueDuplicate,
+			Severity: SeverityWarning,
+			Location: location,
+			Message:  "URL is listed more than once",
+		}}
+	}
+
+	return []Issue{{
+		Code:       IssueNearDuplicate,
+		Severity:   SeverityWarning,
+		Location:   location,
+		Message:    "URL likely duplicates " + first,
+		Suggestion: canonicalForm(first),
+	}}
+}
+
+// nearDuplicateKey returns a form of the URL which is equal for near-duplicates.
+func nearDuplicateKey(location string) string {
+	u, err := url.Parse(strings.ToLower(location))
+	if err != nil {
+		return location
+	}
+
+	u.Scheme = ""
+	u.Fragment = ""
+	u.RawQuery = stripTracking(u.Query()).Encode()
+	u.Path = strings.TrimSuffix(u.Path, "/")
+	u.RawPath = ""
+	return u.String()
+}
+
+// canonicalForm returns the URL without tracking parameters, fragment and with
+// lowercased host.
+func canonicalForm(location string) string {
+	u, err := url.Parse(location)
+	if err != nil {
+		return location
+	}
+
+	u.Host = strings.ToLower(u.Host)
+	u.Fragment = ""
+	if u.RawQuery != "" {
+		u.RawQuery = stripTracking(u.Query()).Encode()
+	}
+	return u.String()
+}
+
+func stripTracking(query url.Values) url.Values {
+	for name := range query {
+		if strings.HasPrefix(strings.ToLower(name), "utm_") {
+			delete(query, name)
+		}
+	}
+	return query
+}
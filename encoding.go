@@ -0,0 +1,167 @@
+package sitemap
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/frase-io/gopher-parse-sitemap/stage"
+)
+
+// Issue codes of encoding checks of Validate.
+const (
+	IssueUTF16Encoding      IssueCode = "utf16-encoding"          // A file is UTF-16 encoded
+	IssueNonUTF8Encoding    IssueCode = "non-utf8-encoding"       // The declaration has an encoding other than UTF-8
+	IssueEncodingMismatch   IssueCode = "encoding-mismatch"       // Content doesn't match the declared encoding
+	IssueStrayBOM           IssueCode = "stray-bom"               // A byte order mark isn't at the start of a file
+	IssueMissingDeclaration IssueCode = "missing-xml-declaration" // A file has no XML declaration
+)
+
+// checkEncoding reports issues of the head of the document. It returns false
+// if the content can't be validated further.
+func (v *validator) checkEncoding(h stage.Encoding) bool {
+	at := func(issue Issue) {
+		issue.Line = 1
+		v.report(issue)
+	}
+
+	if h.UTF16 != "" {
+		at(Issue{Code: IssueUTF16Encoding, Severity: SeverityError,
+			Message: "file is UTF-16 encoded, sitemaps must be UTF-8 encoded"})
+		if h.Declared && !strings.HasPrefix(strings.ToLower(h.Label), "utf-16") {
+			at(Issue{Code: IssueEncodingMismatch, Severity: SeverityError,
+				Message: fmt.Sprintf("file is UTF-16 encoded, but the declaration has encoding %q", h.Label)})
+		}
+		return false
+	}
+
+	if !h.Declared {
+		at(Issue{Code: IssueMissingDeclaration, Severity: SeverityWarning,
+			Message: `file has no XML declaration like <?xml version="1.0" encoding="UTF-8"?>`})
+		return true
+	}
+	encoding := strings.ToLower(h.Label)
+	switch {
+	case strings.HasPrefix(encoding, "utf-16"):
+		at(Issue{Code: IssueEncodingMismatch, Severity: SeverityError,
+			Message: fmt.Sprintf("the declaration has encoding %q, but file isn't UTF-16 encoded", h.Label)})
+	case !h.UTF8():
+		at(Issue{Code: IssueNonUTF8Encoding, Severity: SeverityError,
+			Message: fmt.Sprintf("the declaration has encoding %q, sitemaps must be UTF-8 encoded", h.Label)})
+	}
+	return true
+}
+
+// utf8Checker is a reader which finds invalid UTF-8 sequences and byte order
+// marks after the start of the content. Positions are counted from the start
+// of the content.
+type utf8Checker struct {
+	reader io.Reader
+	// strict enables checks of UTF-8 sequences, BOMs are found in any case.
+	strict  bool
+	issues  []Issue
+	carry   []byte
+	offset  int64
+	line    int
+	invalid bool
+}
+
+func newUTF8Checker(reader io.Reader, strict bool) *utf8Checker {
+	return &utf8Checker{reader: reader, strict: strict, line: 1}
+}
+
+func (c *utf8Checker) Read(p []byte) (int, error) {
+	n, err := c.reader.Read(p)
+	c.check(p[:n], err != nil)
+	return n, err
+}
+
+func (c *utf8Checker) check(data []byte, last bool) {
+	if len(c.carry) > 0 {
+		data = append(c.carry, data...)
+		c.carry = nil
+	}
+
+	for i := 0; i < len(data); {
+		b := data[i]
+		if b < utf8.RuneSelf {
+			if b == '\n' {
+				c.line++
+			}
+			i++
+			c.offset++
+			continue
+		}
+
+		if !last && !utf8.FullRune(data[i:]) {
+			c.carry = append([]byte(nil), data[i:]...)
+			return
+		}
+		r, size := utf8.DecodeRune(data[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			if c.strict && !c.invalid {
+				// the first one is enough, the decoder fails on it
+				c.invalid = true
+				c.report(Issue{Code: IssueEncodingMismatch, Severity: SeverityError,
+					Message: "file has bytes which aren't valid UTF-8"})
+			}
+		case r == '\uFEFF' && c.offset > 0:
+			c.report(Issue{Code: IssueStrayBOM, Severity: SeverityError,
+				Message: "byte order mark is found in the middle of file"})
+		}
+		i += size
+		c.offset += int64(size)
+	}
+}
+
+func (c *utf8Checker) report(issue Issue) {
+	issue.Line, issue.Offset = c.line, c.offset
+	c.issues = append(c.issues, issue)
+}
+
+// checkedContent returns the reader of the content which reports encoding issues
+// to the validator, see finish. It returns nil if the content can't be validated.
+func (v *validator) checkedContent(reader io.Reader) (io.Reader, error) {
+	h, reader, err := stage.Detect(reader)
+	if err != nil {
+		return nil, err
+	}
+	if !v.checkEncoding(h) {
+		return nil, nil
+	}
+	v.checker = newUTF8Checker(reader, h.UTF8())
+	return v.checker, nil
+}
+
+// WithTranscoding makes parsing functions transcode documents to clean UTF-8
+// before parsing by stage.Charset and stage.Sanitize: UTF-16 documents and
+// documents in encodings of declarations are decoded, byte order marks are
+// removed, invalid UTF-8 sequences are replaced by U+FFFD and declarations are
+// changed to UTF-8, so Pipe fixes encoding problems which Validate reports.
+// Writers always write UTF-8.
+func WithTranscoding() Option {
+	return func(o *options) {
+		o.transcoding = true
+	}
+}
+
+// WithStages appends stages which are applied to documents before decoding,
+// after transcoding if it is enabled, e.g. an own sanitizer. See package stage.
+func WithStages(stages ...stage.Stage) Option {
+	return func(o *options) {
+		o.stages = append(o.stages, stages...)
+	}
+}
+
+// prepare passes the document through stages of options before decoding.
+func (o *options) prepare(reader io.Reader) (io.Reader, error) {
+	if o.transcoding {
+		var err error
+		if reader, err = stage.Chain(reader, stage.Charset, stage.Sanitize); err != nil {
+			return nil, err
+		}
+	}
+	return stage.Chain(reader, o.stages...)
+}
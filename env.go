@@ -0,0 +1,86 @@
+package sitemap
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EnvPrefix is the default prefix of environment variables read by OptionsFromEnv.
+const EnvPrefix = "SITEMAP_"
+
+// OptionsFromEnv returns options set by environment variables with the prefix,
+// so crawl workers can be configured in containers without code changes.
+// Unset and empty variables are ignored. With EnvPrefix the variables are:
+//
+//	SITEMAP_TIMEOUT           time limit of a request, e.g. 30s, see WithTimeout
+//	SITEMAP_USER_AGENT        User-Agent header, see WithUserAgent
+//	SITEMAP_PROXIES           comma-separated URLs of proxies, see WithProxies
+//	SITEMAP_WORKERS           count of concurrent sitemaps, see WithWorkers
+//	SITEMAP_HOST_CONCURRENCY  count of concurrent downloads per host, see WithHostConcurrency
+//	SITEMAP_REQUEST_RATE      requests per second, see WithRequestRate
+//	SITEMAP_RETRY_ATTEMPTS    attempts of DefaultRetryPolicy, see WithRetry
+func OptionsFromEnv(prefix string) ([]Option, error) {
+	var opts []Option
+	env := func(name string) string {
+		return strings.TrimSpace(os.Getenv(prefix + name))
+	}
+
+	if value := env("TIMEOUT"); value != "" {
+		timeout, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, envError(prefix+"TIMEOUT", err)
+		}
+		opts = append(opts, WithTimeout(timeout))
+	}
+	if value := env("USER_AGENT"); value != "" {
+		opts = append(opts, WithUserAgent(value))
+	}
+	if value := env("PROXIES"); value != "" {
+		var proxies []string
+		for _, proxy := range strings.Split(value, ",") {
+			if proxy = strings.TrimSpace(proxy); proxy != "" {
+				proxies = append(proxies, proxy)
+			}
+		}
+		opts = append(opts, WithProxies(proxies...))
+	}
+
+	ints := []struct {
+		name   string
+		option func(int) Option
+	}{
+		{"WORKERS", WithWorkers},
+		{"HOST_CONCURRENCY", WithHostConcurrency},
+		{"RETRY_ATTEMPTS", func(n int) Option {
+			policy := DefaultRetryPolicy()
+			policy.Attempts = n
+			return WithRetry(policy)
+		}},
+	}
+	for _, i := range ints {
+		if value := env(i.name); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, envError(prefix+i.name, err)
+			}
+			opts = append(opts, i.option(n))
+		}
+	}
+
+	if value := env("REQUEST_RATE"); value != "" {
+		rate, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, envError(prefix+"REQUEST_RATE", err)
+		}
+		opts = append(opts, WithRequestRate(rate))
+	}
+
+	return opts, nil
+}
+
+func envError(name string, err error) error {
+	return fmt.Errorf("sitemap: invalid %s: %v", name, err)
+}
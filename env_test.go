@@ -0,0 +1,78 @@
+package sitemap
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+// setEnv sets variables and returns a function which unsets them.
+func setEnv(values map[string]string) func() {
+	for name, value := range values {
+		os.Setenv(name, value)
+	}
+	return func() {
+		for name := range values {
+			os.Unsetenv(name)
+		}
+	}
+}
+
+func TestOptionsFromEnv(t *testing.T) {
+	defer setEnv(map[string]string{
+		"TEST_TIMEOUT":          "5s",
+		"TEST_USER_AGENT":       "test-bot/1.0",
+		"TEST_PROXIES":          "http://proxy-1:3128, http://proxy-2:3128",
+		"TEST_WORKERS":          "8",
+		"TEST_HOST_CONCURRENCY": "2",
+		"TEST_REQUEST_RATE":     "2.5",
+		"TEST_RETRY_ATTEMPTS":   "3",
+	})()
+
+	opts, err := OptionsFromEnv("TEST_")
+	if err != nil {
+		t.Fatalf("Reading failed with error %s", err)
+	}
+
+	o := newOptions(opts)
+	if o.timeout != 5*time.Second || o.httpClient().Timeout != 5*time.Second || o.userAgent != "test-bot/1.0" {
+		t.Errorf("Unexpected timeout %s or user agent %q", o.timeout, o.userAgent)
+	}
+	if len(o.proxies) != 2 || o.proxies[1] != "http://proxy-2:3128" {
+		t.Errorf("Unexpected proxies %v", o.proxies)
+	}
+	if o.workers != 8 || o.hostConcurrency != 2 || o.requestRate != 2.5 || o.retry.Attempts != 3 {
+		t.Errorf("Unexpected workers %d, host concurrency %d, request rate %f or attempts %d",
+			o.workers, o.hostConcurrency, o.requestRate, o.retry.Attempts)
+	}
+}
+
+func TestOptionsFromEnv_Error(t *testing.T) {
+	defer setEnv(map[string]string{"TEST_WORKERS": "many"})()
+
+	if _, err := OptionsFromEnv("TEST_"); err == nil {
+		t.Error("Expected error of invalid TEST_WORKERS")
+	}
+}
+
+func TestWithUserAgent(t *testing.T) {
+	var userAgent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.UserAgent()
+		fmt.Fprint(w, "<urlset></urlset>")
+	}))
+	defer server.Close()
+
+	err := ParseFromSite(server.URL, func(e Entry) error {
+		return nil
+	}, WithUserAgent("test-bot/1.0"))
+	if err != nil {
+		t.Fatalf("Parsing failed with error %s", err)
+	}
+	if userAgent != "test-bot/1.0" {
+		t.Errorf("Expected user agent test-bot/1.0, but given %q", userAgent)
+	}
+}
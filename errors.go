@@ -0,0 +1,172 @@
+package sitemap
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ParseError is returned by Parse* functions when a sitemap can't be parsed.
+// Errors returned by consumers are passed as is, not wrapped by ParseError.
+//
+// Source is URL or path of the sitemap, it is empty for Parse and ParseIndex.
+// Offset is the byte offset in the decompressed data where parsing stopped.
+// Line and Column are the position where parsing stopped, they start with 1.
+// LastLocation is loc of the last successfully parsed entry, it is empty
+// if the error occurred before the first entry.
+type ParseError struct {
+	Source       string
+	Offset       int64
+	Line         int
+	Column       int
+	LastLocation string
+	Err          error
+}
+
+// Error returns a description of the error with its position.
+func (e *ParseError) Error() string {
+	var sb strings.Builder
+	sb.WriteString("sitemap: ")
+	if e.Source != "" {
+		sb.WriteString(e.Source)
+		sb.WriteString(": ")
+	}
+	fmt.Fprintf(&sb, "line %d, column %d (offset %d)", e.Line, e.Column, e.Offset)
+	if e.LastLocation != "" {
+		fmt.Fprintf(&sb, " after %s", e.LastLocation)
+	}
+	sb.WriteString(": ")
+	sb.WriteString(e.Err.Error())
+	return sb.String()
+}
+
+// Unwrap returns the underlying error.
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+func newParseError(decoder *xml.Decoder, err error) *ParseError {
+	line, column := decoder.InputPos()
+	return &ParseError{
+		Offset: decoder.InputOffset(),
+		Line:   line,
+		Column: column,
+		Err:    err,
+	}
+}
+
+// withSource sets the source of the error if it is a ParseError.
+func withSource(err error, source string) error {
+	if pe, ok := err.(*ParseError); ok && pe.Source == "" {
+		pe.Source = source
+	}
+	return err
+}
+
+// consumerError marks errors returned by consumers, so they can be returned as is.
+type consumerError struct {
+	err error
+}
+
+func (e consumerError) Error() string {
+	return e.err.Error()
+}
+
+// parseState tracks the last parsed location, filters and counts entries, passes date
+// layouts and labels to them and separates errors of consumers from errors of parsing.
+type parseState struct {
+	o            *options
+	base         *url.URL
+	source       string
+	lastLocation string
+	consumed     int
+	parsed       int
+	// recorder keeps XML of the current element if raw entries are enabled.
+	recorder *rawRecorder
+}
+
+func newParseState(o *options, base string) *parseState {
+	s := &parseState{o: o, source: base}
+	if base != "" {
+		s.base, _ = url.Parse(base)
+	}
+	return s
+}
+
+func (s *parseState) entryConsumer(consume EntryConsumer) EntryConsumer {
+	if consume == nil {
+		return nil
+	}
+	return func(e Entry) error {
+		if err := s.count(); err != nil {
+			return err
+		}
+		if se, ok := e.(*sitemapEntry); ok {
+			se.layouts = s.o.dateLayouts
+			if !s.accept(se) {
+				releaseSitemapEntry(se)
+				return nil
+			}
+			se.raw = s.recorder.element()
+			if s.o.classifier != nil {
+				se.label = s.o.classifier(se.Location)
+			}
+		}
+		s.lastLocation = e.GetLocation()
+		if err := consume(e); err != nil {
+			return consumerError{err}
+		}
+
+		s.consumed++
+		s.o.progress.entry()
+		s.o.events.emit(Event{Kind: EntryEmitted, URL: s.source, Entry: e})
+		s.o.count(CounterEntries, 1)
+		if s.o.maxEntries > 0 && s.consumed >= s.o.maxEntries {
+			return consumerError{errStopped}
+		}
+		return nil
+	}
+}
+
+func (s *parseState) indexConsumer(consume IndexEntryConsumer) IndexEntryConsumer {
+	if consume == nil {
+		return nil
+	}
+	return func(e IndexEntry) error {
+		if err := s.count(); err != nil {
+			return err
+		}
+		if se, ok := e.(*sitemapIndexEntry); ok {
+			se.Location = s.normalize(se.Location)
+			se.layouts = s.o.dateLayouts
+		}
+		s.lastLocation = e.GetLocation()
+		if err := consume(e); err != nil {
+			return consumerError{err}
+		}
+		return nil
+	}
+}
+
+// count counts a parsed entry and fails if there are too many of them.
+func (s *parseState) count() error {
+	s.parsed++
+	if max := s.o.limits.MaxEntries; max > 0 && s.parsed > max {
+		return consumerError{&LimitExceededError{Limit: LimitEntries, Max: int64(max)}}
+	}
+	return nil
+}
+
+func (s *parseState) result(err error) error {
+	switch e := err.(type) {
+	case consumerError:
+		if e.err == errStopped {
+			return nil
+		}
+		return e.err
+	case *ParseError:
+		e.LastLocation = s.lastLocation
+	}
+	return err
+}
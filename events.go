@@ -0,0 +1,134 @@
+package sitemap
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// EventKind is a type represents a kind of events of WithEvents.
+type EventKind = string
+
+// Event kinds constants set.
+const (
+	FetchStarted   EventKind = "fetch-started"   // A request is going to be sent
+	FetchCompleted EventKind = "fetch-completed" // A response is received or a request failed
+	SitemapParsed  EventKind = "sitemap-parsed"  // A downloaded document is parsed
+	EntryEmitted   EventKind = "entry-emitted"   // An entry is consumed by the consumer
+	EventWarning   EventKind = "warning"         // A request is retried
+	EventError     EventKind = "error"           // A downloaded document can't be fetched or parsed
+)
+
+// Event is an event of parsing and fetching. Seq is the position of the event
+// in the stream starting from 1. URL is the URL of the request or of the
+// sitemap, it is empty for entries of documents without URL.
+//
+// Status and Duration are the status and the time of the response of
+// FetchCompleted events, Status is zero if the request failed, warnings of
+// retries have the status too. Report is the
+// report of the document of SitemapParsed and Error events. Entry is the entry
+// of EntryEmitted events. Err is the error of failed requests, of retried ones
+// and of Error events. Message describes warnings.
+type Event struct {
+	Kind     EventKind
+	Seq      int64
+	Time     time.Time
+	URL      string
+	Status   int
+	Duration time.Duration
+	Report   *SitemapReport
+	Entry    Entry
+	Err      error
+	Message  string
+}
+
+// EventHandler is a type represents a handler of events.
+type EventHandler func(Event)
+
+// WithEvents sets the handler of the single ordered stream of events of
+// parsing and fetching functions, so observability, progress UIs and auditing
+// hang off one mechanism. The handler is never called concurrently and events
+// are passed in order of Seq, but it blocks parsing, so it should be fast or
+// pass events further by EventChannel.
+//
+// Fetch events and warnings are emitted for each request including retries
+// and checks of pages, SitemapParsed and Error events by functions which walk
+// sitemap indexes, like ParseFromRobots, ParseIndexConcurrent and Crawler.Crawl.
+// Functions which are called with the same options share the stream.
+func WithEvents(handler EventHandler) Option {
+	stream := &eventStream{handler: handler}
+	return func(o *options) {
+		o.events = stream
+	}
+}
+
+// EventChannel returns the handler which sends events to the channel. Sending
+// blocks parsing while the channel is full, so a slow reader slows parsing
+// down instead of losing events.
+func EventChannel(events chan<- Event) EventHandler {
+	return func(e Event) {
+		events <- e
+	}
+}
+
+// eventStream numbers events and serializes calls of the handler. A nil
+// stream doesn't emit anything.
+type eventStream struct {
+	handler EventHandler
+
+	mu  sync.Mutex
+	seq int64
+}
+
+func (s *eventStream) emit(e Event) {
+	if s == nil || s.handler == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seq++
+	e.Seq = s.seq
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	s.handler(e)
+}
+
+// emitReport emits the event of the processed document.
+func (s *eventStream) emitReport(report *SitemapReport) {
+	if s == nil || report == nil {
+		return
+	}
+
+	r := *report
+	kind := SitemapParsed
+	if r.Err != nil {
+		kind = EventError
+	}
+	s.emit(Event{Kind: kind, URL: r.URL, Report: &r, Err: r.Err})
+}
+
+// emitFetched emits the event of the completed request.
+func (o *options) emitFetched(location string, res *http.Response, err error, duration time.Duration) {
+	e := Event{Kind: FetchCompleted, URL: location, Duration: duration, Err: err}
+	if res != nil {
+		e.Status = res.StatusCode
+	}
+	o.events.emit(e)
+}
+
+// emitRetry emits the warning of the retried request.
+func (o *options) emitRetry(location string, res *http.Response, err error, delay time.Duration) {
+	e := Event{Kind: EventWarning, URL: location, Err: err}
+	if res != nil {
+		e.Status = res.StatusCode
+	}
+	if err != nil {
+		e.Message = fmt.Sprintf("retrying after error %v in %s", err, delay)
+	} else {
+		e.Message = fmt.Sprintf("retrying after status %d in %s", res.StatusCode, delay)
+	}
+	o.events.emit(e)
+}
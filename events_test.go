@@ -0,0 +1,70 @@
+package sitemap
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestWithEvents(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	mux.HandleFunc("/index.xml", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "<sitemapindex><sitemap><loc>%[1]s/flaky.xml</loc></sitemap>"+
+			"<sitemap><loc>%[1]s/missing.xml</loc></sitemap></sitemapindex>", server.URL)
+	})
+	failed := false
+	mux.HandleFunc("/flaky.xml", func(w http.ResponseWriter, r *http.Request) {
+		if !failed {
+			failed = true
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, "<urlset><url><loc>http://example.com/a</loc></url>"+
+			"<url><loc>http://example.com/b</loc></url></urlset>")
+	})
+
+	events := make(chan Event, 100)
+	crawler := NewCrawler(WithWorkers(1), WithRetry(testRetryPolicy()), WithEvents(EventChannel(events)))
+	if _, err := crawler.Crawl(context.Background(), server.URL+"/index.xml", func(e Entry) error { return nil }); err != nil {
+		t.Fatalf("Crawling failed with error %s", err)
+	}
+	close(events)
+
+	var given []string
+	var seq int64
+	for e := range events {
+		if e.Seq != seq+1 {
+			t.Errorf("Expected event %d, but given %d", seq+1, e.Seq)
+		}
+		seq = e.Seq
+		given = append(given, fmt.Sprintf("%s %s %d", e.Kind, e.URL[len(server.URL):], e.Status))
+
+		switch e.Kind {
+		case EntryEmitted:
+			if e.Entry == nil {
+				t.Errorf("Expected an entry of event %+v", e)
+			}
+		case SitemapParsed, EventError:
+			if e.Report == nil || (e.Kind == EventError) != (e.Err != nil) {
+				t.Errorf("Expected a report of event %+v", e)
+			}
+		}
+	}
+
+	expected := []string{
+		"fetch-started /index.xml 0", "fetch-completed /index.xml 200", "sitemap-parsed /index.xml 0",
+		"fetch-started /flaky.xml 0", "fetch-completed /flaky.xml 503", "warning /flaky.xml 503",
+		"fetch-started /flaky.xml 0", "fetch-completed /flaky.xml 200",
+		"entry-emitted /flaky.xml 0", "entry-emitted /flaky.xml 0", "sitemap-parsed /flaky.xml 0",
+		"fetch-started /missing.xml 0", "fetch-completed /missing.xml 404", "error /missing.xml 0",
+	}
+	if !reflect.DeepEqual(given, expected) {
+		t.Errorf("Expected events\n%v, but given\n%v", expected, given)
+	}
+}
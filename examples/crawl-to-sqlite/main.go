@@ -0,0 +1,107 @@
+// Command crawl-to-sqlite crawls sitemaps and stores their entries in a SQLite
+// database, a row per URL.
+//
+// Usage:
+//
+//	crawl-to-sqlite [-driver sqlite3] [-db sitemap.db] url ...
+//
+// The example uses database/sql only, so the build must import a SQLite
+// driver registered by the -driver name, e.g. add
+//
+//	import _ "github.com/mattn/go-sqlite3"
+//
+// to this file. Entries of each crawl are stored in a transaction, rows of
+// URLs which are crawled again are replaced. Settings of crawls can be set by
+// SITEMAP_* environment variables, see sitemap.OptionsFromEnv.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	sitemap "github.com/frase-io/gopher-parse-sitemap"
+)
+
+const schema = `CREATE TABLE IF NOT EXISTS entries (
+	loc TEXT PRIMARY KEY,
+	lastmod TIMESTAMP,
+	changefreq TEXT,
+	priority REAL,
+	crawled TIMESTAMP NOT NULL
+)`
+
+const insert = `INSERT OR REPLACE INTO entries (loc, lastmod, changefreq, priority, crawled) VALUES (?, ?, ?, ?, ?)`
+
+func main() {
+	driver := flag.String("driver", "sqlite3", "name of the database/sql driver")
+	dsn := flag.String("db", "sitemap.db", "data source name of the database")
+	flag.Parse()
+
+	db, err := sql.Open(*driver, *dsn)
+	if err == nil {
+		err = crawl(context.Background(), db, flag.Args(), os.Stderr)
+		db.Close()
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "crawl-to-sqlite:", err)
+		os.Exit(1)
+	}
+}
+
+// crawl crawls the sitemaps and stores their entries to the database. It
+// prints a summary of each crawl to the log.
+func crawl(ctx context.Context, db *sql.DB, urls []string, log io.Writer) error {
+	if len(urls) == 0 {
+		return fmt.Errorf("no sitemaps to crawl")
+	}
+	if _, err := db.ExecContext(ctx, schema); err != nil {
+		return err
+	}
+	env, err := sitemap.OptionsFromEnv(sitemap.EnvPrefix)
+	if err != nil {
+		return err
+	}
+	crawler := sitemap.NewCrawler(env...)
+
+	for _, sitemapURL := range urls {
+		if err = store(ctx, db, crawler, sitemapURL, log); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func store(ctx context.Context, db *sql.DB, crawler *sitemap.Crawler, sitemapURL string, log io.Writer) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	stmt, err := tx.PrepareContext(ctx, insert)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	crawled := time.Now().UTC()
+	report, err := crawler.Crawl(ctx, sitemapURL, func(e sitemap.Entry) error {
+		_, err := stmt.ExecContext(ctx, e.GetLocation(), e.GetLastModified(), e.GetChangeFrequency(), e.GetPriority(), crawled)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(log, "%s: %d entries in %d sitemaps, %d failed\n", sitemapURL, report.Entries, len(report.Sitemaps), report.Failed)
+	for _, r := range report.Sitemaps {
+		if r.Err != nil {
+			fmt.Fprintf(log, "  %s: %v\n", r.URL, r.Err)
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/frase-io/gopher-parse-sitemap/examples/internal/memsql"
+)
+
+func TestCrawl(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	mux.HandleFunc("/index.xml", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "<sitemapindex><sitemap><loc>%[1]s/pages.xml</loc></sitemap>"+
+			"<sitemap><loc>%[1]s/missing.xml</loc></sitemap></sitemapindex>", server.URL)
+	})
+	mux.HandleFunc("/pages.xml", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<urlset><url><loc>http://example.com/</loc><lastmod>2015-05-07</lastmod>"+
+			"<changefreq>daily</changefreq><priority>0.9</priority></url>"+
+			"<url><loc>http://example.com/about</loc></url></urlset>")
+	})
+
+	db, err := sql.Open("memsql", t.Name())
+	if err != nil {
+		t.Fatalf("Opening failed with error %s", err)
+	}
+	defer db.Close()
+
+	var log bytes.Buffer
+	if err = crawl(context.Background(), db, []string{server.URL + "/index.xml"}, &log); err != nil {
+		t.Fatalf("Crawling failed with error %s", err)
+	}
+
+	rows := memsql.Rows(t.Name())
+	if len(rows) != 2 || rows[0][0] != "http://example.com/" || rows[1][0] != "http://example.com/about" {
+		t.Fatalf("Unexpected rows %v", rows)
+	}
+	if lastmod, ok := rows[0][1].(time.Time); !ok || !lastmod.Equal(time.Date(2015, 5, 7, 0, 0, 0, 0, time.UTC)) ||
+		rows[0][2] != "daily" || rows[0][3] != float64(float32(0.9)) || rows[1][1] != nil || rows[1][2] != "always" {
+		t.Errorf("Unexpected values of rows %v", rows)
+	}
+	if !strings.Contains(log.String(), "2 entries in 3 sitemaps, 1 failed") {
+		t.Errorf("Unexpected log\n%s", log.String())
+	}
+}
@@ -0,0 +1,175 @@
+// Command diff-and-alert crawls a sitemap, compares it with the snapshot of
+// the previous run and posts an alert to a webhook if too many URLs are
+// removed or added, e.g. when a deploy drops a section of the site.
+//
+// Usage:
+//
+//	diff-and-alert -snapshot site.snapshot [-webhook url] [-threshold 0.1] url
+//
+// The threshold is the allowed share of removed or added URLs of the previous
+// snapshot. The alert is posted as JSON, without -webhook it is printed to the
+// standard output. The snapshot is replaced by the new one after each run, the
+// first run only creates it. Exit status is 2 if the alert is raised.
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+
+	sitemap "github.com/frase-io/gopher-parse-sitemap"
+)
+
+// maxSamples is the max count of URLs of each kind in an alert.
+const maxSamples = 10
+
+// Alert is a message about unexpected changes of a sitemap.
+type Alert struct {
+	Sitemap  string   `json:"sitemap"`
+	Previous int      `json:"previous"`
+	Current  int      `json:"current"`
+	Added    int      `json:"added"`
+	Removed  int      `json:"removed"`
+	Modified int      `json:"modified"`
+	Samples  []string `json:"samples"`
+}
+
+func main() {
+	snapshotPath := flag.String("snapshot", "", "`path` of the snapshot of the previous run")
+	webhook := flag.String("webhook", "", "`URL` which alerts are posted to")
+	threshold := flag.Float64("threshold", 0.1, "allowed share of removed or added URLs")
+	flag.Parse()
+
+	if *snapshotPath == "" || flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(1)
+	}
+	alert, err := run(context.Background(), flag.Arg(0), *snapshotPath, *threshold)
+	if err == nil && alert != nil {
+		err = send(alert, *webhook, os.Stdout)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "diff-and-alert:", err)
+		os.Exit(1)
+	}
+	if alert != nil {
+		os.Exit(2)
+	}
+}
+
+// run crawls the sitemap, replaces the snapshot and returns the alert if the
+// changes exceed the threshold.
+func run(ctx context.Context, sitemapURL, snapshotPath string, threshold float64) (*Alert, error) {
+	var current bytes.Buffer
+	snapshot := sitemap.NewSnapshotWriter(&current)
+	report, err := sitemap.NewCrawler().Crawl(ctx, sitemapURL, snapshot.Add)
+	if err != nil {
+		return nil, err
+	}
+	if report.Failed > 0 {
+		// A partial snapshot would look like removed URLs.
+		return nil, fmt.Errorf("%d of %d sitemaps of %s failed", report.Failed, len(report.Sitemaps), sitemapURL)
+	}
+	if err = snapshot.Close(); err != nil {
+		return nil, err
+	}
+
+	var alert *Alert
+	previous, err := os.Open(snapshotPath)
+	switch {
+	case err == nil:
+		alert, err = compare(previous, current.Bytes(), threshold)
+		previous.Close()
+	case os.IsNotExist(err):
+		err = nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if alert != nil {
+		alert.Sitemap = sitemapURL
+	}
+
+	if err = ioutil.WriteFile(snapshotPath+".tmp", current.Bytes(), 0644); err != nil {
+		return nil, err
+	}
+	return alert, os.Rename(snapshotPath+".tmp", snapshotPath)
+}
+
+// compare returns the alert if changes between snapshots exceed the threshold.
+func compare(previous io.Reader, current []byte, threshold float64) (*Alert, error) {
+	alert := new(Alert)
+	err := sitemap.CompareSnapshots(previous, bytes.NewReader(current), func(c sitemap.Change) error {
+		switch c.Kind {
+		case sitemap.EntryAdded:
+			alert.Added++
+		case sitemap.EntryRemoved:
+			alert.Removed++
+		case sitemap.EntryModified:
+			alert.Modified++
+			return nil
+		}
+		if len(alert.Samples) < maxSamples {
+			alert.Samples = append(alert.Samples, c.Kind+" "+c.Location)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if alert.Current, err = count(current); err != nil {
+		return nil, err
+	}
+	alert.Previous = alert.Current - alert.Added + alert.Removed
+	limit := threshold * float64(alert.Previous)
+	if float64(alert.Removed) <= limit && float64(alert.Added) <= limit {
+		return nil, nil
+	}
+	return alert, nil
+}
+
+// count returns the count of records of the snapshot.
+func count(snapshot []byte) (int, error) {
+	reader, err := sitemap.LoadSnapshot(bytes.NewReader(snapshot))
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for {
+		if _, err = reader.Next(); err == io.EOF {
+			return n, nil
+		} else if err != nil {
+			return n, err
+		}
+		n++
+	}
+}
+
+// send posts the alert to the webhook or prints it to the output.
+func send(alert *Alert, webhook string, output io.Writer) error {
+	data, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+	if webhook == "" {
+		_, err = fmt.Fprintf(output, "%s\n", data)
+		return err
+	}
+
+	res, err := http.Post(webhook, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	if res.StatusCode/100 != 2 {
+		return fmt.Errorf("webhook responded with status %s", res.Status)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	pages := []string{"a", "b", "c", "d"}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<urlset>")
+		for _, page := range pages {
+			fmt.Fprintf(w, "<url><loc>http://example.com/%s</loc></url>", page)
+		}
+		fmt.Fprint(w, "</urlset>")
+	}))
+	defer server.Close()
+
+	var posted []Alert
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var alert Alert
+		if err := json.NewDecoder(r.Body).Decode(&alert); err != nil {
+			t.Errorf("Decoding of alert failed with error %s", err)
+		}
+		posted = append(posted, alert)
+	}))
+	defer webhook.Close()
+
+	dir, err := ioutil.TempDir("", "diff-and-alert")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	snapshotPath := filepath.Join(dir, "site.snapshot")
+
+	for i, test := range []struct {
+		pages    []string
+		expected string
+	}{
+		{pages, ""},
+		{[]string{"a", "b", "c", "d", "e"}, ""},
+		{[]string{"a", "e"}, "removed http://example.com/b, removed http://example.com/c, removed http://example.com/d"},
+	} {
+		pages = test.pages
+		alert, err := run(context.Background(), server.URL+"/sitemap.xml", snapshotPath, 0.25)
+		if err != nil {
+			t.Fatalf("Run %d failed with error %s", i, err)
+		}
+		if alert == nil {
+			if test.expected != "" {
+				t.Errorf("Run %d raised no alert", i)
+			}
+			continue
+		}
+		if err = send(alert, webhook.URL, nil); err != nil {
+			t.Fatalf("Sending failed with error %s", err)
+		}
+		if strings.Join(alert.Samples, ", ") != test.expected || alert.Previous != 5 || alert.Current != 2 {
+			t.Errorf("Unexpected alert %+v of run %d", alert, i)
+		}
+	}
+	if len(posted) != 1 || posted[0].Removed != 3 || posted[0].Sitemap != server.URL+"/sitemap.xml" {
+		t.Errorf("Unexpected posted alerts %+v", posted)
+	}
+}
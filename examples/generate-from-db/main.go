@@ -0,0 +1,99 @@
+// Command generate-from-db generates sitemaps of pages which are stored in a
+// database, split by the protocol limits and referred by an index.
+//
+// Usage:
+//
+//	generate-from-db -driver name -db dsn -base https://example.com/sitemaps/ [-dir .] [-gzip] [-compact]
+//
+// Pages are selected by the -query, its columns are the URL and the nullable
+// time of the last modification. The example uses database/sql only, so the
+// build must import a driver registered by the -driver name, e.g. a SQLite one.
+// Files are written to the -dir, the index is sitemap-index.xml, see
+// sitemap.SplitWriter. Lastmod values are written as UTC dates, -compact
+// produces the smallest files, -gzip compresses them. Sizes are printed to the
+// standard error.
+package main
+
+import (
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	sitemap "github.com/frase-io/gopher-parse-sitemap"
+)
+
+const defaultQuery = "SELECT loc, lastmod FROM pages ORDER BY loc"
+
+func main() {
+	driver := flag.String("driver", "sqlite3", "name of the database/sql driver")
+	dsn := flag.String("db", "pages.db", "data source name of the database")
+	query := flag.String("query", defaultQuery, "query of URLs and lastmods of pages")
+	baseURL := flag.String("base", "", "`URL` of the directory of sitemaps, it ends with a slash")
+	dir := flag.String("dir", ".", "output `directory`")
+	compress := flag.Bool("gzip", false, "compress sitemaps")
+	compact := flag.Bool("compact", false, "write the smallest sitemaps")
+	flag.Parse()
+
+	opts := []sitemap.Option{sitemap.WithLastmodGranularity(sitemap.LastmodDate), sitemap.WithLastmodUTC()}
+	if *compress {
+		opts = append(opts, sitemap.WithGzip())
+	}
+	if *compact {
+		opts = append(opts, sitemap.WithCompact(), sitemap.WithGzipLevel(gzip.BestCompression))
+	}
+
+	db, err := sql.Open(*driver, *dsn)
+	if err == nil {
+		err = generate(context.Background(), db, *query, sitemap.NewSplitWriter(sitemap.DirFileCreator(*dir), *baseURL, opts...), os.Stderr)
+		db.Close()
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "generate-from-db:", err)
+		os.Exit(1)
+	}
+}
+
+// generate writes pages of the query to the writer and prints its sizes to
+// the log.
+func generate(ctx context.Context, db *sql.DB, query string, w *sitemap.SplitWriter, log io.Writer) error {
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var location string
+		var lastmod sql.NullTime
+		if err = rows.Scan(&location, &lastmod); err != nil {
+			return err
+		}
+		var modified *time.Time
+		if lastmod.Valid {
+			modified = &lastmod.Time
+		}
+		if err = w.WriteEntry(location, modified, "", nil); err != nil {
+			return fmt.Errorf("page %s: %v", location, err)
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+
+	stats := w.Stats()
+	fmt.Fprintf(log, "%d entries in %d sitemaps and the index, %d bytes, %d in the default layout",
+		stats.Entries-len(w.Files()), len(w.Files()), stats.Size, stats.Original)
+	if stats.Compressed > 0 {
+		fmt.Fprintf(log, ", %d compressed", stats.Compressed)
+	}
+	fmt.Fprintln(log)
+	return nil
+}
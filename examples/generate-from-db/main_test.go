@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	sitemap "github.com/frase-io/gopher-parse-sitemap"
+	_ "github.com/frase-io/gopher-parse-sitemap/examples/internal/memsql"
+)
+
+func TestGenerate(t *testing.T) {
+	db, err := sql.Open("memsql", t.Name())
+	if err != nil {
+		t.Fatalf("Opening failed with error %s", err)
+	}
+	defer db.Close()
+	lastmod := time.Date(2015, 5, 7, 23, 13, 9, 0, time.FixedZone("", -5*60*60))
+	for _, page := range []struct {
+		location string
+		lastmod  interface{}
+	}{{"http://example.com/", lastmod}, {"http://example.com/about", nil}} {
+		if _, err = db.Exec("INSERT INTO pages (loc, lastmod) VALUES (?, ?)", page.location, page.lastmod); err != nil {
+			t.Fatalf("Inserting failed with error %s", err)
+		}
+	}
+
+	dir, err := ioutil.TempDir("", "generate-from-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var log bytes.Buffer
+	w := sitemap.NewSplitWriter(sitemap.DirFileCreator(dir), "http://example.com/", sitemap.WithCompact(),
+		sitemap.WithLastmodGranularity(sitemap.LastmodDate), sitemap.WithLastmodUTC())
+	if err = generate(context.Background(), db, defaultQuery, w, &log); err != nil {
+		t.Fatalf("Generating failed with error %s", err)
+	}
+	if !strings.HasPrefix(log.String(), "2 entries in 1 sitemaps and the index") {
+		t.Errorf("Unexpected log %s", log.String())
+	}
+
+	var entries []string
+	err = sitemap.ParseFromFile(filepath.Join(dir, "sitemap-1.xml"), func(e sitemap.Entry) error {
+		entries = append(entries, e.GetLocation()+" "+e.(sitemap.RawLastModifiedProvider).GetLastModifiedRaw())
+		return nil
+	})
+	if err != nil || strings.Join(entries, ", ") != "http://example.com/ 2015-05-08, http://example.com/about " {
+		t.Errorf("Unexpected entries %v, error %v", entries, err)
+	}
+	for _, name := range []string{"sitemap-1.xml", "sitemap-index.xml"} {
+		issues, err := sitemap.ValidateFromFile(filepath.Join(dir, name))
+		if err != nil || len(issues) != 0 {
+			t.Errorf("Unexpected issues %v of %s, error %v", issues, name, err)
+		}
+	}
+}
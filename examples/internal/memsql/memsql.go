@@ -0,0 +1,127 @@
+// Package memsql is an in-memory database/sql driver for tests of examples,
+// so they run without a database server or cgo.
+//
+// The driver is registered as "memsql", databases are named by DSN. It keeps
+// a single table per database: INSERT statements append their arguments as
+// a row, SELECT statements return all rows with the selected count of values.
+// Other statements, e.g. CREATE TABLE, are ignored.
+package memsql
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+)
+
+func init() {
+	sql.Register("memsql", memDriver{})
+}
+
+var (
+	mu        sync.Mutex
+	databases = make(map[string][][]driver.Value)
+)
+
+// Rows returns rows of the database.
+func Rows(dsn string) [][]driver.Value {
+	mu.Lock()
+	defer mu.Unlock()
+	return append([][]driver.Value(nil), databases[dsn]...)
+}
+
+type memDriver struct{}
+
+func (memDriver) Open(dsn string) (driver.Conn, error) {
+	return &conn{dsn: dsn}, nil
+}
+
+type conn struct {
+	dsn string
+}
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return &stmt{dsn: c.dsn, query: strings.TrimSpace(query)}, nil
+}
+
+func (c *conn) Close() error {
+	return nil
+}
+
+func (c *conn) Begin() (driver.Tx, error) {
+	return tx{}, nil
+}
+
+type tx struct{}
+
+func (tx) Commit() error {
+	return nil
+}
+
+func (tx) Rollback() error {
+	return nil
+}
+
+type stmt struct {
+	dsn   string
+	query string
+}
+
+func (s *stmt) Close() error {
+	return nil
+}
+
+func (s *stmt) NumInput() int {
+	return -1
+}
+
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	if !hasPrefixFold(s.query, "INSERT") {
+		return driver.RowsAffected(0), nil
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	databases[s.dsn] = append(databases[s.dsn], append([]driver.Value(nil), args...))
+	return driver.RowsAffected(1), nil
+}
+
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	upper := strings.ToUpper(s.query)
+	from := strings.Index(upper, " FROM ")
+	if !hasPrefixFold(s.query, "SELECT") || from < 0 {
+		return nil, errors.New("memsql: unsupported query " + s.query)
+	}
+	var columns []string
+	for _, column := range strings.Split(s.query[len("SELECT"):from], ",") {
+		columns = append(columns, strings.TrimSpace(column))
+	}
+	return &rows{columns: columns, values: Rows(s.dsn)}, nil
+}
+
+type rows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *rows) Columns() []string {
+	return r.columns
+}
+
+func (r *rows) Close() error {
+	return nil
+}
+
+func (r *rows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+func hasPrefixFold(s, prefix string) bool {
+	return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
@@ -0,0 +1,77 @@
+// Command validate-in-ci validates sitemap files and URLs against the sitemaps
+// protocol and exits with status 1 if they have errors, so it can be a step of
+// a CI pipeline which checks generated sitemaps before a deploy.
+//
+// Usage:
+//
+//	validate-in-ci [-strict] path-or-url ...
+//
+// Issues are printed like compilers print them, "path:line: severity code:
+// message", so CI systems annotate sources. A summary of extensions used by
+// each sitemap follows its issues. With -strict warnings fail the check too.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	sitemap "github.com/frase-io/gopher-parse-sitemap"
+)
+
+func main() {
+	strict := flag.Bool("strict", false, "fail on warnings too")
+	flag.Parse()
+
+	failed, err := validate(flag.Args(), *strict, os.Stdout)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "validate-in-ci:", err)
+		os.Exit(1)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// validate validates the sitemaps, prints their issues to the output and
+// reports whether the check is failed. Malformed sitemaps fail the check.
+func validate(targets []string, strict bool, output io.Writer) (bool, error) {
+	if len(targets) == 0 {
+		return false, fmt.Errorf("no sitemaps to validate")
+	}
+
+	failed := false
+	for _, target := range targets {
+		var coverage sitemap.Coverage
+		var issues []sitemap.Issue
+		var err error
+		if strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://") {
+			issues, err = sitemap.ValidateFromSite(target, sitemap.WithCoverage(&coverage))
+		} else {
+			issues, err = sitemap.ValidateFromFile(target, sitemap.WithCoverage(&coverage))
+		}
+
+		for _, issue := range issues {
+			fmt.Fprintf(output, "%s:%d: %s %s: %s\n", target, issue.Line, issue.Severity, issue.Code, issue.Message)
+			if issue.Severity == sitemap.SeverityError || (strict && issue.Severity == sitemap.SeverityWarning) {
+				failed = true
+			}
+		}
+		if err != nil {
+			fmt.Fprintf(output, "%s: %v\n", target, err)
+			failed = true
+			continue
+		}
+
+		summary := fmt.Sprintf("%s: %d URLs, %d issues", target, coverage.Entries, len(issues))
+		for _, ec := range coverage.Extensions {
+			if ec.Entries > 0 {
+				summary += fmt.Sprintf(", %s in %d URLs", ec.Extension, ec.Entries)
+			}
+		}
+		fmt.Fprintln(output, summary)
+	}
+	return failed, nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "validate-in-ci")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"valid.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"
+  xmlns:image="http://www.google.com/schemas/sitemap-image/1.1">
+  <url><loc>http://example.com/</loc><image:image><image:loc>http://example.com/a.png</image:loc></image:image></url>
+</urlset>`,
+		"warning.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"
+  xmlns:mobile="http://www.google.com/schemas/sitemap-mobile/1.0">
+  <url><loc>http://example.com/</loc><mobile:mobile/></url>
+</urlset>`,
+		"invalid.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>http://example.com/</loc><priority>2</priority></url>
+</urlset>`,
+		"malformed.xml": `<urlset><url>`,
+	}
+	for name, content := range files {
+		if err = ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, test := range []struct {
+		name   string
+		strict bool
+		failed bool
+		output string
+	}{
+		{"valid.xml", true, false, "valid.xml: 1 URLs, 0 issues, image in 1 URLs"},
+		{"warning.xml", false, false, "warning.xml:4: warning deprecated-mobile"},
+		{"warning.xml", true, true, "warning.xml: 1 URLs, 1 issues, mobile in 1 URLs"},
+		{"invalid.xml", false, true, "invalid.xml:3: error invalid-priority"},
+		{"malformed.xml", false, true, "malformed.xml: "},
+	} {
+		var output bytes.Buffer
+		failed, err := validate([]string{filepath.Join(dir, test.name)}, test.strict, &output)
+		if err != nil {
+			t.Fatalf("Validation failed with error %s", err)
+		}
+		if failed != test.failed || !strings.Contains(output.String(), test.output) {
+			t.Errorf("Unexpected result %v of %s\n%s", failed, test.name, output.String())
+		}
+	}
+}
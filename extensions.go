@@ -0,0 +1,69 @@
+package sitemap
+
+import "time"
+
+// Alternate describes an xhtml:link element with rel="alternate" which annotates
+// a version of the page in another language or for another region.
+// See https://developers.google.com/search/docs/specialty/international/localized-versions
+type Alternate struct {
+	Hreflang string
+	Href     string
+}
+
+// Image describes an image:image element of the Google image sitemap extension.
+// See https://developers.google.com/search/docs/crawling-indexing/sitemaps/image-sitemaps
+type Image struct {
+	Location    string `xml:"loc"`
+	Caption     string `xml:"caption,omitempty"`
+	GeoLocation string `xml:"geo_location,omitempty"`
+	Title       string `xml:"title,omitempty"`
+	License     string `xml:"license,omitempty"`
+}
+
+// Video describes a video:video element of the Google video sitemap extension.
+// See https://developers.google.com/search/docs/crawling-indexing/sitemaps/video-sitemaps
+//
+// Dates are kept as they are in the sitemap, use PublicationTime and ExpirationTime
+// to get them parsed.
+type Video struct {
+	ThumbnailLocation    string   `xml:"thumbnail_loc"`
+	Title                string   `xml:"title"`
+	Description          string   `xml:"description"`
+	ContentLocation      string   `xml:"content_loc,omitempty"`
+	PlayerLocation       string   `xml:"player_loc,omitempty"`
+	Duration             int      `xml:"duration,omitempty"`
+	ExpirationDate       string   `xml:"expiration_date,omitempty"`
+	Rating               float32  `xml:"rating,omitempty"`
+	ViewCount            int      `xml:"view_count,omitempty"`
+	PublicationDate      string   `xml:"publication_date,omitempty"`
+	FamilyFriendly       string   `xml:"family_friendly,omitempty"`
+	RequiresSubscription string   `xml:"requires_subscription,omitempty"`
+	Live                 string   `xml:"live,omitempty"`
+	Uploader             string   `xml:"uploader,omitempty"`
+	Tags                 []string `xml:"tag,omitempty"`
+}
+
+// PublicationTime parses and returns the publication date of the video or nil.
+func (v *Video) PublicationTime() *time.Time {
+	return parseDateTime(v.PublicationDate)
+}
+
+// ExpirationTime parses and returns the expiration date of the video or nil.
+func (v *Video) ExpirationTime() *time.Time {
+	return parseDateTime(v.ExpirationDate)
+}
+
+// News describes a news:news element of the Google news sitemap extension.
+// See https://developers.google.com/search/docs/crawling-indexing/sitemaps/news-sitemap
+type News struct {
+	PublicationName     string `xml:"publication>name"`
+	PublicationLanguage string `xml:"publication>language"`
+	PublicationDate     string `xml:"publication_date"`
+	Title               string `xml:"title"`
+	Keywords            string `xml:"keywords,omitempty"`
+}
+
+// PublicationTime parses and returns the publication date of the article or nil.
+func (n *News) PublicationTime() *time.Time {
+	return parseDateTime(n.PublicationDate)
+}
@@ -0,0 +1,172 @@
+package sitemap
+
+import (
+	"context"
+	"sync"
+)
+
+// CrawlTenants crawls all tenants concurrently, sitemaps of each tenant are
+// crawled one by one like CrawlTenant does. At most slots documents of all
+// tenants are downloaded and parsed at once and slots are shared between
+// tenants by weighted round-robin (see Tenant.Weight), so a tenant with a huge
+// index doesn't starve tenants with small sitemaps. It returns errors of failed
+// tenants by their IDs.
+func (c *Crawler) CrawlTenants(ctx context.Context, slots int) map[string]error {
+	if slots <= 0 {
+		slots = defaultWorkers
+	}
+	scheduler := newFairScheduler(slots)
+
+	c.mu.Lock()
+	tenants := make([]Tenant, 0, len(c.tenants))
+	for _, state := range c.tenants {
+		tenants = append(tenants, state.tenant)
+	}
+	c.mu.Unlock()
+
+	var mu sync.Mutex
+	errs := make(map[string]error)
+	var wg sync.WaitGroup
+	for _, tenant := range tenants {
+		wg.Add(1)
+		go func(tenant Tenant) {
+			defer wg.Done()
+			ticket := &fairTicket{scheduler: scheduler, tenant: tenant.ID, weight: tenant.Weight}
+			if _, err := c.crawlTenant(ctx, tenant, func(o *options) { o.fair = ticket }); err != nil {
+				mu.Lock()
+				errs[tenant.ID] = err
+				mu.Unlock()
+			}
+		}(tenant)
+	}
+	wg.Wait()
+	return errs
+}
+
+// fairTicket is a tenant of a fair scheduler.
+type fairTicket struct {
+	scheduler *fairScheduler
+	tenant    string
+	weight    int
+}
+
+// acquire waits for a slot of the scheduler and returns a function which
+// releases it. A nil ticket doesn't wait.
+func (t *fairTicket) acquire(ctx context.Context) (func(), error) {
+	if t == nil {
+		return func() {}, nil
+	}
+	return t.scheduler.acquire(ctx, t.tenant, t.weight)
+}
+
+// fairScheduler shares slots between tenants by weighted round-robin: waiting
+// tenants take turns and each one gets up to its weight of slots per turn.
+type fairScheduler struct {
+	mu      sync.Mutex
+	free    int
+	waiters map[string][]chan struct{}
+	weights map[string]int
+	ring    []string
+	pos     int
+	served  int
+}
+
+func newFairScheduler(slots int) *fairScheduler {
+	return &fairScheduler{free: slots, waiters: make(map[string][]chan struct{}), weights: make(map[string]int)}
+}
+
+func (s *fairScheduler) acquire(ctx context.Context, tenant string, weight int) (func(), error) {
+	s.mu.Lock()
+	if s.free > 0 && len(s.ring) == 0 {
+		s.free--
+		s.mu.Unlock()
+		return s.release, nil
+	}
+
+	if weight < 1 {
+		weight = 1
+	}
+	s.weights[tenant] = weight
+	granted := make(chan struct{})
+	if len(s.waiters[tenant]) == 0 {
+		s.ring = append(s.ring, tenant)
+	}
+	s.waiters[tenant] = append(s.waiters[tenant], granted)
+	s.mu.Unlock()
+
+	select {
+	case <-granted:
+		return s.release, nil
+	case <-ctx.Done():
+	}
+
+	s.mu.Lock()
+	select {
+	case <-granted:
+		// the slot is granted concurrently with cancellation, pass it on
+		s.mu.Unlock()
+		s.release()
+	default:
+		s.cancel(tenant, granted)
+		s.mu.Unlock()
+	}
+	return nil, ctx.Err()
+}
+
+// release passes the slot to the next waiting tenant or frees it.
+func (s *fairScheduler) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.ring) == 0 {
+		s.free++
+		return
+	}
+
+	tenant := s.ring[s.pos]
+	queue := s.waiters[tenant]
+	close(queue[0])
+	s.waiters[tenant] = queue[1:]
+
+	s.served++
+	if len(queue) == 1 {
+		s.remove(s.pos)
+	} else if s.served >= s.weights[tenant] {
+		s.served = 0
+		s.pos = (s.pos + 1) % len(s.ring)
+	}
+}
+
+// cancel removes the waiter which isn't granted yet.
+func (s *fairScheduler) cancel(tenant string, granted chan struct{}) {
+	queue := s.waiters[tenant]
+	for i, ch := range queue {
+		if ch == granted {
+			s.waiters[tenant] = append(queue[:i:i], queue[i+1:]...)
+			break
+		}
+	}
+	if len(s.waiters[tenant]) > 0 {
+		return
+	}
+	for i, t := range s.ring {
+		if t == tenant {
+			s.remove(i)
+			return
+		}
+	}
+}
+
+// remove removes the tenant at the index from the ring.
+func (s *fairScheduler) remove(i int) {
+	s.ring = append(s.ring[:i], s.ring[i+1:]...)
+	switch {
+	case i < s.pos:
+		s.pos--
+	case i == s.pos:
+		s.served = 0
+	}
+	if s.pos >= len(s.ring) {
+		s.pos = 0
+	}
+}
@@ -0,0 +1,110 @@
+package sitemap
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFairScheduler_WeightedRoundRobin(t *testing.T) {
+	s := newFairScheduler(1)
+	release, err := s.acquire(context.Background(), "holder", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	var order []string
+	var wg sync.WaitGroup
+	enqueue := func(tenant string, weight int) {
+		s.mu.Lock()
+		waiting := len(s.waiters[tenant])
+		s.mu.Unlock()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			release, err := s.acquire(context.Background(), tenant, weight)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			mu.Lock()
+			order = append(order, tenant)
+			mu.Unlock()
+			release()
+		}()
+
+		for {
+			s.mu.Lock()
+			queued := len(s.waiters[tenant]) > waiting
+			s.mu.Unlock()
+			if queued {
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	for i := 0; i < 5; i++ {
+		enqueue("big", 2)
+	}
+	enqueue("small", 1)
+	enqueue("small", 1)
+	release()
+	wg.Wait()
+
+	expected := "big big small big big small big"
+	if strings.Join(order, " ") != expected {
+		t.Errorf("Expected order %s, but given %v", expected, order)
+	}
+}
+
+func TestFairScheduler_Cancel(t *testing.T) {
+	s := newFairScheduler(1)
+	release, _ := s.acquire(context.Background(), "a", 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := s.acquire(ctx, "b", 1); err != context.DeadlineExceeded {
+		t.Errorf("Expected the deadline error, but given %v", err)
+	}
+	release()
+
+	if s.free != 1 || len(s.ring) != 0 {
+		t.Errorf("Expected the slot to be free, but given %d free and ring %v", s.free, s.ring)
+	}
+}
+
+func TestCrawler_CrawlTenants(t *testing.T) {
+	server := newCrawlServer()
+	defer server.Close()
+
+	crawler := NewCrawler()
+	var mu sync.Mutex
+	entries := make(map[string]int)
+	for _, id := range []string{"a", "b", "c"} {
+		id := id
+		crawler.AddTenant(Tenant{
+			ID:       id,
+			Sitemaps: []string{server.URL + "/index.xml"},
+			Consumer: func(e Entry) error {
+				mu.Lock()
+				entries[id]++
+				mu.Unlock()
+				return nil
+			},
+		})
+	}
+
+	errs := crawler.CrawlTenants(context.Background(), 2)
+	if len(errs) != 0 {
+		t.Errorf("Unexpected errors %v", errs)
+	}
+	if expected := map[string]int{"a": 3, "b": 3, "c": 3}; !reflect.DeepEqual(entries, expected) {
+		t.Errorf("Unexpected entries of tenants %v", entries)
+	}
+}
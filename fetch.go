@@ -0,0 +1,359 @@
+package sitemap
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/frase-io/gopher-parse-sitemap/stage"
+)
+
+// maxIndexDepth limits nesting of sitemap indexes while walking.
+// The protocol doesn't allow nested indexes, but some sites have them.
+const maxIndexDepth = 3
+
+// fetch downloads a document and returns its body. The body is transparently
+// decompressed if it is gzipped. It returns HTTPError for non-2xx statuses.
+func (o *options) fetch(ctx context.Context, url string) (*download, error) {
+	return o.fetchHeader(ctx, url, nil)
+}
+
+// fetchHeader downloads a document with the header like fetch does. It returns
+// ErrNotModified for 304 responses.
+func (o *options) fetchHeader(ctx context.Context, url string, header http.Header) (*download, error) {
+	started := time.Now()
+	res, err := o.doHeader(ctx, http.MethodGet, url, header)
+	if err != nil {
+		return nil, err
+	}
+	latency := time.Since(started)
+	if res.StatusCode == http.StatusNotModified {
+		res.Body.Close()
+		return nil, ErrNotModified
+	}
+	if err = checkStatus(res, url); err != nil {
+		return nil, err
+	}
+
+	raw := &transferReader{reader: res.Body}
+	reader, err := stage.Decompress(raw)
+	if err != nil {
+		res.Body.Close()
+		return nil, err
+	}
+	digest := sha256.New()
+	decompressed := &countingReader{reader: io.TeeReader(reader, digest)}
+
+	return &download{
+		readCloser:   readCloser{Reader: decompressed, Closer: res.Body},
+		URL:          finalURL(res, url),
+		ContentType:  res.Header.Get("Content-Type"),
+		header:       res.Header,
+		latency:      latency,
+		raw:          raw,
+		decompressed: decompressed,
+		digest:       digest,
+	}, nil
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// download is a body of a downloaded document. URL is the URL after redirects.
+type download struct {
+	readCloser
+	URL         string
+	ContentType string
+	header      http.Header
+
+	// latency is the time until the response header is received, raw counts
+	// the body as it is received and decompressed counts it after decompression.
+	// digest hashes the body after decompression. archived is the time of
+	// the archived copy, see WithArchiveFallback.
+	latency      time.Duration
+	raw          *transferReader
+	decompressed *countingReader
+	digest       hash.Hash
+	archived     time.Time
+}
+
+// measure sets sizes and timings of the download to the report. Parsing is
+// the time of reading and parsing of the body.
+func (d *download) measure(report *SitemapReport, parsing time.Duration) {
+	report.CompressedSize = d.raw.bytes
+	report.UncompressedSize = d.decompressed.count
+	report.DownloadTime = d.latency + d.raw.elapsed
+	report.ParseTime = parsing - d.raw.elapsed
+	report.Hash = hex.EncodeToString(d.digest.Sum(nil))
+}
+
+// transferReader counts bytes of the reader and time spent in reading them.
+type transferReader struct {
+	reader  io.Reader
+	bytes   int64
+	elapsed time.Duration
+}
+
+func (r *transferReader) Read(p []byte) (int, error) {
+	started := time.Now()
+	n, err := r.reader.Read(p)
+	r.elapsed += time.Since(started)
+	r.bytes += int64(n)
+	return n, err
+}
+
+// hostLimiter limits count of concurrent downloads from a single host.
+type hostLimiter struct {
+	limit int
+	mu    sync.Mutex
+	hosts map[string]chan struct{}
+}
+
+func newHostLimiter(limit int) *hostLimiter {
+	if limit <= 0 {
+		return nil
+	}
+	return &hostLimiter{limit: limit, hosts: make(map[string]chan struct{})}
+}
+
+// acquire waits for a free slot of the host of the URL and returns a function
+// which releases it. A nil limiter doesn't limit anything.
+func (l *hostLimiter) acquire(ctx context.Context, location string) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+
+	host := location
+	if u, err := url.Parse(location); err == nil {
+		host = u.Host
+	}
+
+	l.mu.Lock()
+	slots, ok := l.hosts[host]
+	if !ok {
+		slots = make(chan struct{}, l.limit)
+		l.hosts[host] = slots
+	}
+	l.mu.Unlock()
+
+	select {
+	case slots <- struct{}{}:
+		return func() { <-slots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// walker downloads sitemaps and recursively walks sitemap indexes.
+type walker struct {
+	ctx     context.Context
+	o       *options
+	consume EntryConsumer
+	limiter *hostLimiter
+
+	// mu guards visited and consumerErr for concurrent walks.
+	mu      sync.Mutex
+	visited map[string]bool
+
+	// tolerant makes the walker skip children which can't be downloaded or
+	// parsed, errors of the consumer and of the context still stop it.
+	tolerant    bool
+	consumerErr error
+	// report is called for each downloaded document if it is set.
+	report func(SitemapReport)
+	// track records consumed entries for deletions if it is set.
+	track *deletionTracker
+	// quota caps walking if it is set, skips are counts of entries of
+	// sitemaps which are consumed by previous runs, see Crawler.Resume.
+	quota *quotaTracker
+	skips map[string]int
+	// inherited are lastmods of children of indexes, see WithInheritedLastModified.
+	inherited map[string]*time.Time
+}
+
+func newWalker(ctx context.Context, o *options, consumer EntryConsumer) *walker {
+	return &walker{ctx: ctx, o: o, consume: consumer, visited: make(map[string]bool)}
+}
+
+// walk downloads the sitemap or the sitemap index, passes entries of a sitemap
+// to the consumer and walks children of an index.
+func (w *walker) walk(url string, depth int) error {
+	children, err := w.fetch(url)
+	if err != nil {
+		return err
+	}
+
+	if len(children) > 0 && depth >= maxIndexDepth {
+		return fmt.Errorf("sitemap: indexes of %s are nested too deep", url)
+	}
+	for _, child := range children {
+		if err = w.walk(child, depth+1); err != nil && w.fatal(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// fatal reports whether the error of a child stops walking.
+func (w *walker) fatal(err error) bool {
+	w.mu.Lock()
+	consumerErr := w.consumerErr
+	w.mu.Unlock()
+
+	var budgetErr *BudgetExceededError
+	return !w.tolerant || err == consumerErr || w.ctx.Err() != nil || errors.As(err, &budgetErr)
+}
+
+// fetch downloads and parses a single document and returns its children if
+// the document is an index.
+func (w *walker) fetch(url string) ([]string, error) {
+	children, report, err := w.download(url)
+	if report != nil && w.report != nil {
+		w.report(*report)
+	}
+	return children, err
+}
+
+// download downloads and parses a single document like fetch does, but returns
+// the report instead of passing it. The report is nil if the document is
+// already visited.
+func (w *walker) download(url string) ([]string, *SitemapReport, error) {
+	w.mu.Lock()
+	visited := w.visited[url]
+	w.visited[url] = true
+	w.mu.Unlock()
+	if visited {
+		return nil, nil, nil
+	}
+	if err := w.quota.sitemap(); err != nil {
+		return nil, nil, err
+	}
+	w.o.progress.discover(url)
+	defer w.o.progress.finish(url)
+
+	releaseFair, err := w.o.fair.acquire(w.ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer releaseFair()
+	release, err := w.limiter.acquire(w.ctx, url)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer release()
+
+	var children []string
+	report := SitemapReport{URL: url}
+	body, err := w.o.fetchConditional(w.ctx, url)
+	if w.o.unreachable(w.ctx, err) {
+		w.o.events.emit(Event{Kind: EventWarning, URL: url, Err: err,
+			Message: fmt.Sprintf("fetching archived copy after error %v", err)})
+		if archived, archiveErr := w.o.fetchArchived(w.ctx, url); archiveErr == nil {
+			body, err = archived, nil
+		}
+	}
+	if err == nil {
+		report.FinalURL, report.ContentType, report.Archived = body.URL, body.ContentType, body.archived
+		started := time.Now()
+		children, err = w.parse(url, body, &report)
+		if err == nil {
+			// The rest of the body after the end of the document is hashed too.
+			_, err = io.Copy(ioutil.Discard, body)
+		}
+		body.Close()
+		body.measure(&report, time.Since(started))
+		if err != nil {
+			report.Hash = ""
+		}
+	}
+	if err == ErrNotModified {
+		report.NotModified = true
+		children, err = w.o.loadChildren(url)
+	}
+
+	w.o.progress.discover(children...)
+	report.Index, report.Children, report.Err = len(children) > 0, len(children), err
+	if !w.quota.exhausted(err) {
+		w.o.events.emitReport(&report)
+		w.o.countReport(&report)
+	}
+	return children, &report, err
+}
+
+// parse parses the downloaded document and stores its state for conditional
+// fetching. It returns ErrNotModified if the content hash of the document
+// isn't changed, see WithContentHash.
+func (w *walker) parse(url string, body *download, report *SitemapReport) ([]string, error) {
+	reader, err := checkBody(body, url, body.ContentType)
+	if err != nil {
+		return nil, err
+	}
+
+	var hash string
+	if w.o.hashed(body.header) {
+		if reader, hash, err = w.o.hashBody(url, body, reader); err != nil {
+			return nil, err
+		}
+	}
+
+	skip := w.skips[url]
+	inherited := w.inheritedLastModified(url)
+	var children []string
+	err = parseDocumentAt(reader, w.o, url, func(e Entry) error {
+		if skip > 0 {
+			skip--
+			return nil
+		}
+		if inherited != nil && lastModifiedRaw(e) == "" {
+			e = inheritLastModified(e, inherited)
+		}
+		if !body.archived.IsZero() {
+			e = AttachMetadata(e, MetadataArchived, body.archived)
+		}
+		_, err := w.o.delivery.deliver(e, func(e Entry) error {
+			err := w.quota.entry()
+			if err == nil {
+				report.Entries++
+				err = w.consume(e)
+			}
+			return err
+		})
+		if err == nil {
+			err = w.track.see(url, e.GetLocation())
+		}
+		if err == nil {
+			err = w.o.storeResult(url, e)
+		}
+		if err != nil {
+			w.mu.Lock()
+			w.consumerErr = err
+			w.mu.Unlock()
+			return err
+		}
+		return nil
+	}, func(e IndexEntry) error {
+		children = append(children, e.GetLocation())
+		w.recordLastModified(e)
+		return nil
+	})
+	if err != nil || !body.archived.IsZero() {
+		return children, withSource(err, url)
+	}
+	return children, w.o.storeWalked(url, body.header, hash, children)
+}
+
+func isSuccess(res *http.Response) bool {
+	return res.StatusCode >= 200 && res.StatusCode <= 299
+}
@@ -0,0 +1,130 @@
+package sitemap
+
+import (
+	"errors"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// errStopped stops parsing without an error when enough entries are consumed.
+var errStopped = errors.New("sitemap: parsing is stopped")
+
+// sitemapEntryPool keeps entries rejected by filters, so they are reused for
+// next elements instead of allocating new ones.
+var sitemapEntryPool sync.Pool
+
+// WithURLFilter adds a filter of entries by URL. Entries the filter returns
+// false for are skipped before the consumer is called. Filters are applied
+// to normalized URLs, see WithURLNormalization.
+func WithURLFilter(keep func(location string) bool) Option {
+	return func(o *options) {
+		o.urlFilters = append(o.urlFilters, keep)
+	}
+}
+
+// WithURLRegexp adds a filter which keeps entries with URLs matching the expression.
+func WithURLRegexp(expr *regexp.Regexp) Option {
+	return WithURLFilter(expr.MatchString)
+}
+
+// WithURLGlob adds a filter which keeps entries with URLs matching any of glob
+// patterns, e.g. "https://example.com/blog/*". A * matches any sequence
+// of characters including slashes, a ? matches a single character.
+func WithURLGlob(patterns ...string) Option {
+	return WithURLFilter(globMatcher(patterns))
+}
+
+// WithModifiedSince skips entries which were modified before the time.
+// Entries without lastmod aren't skipped.
+func WithModifiedSince(since time.Time) Option {
+	return func(o *options) {
+		o.modifiedSince = since
+	}
+}
+
+// WithMaxEntries stops parsing successfully after n entries of a document are
+// passed to the consumer. Skipped entries aren't counted.
+func WithMaxEntries(n int) Option {
+	return func(o *options) {
+		o.maxEntries = n
+	}
+}
+
+// WithURLNormalization enables normalization of URLs before filtering: fragments
+// are removed, schemes and hosts are lowercased and relative locations are resolved
+// against the sitemap URL. The sitemap URL of *FromSite functions and walkers is
+// set automatically, for other functions see WithSitemapURL.
+func WithURLNormalization() Option {
+	return func(o *options) {
+		o.normalize = true
+	}
+}
+
+// accept normalizes the location of the entry and reports whether the entry
+// passes filters.
+func (s *parseState) accept(e *sitemapEntry) bool {
+	e.Location = s.normalize(e.Location)
+
+	for _, keep := range s.o.urlFilters {
+		if !keep(e.Location) {
+			return false
+		}
+	}
+
+	if !s.o.modifiedSince.IsZero() {
+		if lastmod := e.GetLastModified(); lastmod != nil && lastmod.Before(s.o.modifiedSince) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *parseState) normalize(location string) string {
+	if !s.o.normalize {
+		return location
+	}
+
+	u, err := url.Parse(location)
+	if err != nil {
+		return location
+	}
+	if !u.IsAbs() && s.base != nil {
+		u = s.base.ResolveReference(u)
+	}
+
+	u.Fragment = ""
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	return u.String()
+}
+
+func releaseSitemapEntry(e *sitemapEntry) {
+	sitemapEntryPool.Put(e)
+}
+
+// globMatcher returns a function which reports whether an URL matches any of patterns.
+func globMatcher(patterns []string) func(string) bool {
+	exprs := make([]string, len(patterns))
+	for i, pattern := range patterns {
+		exprs[i] = globToRegexp(pattern)
+	}
+	return regexp.MustCompile("^(?:" + strings.Join(exprs, "|") + ")$").MatchString
+}
+
+func globToRegexp(pattern string) string {
+	var b strings.Builder
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,88 @@
+package sitemap
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+const filterSitemap = `<urlset>
+<url><loc>http://example.com/blog/a</loc><lastmod>2019-03-01</lastmod></url>
+<url><loc>http://example.com/blog/b</loc><lastmod>2018-03-01</lastmod></url>
+<url><loc>http://example.com/shop/c</loc><lastmod>2019-03-02</lastmod></url>
+<url><loc>http://example.com/blog/d</loc></url>
+<url><loc>http://example.com/blog/e</loc><lastmod>2019-03-03</lastmod></url>
+</urlset>`
+
+func parseLocations(t *testing.T, data string, opts ...Option) string {
+	var locations []string
+	err := Parse(strings.NewReader(data), func(e Entry) error {
+		locations = append(locations, strings.TrimPrefix(e.GetLocation(), "http://example.com"))
+		return nil
+	}, opts...)
+	if err != nil {
+		t.Fatalf("Parsing failed with error %s", err)
+	}
+	return strings.Join(locations, " ")
+}
+
+func TestParse_Filters(t *testing.T) {
+	since := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		opts     []Option
+		expected string
+	}{
+		{"glob", []Option{WithURLGlob("http://example.com/blog/*")}, "/blog/a /blog/b /blog/d /blog/e"},
+		{"regexp", []Option{WithURLRegexp(regexp.MustCompile(`/(a|c)$`))}, "/blog/a /shop/c"},
+		{"filter", []Option{WithURLFilter(func(location string) bool {
+			return !strings.HasSuffix(location, "/a")
+		})}, "/blog/b /shop/c /blog/d /blog/e"},
+		{"modified since", []Option{WithModifiedSince(since)}, "/blog/a /shop/c /blog/d /blog/e"},
+		{"max entries", []Option{WithMaxEntries(2)}, "/blog/a /blog/b"},
+		{"combined", []Option{WithURLGlob("*/blog/*"), WithModifiedSince(since), WithMaxEntries(2)}, "/blog/a /blog/d"},
+	}
+	for _, test := range tests {
+		if locations := parseLocations(t, filterSitemap, test.opts...); locations != test.expected {
+			t.Errorf("Filter %s: expected %q, but given %q", test.name, test.expected, locations)
+		}
+	}
+}
+
+func TestParse_URLNormalization(t *testing.T) {
+	data := `<urlset>
+<url><loc>HTTP://Example.COM/Blog/a#comments</loc></url>
+<url><loc>/blog/b</loc></url>
+<url><loc>c?page=2</loc></url>
+</urlset>`
+
+	locations := parseLocations(t, data, WithURLNormalization(),
+		WithSitemapURL("http://example.com/blog/sitemap.xml"))
+	if locations != "/Blog/a /blog/b /blog/c?page=2" {
+		t.Errorf("Unexpected normalized locations %q", locations)
+	}
+}
+
+func TestParseFromSite_URLNormalization(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<urlset><url><loc>page#top</loc></url></urlset>")
+	}))
+	defer server.Close()
+
+	var location string
+	err := ParseFromSite(server.URL+"/sitemaps/sitemap.xml", func(e Entry) error {
+		location = e.GetLocation()
+		return nil
+	}, WithURLNormalization())
+	if err != nil {
+		t.Fatalf("Parsing failed with error %s", err)
+	}
+	if location != server.URL+"/sitemaps/page" {
+		t.Errorf("Expected resolved location, but given %s", location)
+	}
+}
@@ -0,0 +1,193 @@
+package sitemap
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+)
+
+// Format is a type alias for a sitemap format.
+type Format = string
+
+// Sitemap formats constants set. See https://www.sitemaps.org/protocol.html#otherformats.
+const (
+	FormatAuto Format = ""     // Detect the format by content
+	FormatXML  Format = "xml"  // XML sitemap or sitemap index
+	FormatText Format = "text" // Plain text file with one URL per line
+	FormatRSS  Format = "rss"  // RSS 2.0 or RSS 1.0 feed
+	FormatAtom Format = "atom" // Atom 1.0 feed
+)
+
+// sniffLength is the max count of bytes inspected to detect the format.
+const sniffLength = 512
+
+// WithFormat sets the format of parsed sitemaps. By default the format is
+// detected by content: text sitemaps are recognized by the absence of markup,
+// feeds are recognized by their root element.
+func WithFormat(format Format) Option {
+	return func(o *options) {
+		o.format = format
+	}
+}
+
+// sniffText detects whether the data is plain text rather than XML.
+// The returned reader must be used instead of the original one.
+func sniffText(reader io.Reader) (bool, io.Reader) {
+	buffered := bufio.NewReaderSize(reader, sniffLength)
+	head, _ := buffered.Peek(sniffLength)
+
+	switch {
+	case bytes.HasPrefix(head, []byte{0xfe, 0xff}), bytes.HasPrefix(head, []byte{0xff, 0xfe}):
+		// UTF-16 byte order mark, a text sitemap must be UTF-8 encoded
+		return false, buffered
+	}
+
+	head = bytes.TrimPrefix(head, []byte("\xef\xbb\xbf"))
+	head = bytes.TrimLeft(head, " \t\r\n")
+	return len(head) == 0 || head[0] != '<', buffered
+}
+
+// rootParser returns the parser of elements of a document of the format with
+// the root element.
+func rootParser(format Format, root string, consume EntryConsumer, consumeIndex IndexEntryConsumer) elementParser {
+	if format == FormatAuto {
+		switch root {
+		case "rss", "RDF":
+			format = FormatRSS
+		case "feed":
+			format = FormatAtom
+		}
+	}
+
+	switch {
+	case consume == nil:
+		if format == FormatRSS || format == FormatAtom {
+			return skipParser
+		}
+		return func(d *xml.Decoder, se *xml.StartElement) error {
+			return indexEntryParser(d, se, consumeIndex)
+		}
+	case format == FormatRSS:
+		return func(d *xml.Decoder, se *xml.StartElement) error {
+			return rssItemParser(d, se, consume)
+		}
+	case format == FormatAtom:
+		return func(d *xml.Decoder, se *xml.StartElement) error {
+			return atomEntryParser(d, se, consume)
+		}
+	case consumeIndex == nil:
+		return func(d *xml.Decoder, se *xml.StartElement) error {
+			return entryParser(d, se, consume)
+		}
+	}
+
+	return func(d *xml.Decoder, se *xml.StartElement) error {
+		if se.Name.Local == "sitemap" {
+			return indexEntryParser(d, se, consumeIndex)
+		}
+		return entryParser(d, se, consume)
+	}
+}
+
+func skipParser(*xml.Decoder, *xml.StartElement) error {
+	return nil
+}
+
+type rssItem struct {
+	Link    string `xml:"link"`
+	PubDate string `xml:"pubDate"`
+	Date    string `xml:"date"`
+}
+
+func rssItemParser(decoder *xml.Decoder, se *xml.StartElement, consume EntryConsumer) error {
+	if se.Name.Local != "item" {
+		return nil
+	}
+
+	item := new(rssItem)
+	if err := decoder.DecodeElement(item, se); err != nil {
+		return err
+	}
+
+	lastmod := item.PubDate
+	if lastmod == "" {
+		// RSS 1.0 uses dc:date
+		lastmod = item.Date
+	}
+	return consumeFeedEntry(item.Link, lastmod, consume)
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr"`
+	Href string `xml:"href,attr"`
+}
+
+type atomEntry struct {
+	Links     []atomLink `xml:"link"`
+	Updated   string     `xml:"updated"`
+	Published string     `xml:"published"`
+}
+
+func atomEntryParser(decoder *xml.Decoder, se *xml.StartElement, consume EntryConsumer) error {
+	if se.Name.Local != "entry" {
+		return nil
+	}
+
+	entry := new(atomEntry)
+	if err := decoder.DecodeElement(entry, se); err != nil {
+		return err
+	}
+
+	var location string
+	for _, l := range entry.Links {
+		if l.Rel == "" || l.Rel == "alternate" {
+			location = l.Href
+			break
+		}
+	}
+
+	lastmod := entry.Updated
+	if lastmod == "" {
+		lastmod = entry.Published
+	}
+	return consumeFeedEntry(location, lastmod, consume)
+}
+
+func consumeFeedEntry(location, lastmod string, consume EntryConsumer) error {
+	location = strings.TrimSpace(location)
+	if location == "" {
+		return nil
+	}
+
+	entry := newSitemapEntry()
+	entry.Location = location
+	entry.LastModified = strings.TrimSpace(lastmod)
+	return consume(entry)
+}
+
+func parseText(reader io.Reader, consume EntryConsumer) error {
+	var line int
+	var offset int64
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line++
+		offset += int64(len(scanner.Bytes())) + 1
+		location := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\xef\xbb\xbf"))
+		if location == "" {
+			continue
+		}
+
+		entry := newSitemapEntry()
+		entry.Location = location
+		if err := consume(entry); err != nil {
+			return err
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return &ParseError{Offset: offset, Line: line + 1, Column: 1, Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,19 @@
+//go:build gofuzz
+// +build gofuzz
+
+package sitemap
+
+import "bytes"
+
+// Fuzz is the entry point of go-fuzz (https://github.com/dvyukov/go-fuzz) for
+// ParseUntrusted. Panics aren't recovered, so the fuzzer finds them.
+func Fuzz(data []byte) int {
+	err := parseDocument(bytes.NewReader(data), untrustedOptions(nil), func(e Entry) error {
+		e.GetLastModified()
+		return nil
+	}, nil)
+	if err != nil {
+		return 0
+	}
+	return 1
+}
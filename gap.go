@@ -0,0 +1,130 @@
+package sitemap
+
+import (
+	"bufio"
+	"io"
+	"net/url"
+	"strings"
+)
+
+// GapKind is a type represents a kind of difference between a sitemap and requests.
+type GapKind = string
+
+// Gap kinds constants set.
+const (
+	NotInSitemap   GapKind = "not-in-sitemap"  // A requested URL is missed in the sitemap
+	NeverRequested GapKind = "never-requested" // A sitemap URL was never requested
+)
+
+// Gap describes an URL which is either requested but missed in the sitemap, or
+// is in the sitemap but was never requested. Location is the URL as it is given
+// in the requests stream or in the sitemap.
+type Gap struct {
+	Kind     GapKind
+	Location string
+}
+
+// GapConsumer is a type represents consumer of gaps between a sitemap and requests.
+type GapConsumer func(Gap) error
+
+// CompareWithRequests parses the sitemap and reads the stream of requested URLs,
+// then reports requested URLs which are missed in the sitemap as soon as they
+// are read and, at the end, sitemap URLs which were never requested.
+//
+// The requests stream contains one request per line, a line is either an URL,
+// or a path, or a line of an access log in common or combined log format.
+// URLs are compared by path and query, so absolute and relative forms match.
+//
+// Keep in mind. Memory grows with the sitemap and with missed requests: each
+// distinct sitemap URL is kept with its path and query key until the end, and
+// so is each distinct requested URL which is missed in the sitemap, so a sitemap
+// of a million URLs takes about twice the size of its locations. The requests
+// stream itself is read line by line. Neither input has to be sorted, access
+// logs are ordered by time, so the sitemap is indexed instead of merge-joined.
+func CompareWithRequests(sitemap io.Reader, requests io.Reader, consumer GapConsumer) error {
+	var order []string
+	requested := make(map[string]bool)
+	locations := make(map[string]string)
+
+	err := Parse(sitemap, func(e Entry) error {
+		key := requestKey(e.GetLocation())
+		if _, ok := locations[key]; !ok {
+			order = append(order, key)
+			locations[key] = e.GetLocation()
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	missed := make(map[string]bool)
+	scanner := bufio.NewScanner(requests)
+	for scanner.Scan() {
+		location := requestLocation(scanner.Text())
+		if location == "" {
+			continue
+		}
+
+		key := requestKey(location)
+		if _, ok := locations[key]; ok {
+			requested[key] = true
+			continue
+		}
+		if missed[key] {
+			continue
+		}
+		missed[key] = true
+
+		if err = consumer(Gap{Kind: NotInSitemap, Location: location}); err != nil {
+			return err
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return err
+	}
+
+	for _, key := range order {
+		if requested[key] {
+			continue
+		}
+		if err = consumer(Gap{Kind: NeverRequested, Location: locations[key]}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// requestLocation extracts the requested URL from a line of the requests stream.
+func requestLocation(line string) string {
+	line = strings.TrimSpace(line)
+
+	// an access log line contains a request line in quotes: "GET /path HTTP/1.1"
+	if i := strings.IndexByte(line, '"'); i >= 0 {
+		fields := strings.Fields(line[i+1:])
+		if len(fields) < 2 {
+			return ""
+		}
+		return fields[1]
+	}
+
+	return line
+}
+
+// requestKey returns path and query of the URL.
+func requestKey(location string) string {
+	u, err := url.Parse(location)
+	if err != nil {
+		return location
+	}
+
+	key := u.EscapedPath()
+	if key == "" {
+		key = "/"
+	}
+	if u.RawQuery != "" {
+		key += "?" + u.RawQuery
+	}
+	return key
+}
@@ -0,0 +1,44 @@
+package sitemap
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCompareWithRequests(t *testing.T) {
+	sitemap, err := os.Open("./testdata/sitemap.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sitemap.Close()
+
+	requests := strings.NewReader(strings.Join([]string{
+		`127.0.0.1 - - [10/Oct/2000:13:55:36 -0700] "GET /tools/ HTTP/1.1" 200 2326 "-" "Googlebot"`,
+		`http://HOST/`,
+		`/old-page/`,
+		`/old-page/`,
+		``,
+		`127.0.0.1 - - [10/Oct/2000:13:55:37 -0700] "GET /search?q=go HTTP/1.1" 200 100`,
+	}, "\n"))
+
+	var gaps []Gap
+	err = CompareWithRequests(sitemap, requests, func(g Gap) error {
+		gaps = append(gaps, g)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Comparison failed with error %s", err)
+	}
+
+	expected := []Gap{
+		{NotInSitemap, "/old-page/"},
+		{NotInSitemap, "/search?q=go"},
+		{NeverRequested, "http://HOST/contribution-to-oss/"},
+		{NeverRequested, "http://HOST/page-1/"},
+	}
+	if !reflect.DeepEqual(gaps, expected) {
+		t.Errorf("Unexpected gaps %v", gaps)
+	}
+}
@@ -0,0 +1,47 @@
+package sitemap
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// WithContentHash enables change detection by content hashes for servers which
+// don't respond with ETag or Last-Modified headers. Walkers with conditional
+// fetching (see WithConditional) store a SHA-256 hash of each document and skip
+// parsing of documents with unchanged hashes. Such documents are still downloaded
+// and are kept in memory while they are hashed, up to MaxDecompressedSize of
+// WithLimits.
+func WithContentHash() Option {
+	return func(o *options) {
+		o.contentHash = true
+	}
+}
+
+// hashed reports whether the document of the response is checked by its hash.
+func (o *options) hashed(header http.Header) bool {
+	return o.contentHash && o.validatorStore != nil && responseValidators(header).IsZero()
+}
+
+// hashBody reads the whole body of the download limited by the document limits
+// and compares its hash with the stored one. The hash is the digest of the
+// download, so the body is hashed once. It returns ErrNotModified if it isn't
+// changed, otherwise a reader of the body and its hash.
+func (o *options) hashBody(location string, body *download, reader io.Reader) (io.Reader, string, error) {
+	data, err := ioutil.ReadAll(o.limitDocument(reader))
+	if err != nil {
+		return nil, "", err
+	}
+	hash := hex.EncodeToString(body.digest.Sum(nil))
+
+	stored, err := o.validatorStore.Get(hashPrefix + location)
+	if err != nil {
+		return nil, "", err
+	}
+	if string(stored) == hash {
+		return nil, "", ErrNotModified
+	}
+	return bytes.NewReader(data), hash, nil
+}
@@ -0,0 +1,74 @@
+package sitemap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCrawler_ContentHash(t *testing.T) {
+	version := 1
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "<urlset><url><loc>http://HOST/%d</loc></url></urlset>", version)
+	}))
+	defer server.Close()
+
+	store := NewMemoryStateStore()
+	crawl := func(opts ...Option) (int, SitemapReport) {
+		counter := 0
+		report, err := NewCrawler(append(opts, WithConditional(store))...).Crawl(context.Background(), server.URL, func(e Entry) error {
+			counter++
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Crawling failed with error %s", err)
+		}
+		return counter, report.Sitemaps[0]
+	}
+
+	if counter, _ := crawl(WithContentHash()); counter != 1 {
+		t.Fatalf("Expected 1 entry of the first crawl, but given %d", counter)
+	}
+	if counter, report := crawl(WithContentHash()); counter != 0 || !report.NotModified {
+		t.Errorf("Expected the unchanged sitemap to be skipped, but given %d entries and %+v", counter, report)
+	}
+	if counter, _ := crawl(); counter != 1 {
+		t.Errorf("Expected the sitemap to be parsed without hashes, but given %d entries", counter)
+	}
+
+	version = 2
+	if counter, report := crawl(WithContentHash()); counter != 1 || report.NotModified {
+		t.Errorf("Expected the changed sitemap to be parsed, but given %d entries and %+v", counter, report)
+	}
+}
+
+func TestCrawler_ContentHashLimits(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	mux.HandleFunc("/index.xml", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "<sitemapindex><sitemap><loc>%s/sitemap.xml</loc></sitemap></sitemapindex>", server.URL)
+	})
+	mux.HandleFunc("/sitemap.xml", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<urlset>")
+		for i := 0; i < 10000; i++ {
+			fmt.Fprintf(w, "<url><loc>http://HOST/%d</loc></url>", i)
+		}
+		fmt.Fprint(w, "</urlset>")
+	})
+
+	report, err := NewCrawler(WithConditional(NewMemoryStateStore()), WithContentHash(),
+		WithLimits(Limits{MaxDecompressedSize: 1000})).Crawl(context.Background(), server.URL+"/index.xml", func(e Entry) error {
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Crawling failed with error %s", err)
+	}
+	var limitErr *LimitExceededError
+	if sitemap := report.Sitemaps[1]; !errors.As(sitemap.Err, &limitErr) || sitemap.UncompressedSize > 2000 {
+		t.Errorf("Expected the limit of the hashed document, but given %+v", sitemap)
+	}
+}
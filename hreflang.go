@@ -0,0 +1,199 @@
+package sitemap
+
+import (
+	"io"
+	"os"
+)
+
+// HreflangProblem is a type represents a kind of hreflang annotation problem.
+type HreflangProblem = string
+
+// Hreflang problems constants set describes why an hreflang cluster is broken.
+const (
+	HreflangNoSelfReference    HreflangProblem = "no-self-reference"     // A page doesn't refer to itself
+	HreflangNoReturnLink       HreflangProblem = "no-return-link"        // An alternate page doesn't refer back
+	HreflangTargetNotInSitemap HreflangProblem = "target-not-in-sitemap" // An alternate page isn't in the sitemap
+	HreflangConflict           HreflangProblem = "conflict"              // A language refers to different pages
+)
+
+// HreflangIssue describes a single problem of hreflang annotations of a page.
+//
+// Location is URL of the page from the sitemap.
+// Hreflang and Href describe the alternate link which has the problem,
+// they are empty for HreflangNoSelfReference.
+type HreflangIssue struct {
+	Location string
+	Hreflang string
+	Href     string
+	Problem  HreflangProblem
+}
+
+// HreflangCluster is a set of pages linked by hreflang annotations.
+// Locations are listed in order of appearance in the sitemap.
+type HreflangCluster struct {
+	Locations []string
+	Issues    []HreflangIssue
+}
+
+// AuditHreflang parses the sitemap which provides by the reader and verifies that
+// xhtml:link alternate annotations are self-referencing and reciprocal across
+// the whole sitemap. It returns broken clusters only.
+//
+// Keep in mind. Unlike parsing the audit keeps all locations and annotations
+// in memory.
+func AuditHreflang(reader io.Reader) ([]HreflangCluster, error) {
+	audit := newHreflangAudit()
+
+	err := Parse(reader, func(e Entry) error {
+		var alternates []Alternate
+		if ap, ok := e.(AlternatesProvider); ok {
+			alternates = ap.GetAlternates()
+		}
+		audit.add(e.GetLocation(), alternates)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return audit.clusters(), nil
+}
+
+// AuditHreflangFromFile reads sitemap from a file and audits it like AuditHreflang does.
+func AuditHreflangFromFile(sitemapPath string) ([]HreflangCluster, error) {
+	sitemapFile, err := os.OpenFile(sitemapPath, os.O_RDONLY, os.ModeExclusive)
+	if err != nil {
+		return nil, err
+	}
+	defer sitemapFile.Close()
+
+	return AuditHreflang(sitemapFile)
+}
+
+// hreflangAudit keeps annotations of the sitemap. Present are all locations of
+// the sitemap, linked are hrefs of alternates, so pages without annotations are
+// still known as targets.
+type hreflangAudit struct {
+	order      []string
+	present    map[string]bool
+	linked     map[string]bool
+	alternates map[string][]Alternate
+	parents    map[string]string
+}
+
+func newHreflangAudit() *hreflangAudit {
+	return &hreflangAudit{
+		present:    make(map[string]bool),
+		linked:     make(map[string]bool),
+		alternates: make(map[string][]Alternate),
+		parents:    make(map[string]string),
+	}
+}
+
+func (a *hreflangAudit) add(location string, alternates []Alternate) {
+	if !a.present[location] {
+		a.present[location] = true
+		a.order = append(a.order, location)
+	}
+	if len(alternates) == 0 {
+		return
+	}
+
+	a.alternates[location] = append(a.alternates[location], alternates...)
+	for _, l := range alternates {
+		a.linked[l.Href] = true
+		a.union(location, l.Href)
+	}
+}
+
+func (a *hreflangAudit) find(location string) string {
+	parent, ok := a.parents[location]
+	if !ok || parent == location {
+		a.parents[location] = location
+		return location
+	}
+	root := a.find(parent)
+	a.parents[location] = root
+	return root
+}
+
+func (a *hreflangAudit) union(x, y string) {
+	rx, ry := a.find(x), a.find(y)
+	if rx != ry {
+		a.parents[ry] = rx
+	}
+}
+
+func (a *hreflangAudit) issues(location string) []HreflangIssue {
+	var issues []HreflangIssue
+	alternates := a.alternates[location]
+	if len(alternates) == 0 {
+		// pages without annotations are reported by pages which refer to them
+		return nil
+	}
+
+	self := false
+	languages := make(map[string]string)
+	for _, l := range alternates {
+		if l.Href == location {
+			self = true
+		}
+
+		if href, ok := languages[l.Hreflang]; ok && href != l.Href {
+			issues = append(issues, HreflangIssue{location, l.Hreflang, l.Href, HreflangConflict})
+		}
+		languages[l.Hreflang] = l.Href
+
+		if l.Href == location {
+			continue
+		}
+		if !a.present[l.Href] {
+			issues = append(issues, HreflangIssue{location, l.Hreflang, l.Href, HreflangTargetNotInSitemap})
+		} else if !refersTo(a.alternates[l.Href], location) {
+			issues = append(issues, HreflangIssue{location, l.Hreflang, l.Href, HreflangNoReturnLink})
+		}
+	}
+	if !self {
+		issues = append(issues, HreflangIssue{Location: location, Problem: HreflangNoSelfReference})
+	}
+
+	return issues
+}
+
+func (a *hreflangAudit) clusters() []HreflangCluster {
+	var result []HreflangCluster
+	byRoot := make(map[string]int)
+
+	for _, location := range a.order {
+		if len(a.alternates[location]) == 0 && !a.linked[location] {
+			continue
+		}
+		root := a.find(location)
+		index, ok := byRoot[root]
+		if !ok {
+			index = len(result)
+			byRoot[root] = index
+			result = append(result, HreflangCluster{})
+		}
+
+		result[index].Locations = append(result[index].Locations, location)
+		result[index].Issues = append(result[index].Issues, a.issues(location)...)
+	}
+
+	broken := result[:0]
+	for _, cluster := range result {
+		if len(cluster.Issues) > 0 {
+			broken = append(broken, cluster)
+		}
+	}
+	return broken
+}
+
+func refersTo(alternates []Alternate, location string) bool {
+	for _, l := range alternates {
+		if l.Href == location {
+			return true
+		}
+	}
+	return false
+}
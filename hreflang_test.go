@@ -0,0 +1,71 @@
+package sitemap
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAuditHreflang(t *testing.T) {
+	clusters, err := AuditHreflangFromFile("./testdata/sitemap-hreflang.xml")
+	if err != nil {
+		t.Fatalf("Audit failed with error %s", err)
+	}
+
+	if len(clusters) != 1 {
+		t.Fatalf("Expected 1 broken cluster, but given %d", len(clusters))
+	}
+
+	expected := HreflangCluster{
+		Locations: []string{"http://HOST/en/about/", "http://HOST/de/about/"},
+		Issues: []HreflangIssue{
+			{"http://HOST/en/about/", "de", "http://HOST/de/about/", HreflangNoReturnLink},
+			{"http://HOST/en/about/", "fr", "http://HOST/fr/about/", HreflangTargetNotInSitemap},
+		},
+	}
+	if !reflect.DeepEqual(clusters[0], expected) {
+		t.Errorf("Unexpected cluster %+v", clusters[0])
+	}
+}
+
+func TestGetAlternates(t *testing.T) {
+	var alternates [][]Alternate
+	err := ParseFromFile("./testdata/sitemap-hreflang.xml", func(e Entry) error {
+		alternates = append(alternates, e.(ExtendedEntry).GetAlternates())
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Parsing failed with error %s", err)
+	}
+
+	if len(alternates) != 4 {
+		t.Fatalf("Expected 4 entries, but given %d", len(alternates))
+	}
+
+	expected := []Alternate{
+		{Hreflang: "en", Href: "http://HOST/en/"},
+		{Hreflang: "de", Href: "http://HOST/de/"},
+	}
+	if !reflect.DeepEqual(alternates[0], expected) {
+		t.Errorf("Unexpected alternates %+v", alternates[0])
+	}
+	if len(alternates[3]) != 1 || alternates[3][0].Hreflang != "de" {
+		t.Errorf("Unexpected alternates %+v", alternates[3])
+	}
+}
+
+func TestAuditHreflang_TargetWithoutAnnotations(t *testing.T) {
+	clusters, err := AuditHreflangFromFile("./testdata/sitemap-hreflang-plain.xml")
+	if err != nil {
+		t.Fatalf("Audit failed with error %s", err)
+	}
+
+	expected := []HreflangCluster{{
+		Locations: []string{"http://HOST/en/", "http://HOST/de/"},
+		Issues: []HreflangIssue{
+			{"http://HOST/en/", "de", "http://HOST/de/", HreflangNoReturnLink},
+		},
+	}}
+	if !reflect.DeepEqual(clusters, expected) {
+		t.Errorf("Unexpected clusters %+v", clusters)
+	}
+}
@@ -0,0 +1,61 @@
+package sitemap
+
+import "time"
+
+// WithInheritedLastModified makes walks of sitemap indexes give entries without
+// lastmod the lastmod of their sitemap in the parent index, so schedulers get
+// a freshness signal of sitemaps which don't have per-URL dates. Such entries
+// are flagged: GetLastModifiedRaw of RawLastModifiedProvider returns an empty
+// string and they implement InheritedProvider which reports true. Children of
+// indexes skipped as unchanged (see WithConditional) have no lastmod to inherit.
+func WithInheritedLastModified() Option {
+	return func(o *options) {
+		o.inheritLastModified = true
+	}
+}
+
+// recordLastModified keeps the lastmod of the child of an index if
+// WithInheritedLastModified is set. The newest one is kept if the child is
+// listed by several indexes.
+func (w *walker) recordLastModified(e IndexEntry) {
+	lastmod := e.GetLastModified()
+	if !w.o.inheritLastModified || lastmod == nil {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.inherited == nil {
+		w.inherited = make(map[string]*time.Time)
+	}
+	if old := w.inherited[e.GetLocation()]; old == nil || lastmod.After(*old) {
+		w.inherited[e.GetLocation()] = lastmod
+	}
+}
+
+// inheritedLastModified returns the lastmod of the sitemap in its parent index.
+func (w *walker) inheritedLastModified(url string) *time.Time {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.inherited[url]
+}
+
+// inheritLastModified returns the entry with the inherited lastmod.
+func inheritLastModified(e Entry, lastmod *time.Time) Entry {
+	se, ok := e.(*sitemapEntry)
+	if !ok {
+		se = copyEntry(e)
+	}
+	se.ParsedLastModified = lastmod
+	se.inherited = true
+	return se
+}
+
+// lastModifiedRaw returns the raw lastmod of the entry if it implements
+// RawLastModifiedProvider, otherwise an empty string.
+func lastModifiedRaw(e interface{}) string {
+	if rp, ok := e.(RawLastModifiedProvider); ok {
+		return rp.GetLastModifiedRaw()
+	}
+	return ""
+}
@@ -0,0 +1,81 @@
+package sitemap
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWithInheritedLastModified(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	mux.HandleFunc("/index.xml", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "<sitemapindex><sitemap><loc>%[1]s/dated.xml</loc><lastmod>2020-05-01</lastmod></sitemap>"+
+			"<sitemap><loc>%[1]s/undated.xml</loc></sitemap></sitemapindex>", server.URL)
+	})
+	mux.HandleFunc("/dated.xml", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<urlset><url><loc>http://example.com/a</loc></url>"+
+			"<url><loc>http://example.com/b</loc><lastmod>2020-01-01</lastmod></url></urlset>")
+	})
+	mux.HandleFunc("/undated.xml", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<urlset><url><loc>http://example.com/c</loc></url></urlset>")
+	})
+
+	type result struct {
+		lastmod   *time.Time
+		inherited bool
+	}
+	crawl := func(opts ...Option) map[string]result {
+		results := make(map[string]result)
+		_, err := NewCrawler(opts...).Crawl(context.Background(), server.URL+"/index.xml", func(e Entry) error {
+			results[e.GetLocation()] = result{e.GetLastModified(), e.(InheritedProvider).IsLastModifiedInherited()}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Crawling failed with error %s", err)
+		}
+		return results
+	}
+
+	results := crawl(WithInheritedLastModified())
+	a, b, c := results["http://example.com/a"], results["http://example.com/b"], results["http://example.com/c"]
+	if a.lastmod == nil || !a.lastmod.Equal(time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC)) || !a.inherited {
+		t.Errorf("Expected inherited lastmod of the index, but given %+v", a)
+	}
+	if b.lastmod == nil || !b.lastmod.Equal(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)) || b.inherited {
+		t.Errorf("Expected own lastmod, but given %+v", b)
+	}
+	if c.lastmod != nil || c.inherited {
+		t.Errorf("Expected no lastmod, but given %+v", c)
+	}
+
+	if a := crawl()["http://example.com/a"]; a.lastmod != nil || a.inherited {
+		t.Errorf("Expected no lastmod without the option, but given %+v", a)
+	}
+}
+
+// minimalEntry implements Entry only, like entries of other packages.
+type minimalEntry struct{}
+
+func (minimalEntry) GetLocation() string           { return "http://example.com/minimal" }
+func (minimalEntry) GetLastModified() *time.Time   { return nil }
+func (minimalEntry) GetChangeFrequency() Frequency { return Daily }
+func (minimalEntry) GetPriority() float32          { return 0.5 }
+
+func TestInheritLastModified_MinimalEntry(t *testing.T) {
+	lastmod := time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC)
+	if raw := lastModifiedRaw(minimalEntry{}); raw != "" {
+		t.Errorf("Expected empty raw lastmod, but given %q", raw)
+	}
+
+	e := inheritLastModified(minimalEntry{}, &lastmod)
+	if e.GetLocation() != "http://example.com/minimal" || !e.GetLastModified().Equal(lastmod) ||
+		lastModifiedRaw(e) != "" || !e.(InheritedProvider).IsLastModifiedInherited() {
+		t.Errorf("Unexpected entry %+v", e)
+	}
+}
@@ -0,0 +1,115 @@
+package sitemap
+
+import (
+	"context"
+	"io"
+)
+
+// ParseToChannel parses data which provides by the reader in a new goroutine
+// and sends each entry to the returned entries channel. Parsing is the same as
+// Parse does, so it accepts the same options.
+//
+// The entries channel is closed when parsing is finished, after that the errors
+// channel returns the parsing error if any and is closed too. Cancel the context
+// to stop parsing early, otherwise the goroutine is blocked until all entries
+// are received. See WithEntryBuffer to let it parse ahead of the receiver.
+func ParseToChannel(ctx context.Context, reader io.Reader, opts ...Option) (<-chan Entry, <-chan error) {
+	o := newOptions(opts)
+	entries := make(chan Entry, o.entryBufferOr(0))
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(errs)
+		err := Parse(reader, func(e Entry) error {
+			select {
+			case entries <- e:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}, opts...)
+		close(entries)
+		if err != nil {
+			errs <- err
+		}
+	}()
+
+	return entries, errs
+}
+
+// Iterator is a pull-style alternative of EntryConsumer. It is used like
+// bufio.Scanner:
+//
+//	it := sitemap.NewIterator(reader)
+//	defer it.Close()
+//	for it.Next() {
+//		fmt.Println(it.Entry().GetLocation())
+//	}
+//	if err := it.Err(); err != nil {
+//		// handle the error
+//	}
+type Iterator struct {
+	entries <-chan Entry
+	errs    <-chan error
+	cancel  context.CancelFunc
+	entry   Entry
+	err     error
+	done    bool
+}
+
+// NewIterator creates a new iterator over entries of data which provides by
+// the reader. It accepts the same options as Parse does.
+func NewIterator(reader io.Reader, opts ...Option) *Iterator {
+	ctx, cancel := context.WithCancel(context.Background())
+	entries, errs := ParseToChannel(ctx, reader, opts...)
+	return &Iterator{entries: entries, errs: errs, cancel: cancel}
+}
+
+// Next advances the iterator to the next entry, which is available by Entry.
+// It returns false when there are no more entries or parsing failed.
+func (it *Iterator) Next() bool {
+	if it.done {
+		return false
+	}
+
+	e, ok := <-it.entries
+	if !ok {
+		it.finish()
+		return false
+	}
+	it.entry = e
+	return true
+}
+
+// Entry returns the current entry.
+func (it *Iterator) Entry() Entry {
+	return it.entry
+}
+
+// Err returns the parsing error if any. It is nil if the iterator was closed
+// before the end of data.
+func (it *Iterator) Err() error {
+	return it.err
+}
+
+// Close stops parsing and releases the goroutine of the iterator.
+// It is safe to call Close several times.
+func (it *Iterator) Close() error {
+	if it.done {
+		return nil
+	}
+
+	it.cancel()
+	it.finish()
+	if it.err == context.Canceled {
+		it.err = nil
+	}
+	return nil
+}
+
+func (it *Iterator) finish() {
+	it.done = true
+	it.entry = nil
+	it.err = <-it.errs
+	it.cancel()
+}
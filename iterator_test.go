@@ -0,0 +1,66 @@
+package sitemap
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestIterator(t *testing.T) {
+	sitemapFile, err := os.Open("./testdata/sitemap.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sitemapFile.Close()
+
+	it := NewIterator(sitemapFile)
+	defer it.Close()
+
+	counter := 0
+	for it.Next() {
+		if it.Entry().GetLocation() == "" {
+			t.Errorf("Entry %d has no location", counter)
+		}
+		counter++
+	}
+	if err := it.Err(); err != nil {
+		t.Fatalf("Iteration failed with error %s", err)
+	}
+	if counter != 4 {
+		t.Errorf("Expected 4 entries, but given %d", counter)
+	}
+}
+
+func TestIterator_Close(t *testing.T) {
+	it := NewIterator(strings.NewReader("<urlset><url><loc>http://a/</loc></url><url><loc>http://b/</loc></url></urlset>"))
+	if !it.Next() || it.Entry().GetLocation() != "http://a/" {
+		t.Fatalf("Expected the first entry, but given %v", it.Entry())
+	}
+	it.Close()
+	if it.Next() || it.Err() != nil {
+		t.Errorf("Expected no entries and no error after Close, but given error %v", it.Err())
+	}
+}
+
+func TestIterator_ParseError(t *testing.T) {
+	it := NewIterator(strings.NewReader("<urlset><url><loc>http://a/</loc></url><url>"))
+	defer it.Close()
+	for it.Next() {
+	}
+	if _, ok := it.Err().(*ParseError); !ok {
+		t.Errorf("Expected ParseError, but given %v", it.Err())
+	}
+}
+
+func TestParseToChannel_Cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	entries, errs := ParseToChannel(ctx, strings.NewReader(
+		"<urlset><url><loc>http://a/</loc></url><url><loc>http://b/</loc></url></urlset>"))
+
+	<-entries
+	cancel()
+	if err := <-errs; err != context.Canceled {
+		t.Errorf("Expected context.Canceled, but given %v", err)
+	}
+}
@@ -0,0 +1,81 @@
+package sitemap
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// jsonRecord is a JSON representation of an entry.
+type jsonRecord struct {
+	Location        string                 `json:"loc"`
+	LastModified    *time.Time             `json:"lastmod,omitempty"`
+	ChangeFrequency Frequency              `json:"changefreq,omitempty"`
+	Priority        float32                `json:"priority,omitempty"`
+	Inherited       bool                   `json:"lastmod_inherited,omitempty"`
+	Metadata        map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// JSONWriter writes entries as newline delimited JSON, an object per line
+// with loc, lastmod, changefreq, priority, lastmod_inherited (see
+// WithInheritedLastModified) and metadata attached by AttachMetadata. Each entry is written by a single call of the underlying
+// writer, wrap it by bufio.Writer to buffer them. Gzip compression is enabled
+// by WithGzip option.
+type JSONWriter struct {
+	gz      *gzip.Writer
+	encoder *json.Encoder
+	closed  bool
+}
+
+// NewJSONWriter creates a new JSON writer. You must call Close to finish
+// compressed data.
+func NewJSONWriter(w io.Writer, opts ...Option) *JSONWriter {
+	j := new(JSONWriter)
+	if newOptions(opts).gzip {
+		j.gz = gzip.NewWriter(w)
+		w = j.gz
+	}
+	j.encoder = json.NewEncoder(w)
+	return j
+}
+
+// Add writes the entry with its metadata. It can be used as EntryConsumer,
+// Pipe uses it instead of WriteEntry, so metadata of transforms is written.
+func (j *JSONWriter) Add(e Entry) error {
+	if j.closed {
+		return ErrWriterClosed
+	}
+	inherited, _ := e.(InheritedProvider)
+	return j.encoder.Encode(jsonRecord{
+		Location:        e.GetLocation(),
+		LastModified:    e.GetLastModified(),
+		ChangeFrequency: e.GetChangeFrequency(),
+		Priority:        e.GetPriority(),
+		Inherited:       inherited != nil && inherited.IsLastModifiedInherited(),
+		Metadata:        MetadataOf(e),
+	})
+}
+
+// WriteEntry writes an entry without metadata. The lastmod and the priority
+// can be nil.
+func (j *JSONWriter) WriteEntry(loc string, lastmod *time.Time, changefreq Frequency, priority *float32) error {
+	e := &sitemapEntry{Location: loc, ParsedLastModified: lastmod, ChangeFrequency: changefreq}
+	if priority != nil {
+		e.Priority = *priority
+	}
+	return j.Add(e)
+}
+
+// Close flushes compressed data. It doesn't close the underlying writer.
+func (j *JSONWriter) Close() error {
+	if j.closed {
+		return ErrWriterClosed
+	}
+	j.closed = true
+
+	if j.gz != nil {
+		return j.gz.Close()
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+package sitemap
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// LimitKind is a type represents a kind of safety limits.
+type LimitKind = string
+
+// Safety limit kinds constants set.
+const (
+	LimitDownloadSize     LimitKind = "download size"
+	LimitDecompressedSize LimitKind = "decompressed size"
+	LimitEntries          LimitKind = "entries"
+	LimitElementSize      LimitKind = "element size"
+	LimitDepth            LimitKind = "depth"
+	LimitDeadline         LimitKind = "deadline"
+)
+
+// Limits are safety limits of parsing of untrusted sitemaps, like decompression
+// bombs or endless streams of elements. Zero values mean no limit.
+//
+// MaxDownloadSize limits bytes of each downloaded response as they are received.
+// MaxDecompressedSize limits bytes of each parsed document after decompression.
+// MaxEntries limits parsed entries of each document including skipped by filters.
+// MaxElementSize limits the size of a single XML tag with its attributes or of a text.
+// MaxDepth limits nesting of XML elements, it is counted by tags, so tags
+// inside comments and CDATA sections are counted too.
+// Deadline limits the overall time of the call, reads of a stalled connection
+// are limited by WithTimeout only.
+type Limits struct {
+	MaxDownloadSize     int64
+	MaxDecompressedSize int64
+	MaxEntries          int
+	MaxElementSize      int64
+	MaxDepth            int
+	Deadline            time.Duration
+}
+
+// LimitExceededError is returned when parsing is aborted by a limit set by
+// WithLimits. It can be wrapped by ParseError, check it by errors.As.
+type LimitExceededError struct {
+	Limit LimitKind
+	Max   int64
+}
+
+func (e *LimitExceededError) Error() string {
+	if e.Limit == LimitDeadline {
+		return fmt.Sprintf("sitemap: deadline of %s is exceeded", time.Duration(e.Max))
+	}
+	return fmt.Sprintf("sitemap: limit of %s %d is exceeded", e.Limit, e.Max)
+}
+
+// WithLimits sets safety limits of parsing. A download or parsing which hits
+// any of the limits is aborted with LimitExceededError.
+func WithLimits(limits Limits) Option {
+	return func(o *options) {
+		o.limits = limits
+	}
+}
+
+// deadlineExceeded returns LimitExceededError if the deadline is passed.
+func (o *options) deadlineExceeded() error {
+	if o.limits.Deadline > 0 && !time.Now().Before(o.deadline) {
+		return &LimitExceededError{Limit: LimitDeadline, Max: int64(o.limits.Deadline)}
+	}
+	return nil
+}
+
+// limitResponse limits the body of the response by MaxDownloadSize and the deadline.
+func (o *options) limitResponse(res *http.Response) {
+	if o.limits.MaxDownloadSize > 0 || o.limits.Deadline > 0 {
+		res.Body = &readCloser{
+			Reader: &limitedReader{reader: res.Body, o: o, kind: LimitDownloadSize, max: o.limits.MaxDownloadSize},
+			Closer: res.Body,
+		}
+	}
+}
+
+// limitDocument limits the document by MaxDecompressedSize and the deadline.
+func (o *options) limitDocument(reader io.Reader) io.Reader {
+	if o.limits.MaxDecompressedSize > 0 || o.limits.Deadline > 0 {
+		return &limitedReader{reader: reader, o: o, kind: LimitDecompressedSize, max: o.limits.MaxDecompressedSize}
+	}
+	return reader
+}
+
+// limitElements limits elements of an XML document by MaxElementSize and MaxDepth.
+func (o *options) limitElements(reader io.Reader) io.Reader {
+	if o.limits.MaxElementSize > 0 {
+		reader = &elementLimitedReader{reader: reader, max: o.limits.MaxElementSize}
+	}
+	if o.limits.MaxDepth > 0 {
+		reader = &depthLimitedReader{reader: reader, max: o.limits.MaxDepth}
+	}
+	return reader
+}
+
+// limitedReader fails when more than max bytes are read or the deadline is
+// passed. Zero max means no limit of size.
+type limitedReader struct {
+	reader io.Reader
+	o      *options
+	kind   LimitKind
+	max    int64
+	n      int64
+}
+
+func (r *limitedReader) Read(p []byte) (int, error) {
+	if err := r.o.deadlineExceeded(); err != nil {
+		return 0, err
+	}
+
+	n, err := r.reader.Read(p)
+	r.n += int64(n)
+	if r.max > 0 && r.n > r.max {
+		return n, &LimitExceededError{Limit: r.kind, Max: r.max}
+	}
+	return n, err
+}
+
+// elementLimitedReader fails when there are more than max bytes between
+// angle brackets, so decoding of a single token can't exhaust memory.
+type elementLimitedReader struct {
+	reader io.Reader
+	max    int64
+	run    int64
+}
+
+func (r *elementLimitedReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	data := p[:n]
+	for len(data) > 0 {
+		i := bytes.IndexAny(data, "<>")
+		if i < 0 {
+			r.run += int64(len(data))
+			break
+		}
+		r.run += int64(i)
+		if r.run > r.max {
+			break
+		}
+		r.run = 0
+		data = data[i+1:]
+	}
+	if r.run > r.max {
+		return n, &LimitExceededError{Limit: LimitElementSize, Max: r.max}
+	}
+	return n, err
+}
+
+// depthLimitedReader fails when elements are nested deeper than max. Tags are
+// counted as opening if '<' is followed by a name, '</' and '/>' are closing.
+type depthLimitedReader struct {
+	reader io.Reader
+	max    int
+	depth  int
+	// afterOpen is set after '<' and slash after '/' of the previous byte.
+	afterOpen bool
+	slash     bool
+}
+
+func (r *depthLimitedReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	for _, b := range p[:n] {
+		switch {
+		case r.afterOpen:
+			r.afterOpen = false
+			switch b {
+			case '/':
+				r.depth--
+			case '!', '?':
+			default:
+				r.depth++
+			}
+		case b == '<':
+			r.afterOpen = true
+		case b == '>' && r.slash:
+			r.depth--
+		}
+		r.slash = b == '/'
+
+		if r.depth > r.max {
+			return n, &LimitExceededError{Limit: LimitDepth, Max: int64(r.max)}
+		}
+	}
+	return n, err
+}
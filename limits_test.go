@@ -0,0 +1,97 @@
+package sitemap
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse_Limits(t *testing.T) {
+	urls := strings.Repeat("<url><loc>http://HOST/</loc></url>", 3)
+	tests := []struct {
+		name   string
+		data   string
+		limits Limits
+		kind   LimitKind
+	}{
+		{"entries", "<urlset>" + urls + "</urlset>", Limits{MaxEntries: 2}, LimitEntries},
+		{"decompressed size", "<urlset>" + urls + "</urlset>", Limits{MaxDecompressedSize: 50}, LimitDecompressedSize},
+		{"element size", "<urlset><url><loc>http://HOST/" + strings.Repeat("a", 5000) + "</loc></url></urlset>", Limits{MaxElementSize: 1000}, LimitElementSize},
+		{"deadline", "<urlset>" + urls + "</urlset>", Limits{Deadline: time.Nanosecond}, LimitDeadline},
+	}
+
+	for _, test := range tests {
+		err := Parse(strings.NewReader(test.data), func(e Entry) error {
+			return nil
+		}, WithLimits(test.limits))
+
+		var limitErr *LimitExceededError
+		if !errors.As(err, &limitErr) || limitErr.Limit != test.kind {
+			t.Errorf("%s: expected LimitExceededError of %s, but given %v", test.name, test.kind, err)
+		}
+	}
+}
+
+func TestParse_LimitsNotExceeded(t *testing.T) {
+	err := ParseFromFile("./testdata/sitemap.xml", func(e Entry) error {
+		return nil
+	}, WithLimits(Limits{MaxEntries: 4, MaxDecompressedSize: 1 << 20, MaxElementSize: 1000, Deadline: time.Minute}))
+	if err != nil {
+		t.Fatalf("Parsing failed with error %s", err)
+	}
+}
+
+func TestParseFromSite_MaxDownloadSize(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<urlset>")
+		for i := 0; i < 1000; i++ {
+			fmt.Fprintf(w, "<url><loc>http://HOST/%d</loc></url>", i)
+		}
+		fmt.Fprint(w, "</urlset>")
+	}))
+	defer server.Close()
+
+	counter := 0
+	err := ParseFromSite(server.URL, func(e Entry) error {
+		counter++
+		return nil
+	}, WithLimits(Limits{MaxDownloadSize: 1000}))
+
+	var limitErr *LimitExceededError
+	if !errors.As(err, &limitErr) || limitErr.Limit != LimitDownloadSize {
+		t.Fatalf("Expected LimitExceededError of download size, but given %v", err)
+	}
+	if counter == 0 || counter >= 1000 {
+		t.Errorf("Expected parsing to be aborted in the middle, but given %d entries", counter)
+	}
+}
+
+func TestParseIndexFromSite_Limits(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<sitemapindex>"+strings.Repeat("<sitemap><loc>http://HOST/a.xml</loc><lastmod>05/08/2015</lastmod></sitemap>", 3)+"</sitemapindex>")
+	}))
+	defer server.Close()
+
+	counter := 0
+	err := ParseIndexFromSite(server.URL, func(e IndexEntry) error {
+		counter++
+		return nil
+	}, WithLimits(Limits{MaxEntries: 1}))
+	var limitErr *LimitExceededError
+	if !errors.As(err, &limitErr) || limitErr.Limit != LimitEntries || counter != 1 {
+		t.Errorf("Expected limit of entries after 1 entry, but given %v after %d", err, counter)
+	}
+
+	var lastmod *time.Time
+	err = ParseIndexFromSite(server.URL, func(e IndexEntry) error {
+		lastmod = e.GetLastModified()
+		return nil
+	}, WithDateLayouts("01/02/2006"))
+	if err != nil || lastmod == nil || !lastmod.Equal(time.Date(2015, 5, 8, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected lastmod of the date layout, but given %v with error %v", lastmod, err)
+	}
+}
@@ -0,0 +1,114 @@
+package sitemap
+
+import (
+	"io"
+	"os"
+)
+
+// Severity is a type alias for severity of an issue.
+type Severity = string
+
+// Severity constants set.
+const (
+	SeverityError   Severity = "error"   // The sitemap violates the protocol
+	SeverityWarning Severity = "warning" // The sitemap is valid, but likely has a mistake
+	SeverityInfo    Severity = "info"    // The sitemap can be improved
+)
+
+// IssueCode is a type alias for a code of an issue kind.
+type IssueCode = string
+
+// Issue describes a problem found by Lint or Validate.
+//
+// Location is URL of the offending entry, it is empty for issues of the whole sitemap.
+// Suggestion is a proposed replacement of Location, it can be empty.
+// Line and Offset are the position of the offending element, they are zero
+// when the position is unknown.
+type Issue struct {
+	Code       IssueCode
+	Severity   Severity
+	Location   string
+	Message    string
+	Suggestion string
+	Line       int
+	Offset     int64
+}
+
+// IssueConsumer is a type represents consumer of found issues.
+type IssueConsumer func(Issue) error
+
+// LintRule is an interface of a check of sitemap entries. A rule can keep state
+// between entries of a sitemap, so a rule instance must not be shared by
+// concurrent runs.
+//
+// Check returns issues of the entry or nil.
+type LintRule interface {
+	Check(e Entry) []Issue
+}
+
+// WithLintRules sets rules used by Lint instead of the default ones.
+func WithLintRules(rules ...LintRule) Option {
+	return func(o *options) {
+		o.lintRules = append(o.lintRules, rules...)
+	}
+}
+
+// DefaultLintRules returns new instances of rules which Lint uses by default.
+func DefaultLintRules() []LintRule {
+	return []LintRule{NewDuplicateRule(), NewLimitMarginRule(DefaultLimitMargin)}
+}
+
+// Lint parses the sitemap which provides by the reader, checks each entry by
+// rules (see WithLintRules) and for each found issue calls the consumer's function.
+// Issues of rules which implement DocumentRule are passed after all entries.
+func Lint(reader io.Reader, consumer IssueConsumer, opts ...Option) error {
+	o := newOptions(opts)
+	rules := o.lintRules
+	if len(rules) == 0 {
+		rules = DefaultLintRules()
+	}
+
+	counter := &countingReader{reader: reader}
+	entries := 0
+	err := parseDocument(counter, o, func(e Entry) error {
+		entries++
+		for _, rule := range rules {
+			if err := consumeIssues(rule.Check(e), consumer); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, nil)
+	if err != nil {
+		return err
+	}
+
+	for _, rule := range rules {
+		if document, ok := rule.(DocumentRule); ok {
+			if err = consumeIssues(document.CheckDocument(entries, counter.count), consumer); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func consumeIssues(issues []Issue, consumer IssueConsumer) error {
+	for _, issue := range issues {
+		if err := consumer(issue); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LintFromFile reads sitemap from a file and checks it like Lint does.
+func LintFromFile(sitemapPath string, consumer IssueConsumer, opts ...Option) error {
+	sitemapFile, err := os.OpenFile(sitemapPath, os.O_RDONLY, os.ModeExclusive)
+	if err != nil {
+		return err
+	}
+	defer sitemapFile.Close()
+
+	return withSource(Lint(sitemapFile, consumer, opts...), sitemapPath)
+}
@@ -0,0 +1,75 @@
+package sitemap
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLint_Duplicates(t *testing.T) {
+	sitemap := `<urlset>
+		<url><loc>https://HOST/page/</loc></url>
+		<url><loc>https://HOST/page/</loc></url>
+		<url><loc>http://host/Page</loc></url>
+		<url><loc>https://HOST/page/?utm_source=mail&amp;id=1</loc></url>
+		<url><loc>https://HOST/page/?id=1</loc></url>
+		<url><loc>https://HOST/other/</loc></url>
+	</urlset>`
+
+	var issues []Issue
+	err := Lint(strings.NewReader(sitemap), func(issue Issue) error {
+		issue.Message = ""
+		issues = append(issues, issue)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Lint failed with error %s", err)
+	}
+
+	expected := []Issue{
+		{Code: IssueDuplicate, Severity: SeverityWarning, Location: "https://HOST/page/"},
+		{Code: IssueNearDuplicate, Severity: SeverityWarning, Location: "http://host/Page", Suggestion: "https://host/page/"},
+		{Code: IssueNearDuplicate, Severity: SeverityWarning, Location: "https://HOST/page/?id=1", Suggestion: "https://host/page/?id=1"},
+	}
+	if !reflect.DeepEqual(issues, expected) {
+		t.Errorf("Unexpected issues %+v", issues)
+	}
+}
+
+func TestLint_QueryPolicy(t *testing.T) {
+	sitemap := `<urlset>
+		<url><loc>https://HOST/a?utm_source=x&amp;page=2</loc></url>
+		<url><loc>https://HOST/b?color=red&amp;size=m</loc></url>
+		<url><loc>https://HOST/c</loc></url>
+	</urlset>`
+	policy := QueryPolicy{Strip: []string{"utm_*"}, Allow: []string{"page", "color"}}
+
+	var issues []string
+	err := Lint(strings.NewReader(sitemap), func(issue Issue) error {
+		issues = append(issues, issue.Code+" "+issue.Suggestion)
+		return nil
+	}, WithLintRules(policy.LintRule()))
+	if err != nil {
+		t.Fatalf("Lint failed with error %s", err)
+	}
+
+	expected := []string{
+		IssueStrippedQuery + " https://HOST/a?page=2",
+		IssueStrippedQuery + " https://HOST/b?color=red",
+	}
+	if !reflect.DeepEqual(issues, expected) {
+		t.Errorf("Unexpected issues %v", issues)
+	}
+
+	policy.Reject = true
+	var kept []string
+	err = Parse(strings.NewReader(sitemap), func(e Entry) error {
+		if e, _ = policy.Transform()(e); e != nil {
+			kept = append(kept, e.GetLocation())
+		}
+		return nil
+	})
+	if err != nil || strings.Join(kept, " ") != "https://HOST/c" {
+		t.Errorf("Unexpected kept entries %v %v", kept, err)
+	}
+}
@@ -0,0 +1,58 @@
+package sitemap
+
+import "fmt"
+
+// Issue codes of the limit margin rule.
+const (
+	IssueNearEntryLimit IssueCode = "near-entry-limit" // A file has almost MaxEntries entries
+	IssueNearSizeLimit  IssueCode = "near-size-limit"  // A file is almost MaxFileSize bytes
+)
+
+// DefaultLimitMargin is the share of protocol limits which the limit margin
+// rule of DefaultLintRules warns at.
+const DefaultLimitMargin = 0.9
+
+// DocumentRule is an optional interface of lint rules which check the whole
+// document. Lint calls CheckDocument after the last entry with the count of
+// entries and the size of the document in bytes as it is read.
+type DocumentRule interface {
+	CheckDocument(entries int, size int64) []Issue
+}
+
+// NewLimitMarginRule returns a rule which warns about sitemaps which reach the
+// margin share of MaxEntries or MaxFileSize, e.g. 0.9 warns at 90%, so sites
+// are noticed before search engines truncate them. A margin out of (0, 1]
+// means DefaultLimitMargin. Files over the limits are errors of Validate.
+func NewLimitMarginRule(margin float64) LintRule {
+	if margin <= 0 || margin > 1 {
+		margin = DefaultLimitMargin
+	}
+	return &limitMarginRule{margin: margin}
+}
+
+type limitMarginRule struct {
+	margin float64
+}
+
+func (r *limitMarginRule) Check(e Entry) []Issue {
+	return nil
+}
+
+func (r *limitMarginRule) CheckDocument(entries int, size int64) []Issue {
+	var issues []Issue
+	if float64(entries) >= r.margin*MaxEntries {
+		issues = append(issues, Issue{
+			Code:     IssueNearEntryLimit,
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("file has %d entries, it is %.0f%% of the limit of %d entries", entries, 100*float64(entries)/MaxEntries, MaxEntries),
+		})
+	}
+	if float64(size) >= r.margin*MaxFileSize {
+		issues = append(issues, Issue{
+			Code:     IssueNearSizeLimit,
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("file is %d bytes, it is %.0f%% of the limit of %d bytes", size, 100*float64(size)/MaxFileSize, MaxFileSize),
+		})
+	}
+	return issues
+}
@@ -0,0 +1,39 @@
+package sitemap
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLint_LimitMargin(t *testing.T) {
+	sitemap := "<urlset>" + strings.Repeat("<url><loc>https://example.com/</loc></url>", 40000) + "</urlset>"
+
+	lint := func(margin float64) []IssueCode {
+		var codes []IssueCode
+		err := Lint(strings.NewReader(sitemap), func(issue Issue) error {
+			codes = append(codes, issue.Code)
+			return nil
+		}, WithLintRules(NewLimitMarginRule(margin)))
+		if err != nil {
+			t.Fatalf("Lint failed with error %s", err)
+		}
+		return codes
+	}
+
+	if codes := lint(0.9); len(codes) != 0 {
+		t.Errorf("Expected no issues at 80%% of the limit, but given %v", codes)
+	}
+	if codes := lint(0.8); len(codes) != 1 || codes[0] != IssueNearEntryLimit {
+		t.Errorf("Expected %s issue, but given %v", IssueNearEntryLimit, codes)
+	}
+}
+
+func TestLimitMarginRule_Size(t *testing.T) {
+	rule := NewLimitMarginRule(0).(DocumentRule)
+	if issues := rule.CheckDocument(10, MaxFileSize*8/10); len(issues) != 0 {
+		t.Errorf("Expected no issues at 80%% of the limit, but given %+v", issues)
+	}
+	if issues := rule.CheckDocument(10, MaxFileSize*95/100); len(issues) != 1 || issues[0].Code != IssueNearSizeLimit {
+		t.Errorf("Expected %s issue, but given %+v", IssueNearSizeLimit, issues)
+	}
+}
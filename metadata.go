@@ -0,0 +1,34 @@
+package sitemap
+
+// AttachMetadata returns a copy of the entry with the custom value of the key
+// attached, so filters, transforms and enrichment steps of a pipeline can pass
+// data to later steps without side tables keyed by URL. Metadata is carried by
+// copies of the entry and written by JSONWriter, e.g. as the sink of Pipe.
+// Values must be marshalable to JSON.
+//
+//	sitemap.WithTransform(func(e sitemap.Entry) (sitemap.Entry, error) {
+//		return sitemap.AttachMetadata(e, "lang", detectLanguage(e.GetLocation())), nil
+//	})
+func AttachMetadata(e Entry, key string, value interface{}) Entry {
+	c := copyEntry(e)
+	c.metadata[key] = value
+	return c
+}
+
+// MetadataOf returns metadata attached to the entry by AttachMetadata or nil
+// if there is none. Don't change the returned map.
+func MetadataOf(e Entry) map[string]interface{} {
+	if mp, ok := e.(MetadataProvider); ok {
+		return mp.GetMetadata()
+	}
+	return nil
+}
+
+// cloneMetadata returns a copy of the metadata which is never nil.
+func cloneMetadata(metadata map[string]interface{}) map[string]interface{} {
+	c := make(map[string]interface{}, len(metadata)+1)
+	for key, value := range metadata {
+		c[key] = value
+	}
+	return c
+}
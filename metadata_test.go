@@ -0,0 +1,63 @@
+package sitemap
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestAttachMetadata(t *testing.T) {
+	e := newSitemapEntry()
+	e.Location = "https://example.com/"
+
+	first := AttachMetadata(e, "lang", "de")
+	second := AttachMetadata(first, "score", 3)
+	if MetadataOf(e) != nil {
+		t.Errorf("Expected no metadata of the original entry, but given %v", MetadataOf(e))
+	}
+	if m := MetadataOf(first); len(m) != 1 || m["lang"] != "de" {
+		t.Errorf("Unexpected metadata %v of the first copy", m)
+	}
+	if m := MetadataOf(second); len(m) != 2 || m["lang"] != "de" || m["score"] != 3 {
+		t.Errorf("Unexpected metadata %v of the second copy", m)
+	}
+	if second.GetLocation() != e.Location {
+		t.Errorf("Expected location %s, but given %s", e.Location, second.GetLocation())
+	}
+}
+
+func TestPipe_Metadata(t *testing.T) {
+	data := `<urlset>
+<url><loc>https://example.com/de/a</loc><lastmod>2020-01-01</lastmod></url>
+<url><loc>https://example.com/b</loc></url>
+</urlset>`
+
+	var buf bytes.Buffer
+	w := NewJSONWriter(&buf)
+	err := Pipe(strings.NewReader(data), w,
+		WithTransform(func(e Entry) (Entry, error) {
+			if strings.Contains(e.GetLocation(), "/de/") {
+				return AttachMetadata(e, "lang", "de"), nil
+			}
+			return e, nil
+		}),
+		WithTransform(func(e Entry) (Entry, error) {
+			if MetadataOf(e)["lang"] == "de" {
+				return AttachMetadata(e, "market", "dach"), nil
+			}
+			return e, nil
+		}))
+	if err != nil {
+		t.Fatalf("Piping failed with error %s", err)
+	}
+	if err = w.Close(); err != nil {
+		t.Fatalf("Writing failed with error %s", err)
+	}
+
+	expected := `{"loc":"https://example.com/de/a","lastmod":"2020-01-01T00:00:00Z","changefreq":"always","priority":0.5,"metadata":{"lang":"de","market":"dach"}}
+{"loc":"https://example.com/b","changefreq":"always","priority":0.5}
+`
+	if buf.String() != expected {
+		t.Errorf("Expected output %q, but given %q", expected, buf.String())
+	}
+}
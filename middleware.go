@@ -0,0 +1,137 @@
+package sitemap
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Middleware is a type represents a wrapper of a consumer, which adds
+// a cross-cutting behaviour to it, like deduplication or metrics.
+type Middleware func(EntryConsumer) EntryConsumer
+
+// Chain wraps the consumer by middlewares. The first middleware is the
+// outermost one, so it gets entries first:
+//
+//	consumer := sitemap.Chain(save, sitemap.Recover(), sitemap.Dedup())
+//	err := sitemap.ParseFromSite(url, consumer)
+func Chain(consumer EntryConsumer, middlewares ...Middleware) EntryConsumer {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		consumer = middlewares[i](consumer)
+	}
+	return consumer
+}
+
+// Dedup returns a middleware which skips entries with already consumed URLs.
+// It remembers all consumed URLs, so use a new instance for each parsing.
+func Dedup() Middleware {
+	return func(next EntryConsumer) EntryConsumer {
+		var mu sync.Mutex
+		seen := make(map[string]struct{})
+		return func(e Entry) error {
+			mu.Lock()
+			_, ok := seen[e.GetLocation()]
+			seen[e.GetLocation()] = struct{}{}
+			mu.Unlock()
+
+			if ok {
+				return nil
+			}
+			return next(e)
+		}
+	}
+}
+
+// Filter returns a middleware which skips entries the keep function returns false for.
+func Filter(keep func(Entry) bool) Middleware {
+	return func(next EntryConsumer) EntryConsumer {
+		return func(e Entry) error {
+			if !keep(e) {
+				return nil
+			}
+			return next(e)
+		}
+	}
+}
+
+// RateLimit returns a middleware which passes at most perSecond entries
+// per second to the consumer, it blocks parsing when entries come faster.
+// Zero or negative perSecond means no limit, the consumer is returned as it is.
+func RateLimit(perSecond float64) Middleware {
+	if perSecond <= 0 {
+		return func(next EntryConsumer) EntryConsumer {
+			return next
+		}
+	}
+	interval := time.Duration(float64(time.Second) / perSecond)
+	return func(next EntryConsumer) EntryConsumer {
+		var mu sync.Mutex
+		var last time.Time
+		return func(e Entry) error {
+			mu.Lock()
+			if wait := interval - time.Since(last); !last.IsZero() && wait > 0 {
+				time.Sleep(wait)
+			}
+			last = time.Now()
+			mu.Unlock()
+
+			return next(e)
+		}
+	}
+}
+
+// ConsumerMetrics are counters of a consumer, which are collected by Metrics
+// middleware. They are safe to read while parsing.
+type ConsumerMetrics struct {
+	entries  int64
+	errors   int64
+	duration int64
+}
+
+// Entries returns the count of consumed entries.
+func (m *ConsumerMetrics) Entries() int64 {
+	return atomic.LoadInt64(&m.entries)
+}
+
+// Errors returns the count of entries which the consumer returned errors for.
+func (m *ConsumerMetrics) Errors() int64 {
+	return atomic.LoadInt64(&m.errors)
+}
+
+// Duration returns the total time spent in the consumer.
+func (m *ConsumerMetrics) Duration() time.Duration {
+	return time.Duration(atomic.LoadInt64(&m.duration))
+}
+
+// Metrics returns a middleware which counts consumed entries, errors and time
+// spent in the consumer.
+func Metrics(metrics *ConsumerMetrics) Middleware {
+	return func(next EntryConsumer) EntryConsumer {
+		return func(e Entry) error {
+			start := time.Now()
+			err := next(e)
+			atomic.AddInt64(&metrics.duration, int64(time.Since(start)))
+			atomic.AddInt64(&metrics.entries, 1)
+			if err != nil {
+				atomic.AddInt64(&metrics.errors, 1)
+			}
+			return err
+		}
+	}
+}
+
+// Recover returns a middleware which turns panics of the consumer into errors,
+// so parsing stops with an error instead of crashing the program.
+func Recover() Middleware {
+	return func(next EntryConsumer) EntryConsumer {
+		return func(e Entry) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					err = fmt.Errorf("sitemap: consumer panicked on %s: %v", e.GetLocation(), r)
+				}
+			}()
+			return next(e)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package sitemap
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+const middlewareSitemap = `<urlset>
+<url><loc>http://example.com/a</loc></url>
+<url><loc>http://example.com/b</loc></url>
+<url><loc>http://example.com/a</loc></url>
+<url><loc>http://example.com/private/c</loc></url>
+</urlset>`
+
+func TestChain(t *testing.T) {
+	var locations []string
+	metrics := new(ConsumerMetrics)
+	consumer := Chain(func(e Entry) error {
+		locations = append(locations, e.GetLocation())
+		return nil
+	}, Dedup(), Filter(func(e Entry) bool {
+		return !strings.Contains(e.GetLocation(), "/private/")
+	}), Metrics(metrics))
+
+	if err := Parse(strings.NewReader(middlewareSitemap), consumer); err != nil {
+		t.Fatalf("Parsing failed with error %s", err)
+	}
+
+	if strings.Join(locations, " ") != "http://example.com/a http://example.com/b" {
+		t.Errorf("Unexpected consumed locations %v", locations)
+	}
+	if metrics.Entries() != 2 || metrics.Errors() != 0 {
+		t.Errorf("Expected 2 entries and no errors in metrics, but given %d and %d",
+			metrics.Entries(), metrics.Errors())
+	}
+}
+
+func TestRecover(t *testing.T) {
+	err := Parse(strings.NewReader(middlewareSitemap), Chain(func(e Entry) error {
+		panic("broken consumer")
+	}, Recover()))
+	if err == nil || !strings.Contains(err.Error(), "broken consumer") {
+		t.Errorf("Expected error of the panic, but given %v", err)
+	}
+}
+
+func TestMetrics_Errors(t *testing.T) {
+	expected := errors.New("stop")
+	metrics := new(ConsumerMetrics)
+	err := Parse(strings.NewReader(middlewareSitemap), Chain(func(e Entry) error {
+		return expected
+	}, Metrics(metrics)))
+	if err != expected || metrics.Entries() != 1 || metrics.Errors() != 1 {
+		t.Errorf("Unexpected error %v and metrics %d / %d", err, metrics.Entries(), metrics.Errors())
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	start := time.Now()
+	err := Parse(strings.NewReader(middlewareSitemap), Chain(func(e Entry) error {
+		return nil
+	}, RateLimit(100)))
+	if err != nil {
+		t.Fatalf("Parsing failed with error %s", err)
+	}
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Errorf("Expected at least 30ms for 4 entries, but given %s", elapsed)
+	}
+}
+
+func TestRateLimit_NoLimit(t *testing.T) {
+	for _, perSecond := range []float64{0, -1} {
+		counter := 0
+		start := time.Now()
+		err := Parse(strings.NewReader(middlewareSitemap), Chain(func(e Entry) error {
+			counter++
+			return nil
+		}, RateLimit(perSecond)))
+		if err != nil || counter != 4 {
+			t.Fatalf("Expected 4 entries, but given %d with error %v", counter, err)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("Expected no limit of %v entries per second, but given %s", perSecond, elapsed)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package sitemap
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Expectations describes requirements which a healthy sitemap must satisfy.
+// The zero value of a field disables the related check.
+//
+// MaxAge is the max age of the newest lastmod in the sitemap.
+// MinEntries is the min count of entries in the sitemap.
+// MaxChurn is the max share of URLs which were added or removed since the previous
+// evaluation, relatively to the previous count of URLs. For example, 0.1 means 10%.
+type Expectations struct {
+	MaxAge     time.Duration
+	MinEntries int
+	MaxChurn   float64
+}
+
+// MonitorResult is a result of evaluation of a sitemap.
+//
+// Newest is the newest lastmod of the sitemap entries or nil.
+// Added and Removed are counts of URLs in comparison with the previous evaluation,
+// they are zero for the first evaluation.
+// Failures contains human-readable descriptions of unmet expectations.
+type MonitorResult struct {
+	Passed   bool
+	Entries  int
+	Newest   *time.Time
+	Added    int
+	Removed  int
+	Churn    float64
+	Failures []string
+}
+
+// Monitor evaluates sitemaps against expectations, so it can be wired into
+// alerting. It remembers URLs of the previous evaluation to compute churn,
+// so use a dedicated Monitor for each sitemap.
+type Monitor struct {
+	expectations Expectations
+	opts         []Option
+	now          func() time.Time
+	previous     map[string]struct{}
+}
+
+// NewMonitor creates a new monitor. WithClock option changes the source of
+// current time used to check MaxAge.
+func NewMonitor(expectations Expectations, opts ...Option) *Monitor {
+	return &Monitor{
+		expectations: expectations,
+		opts:         opts,
+		now:          newOptions(opts).now,
+	}
+}
+
+// Evaluate parses the sitemap which provides by the reader with options of
+// the monitor and checks it.
+func (m *Monitor) Evaluate(reader io.Reader) (*MonitorResult, error) {
+	result := new(MonitorResult)
+	current := make(map[string]struct{})
+
+	err := Parse(reader, func(e Entry) error {
+		result.Entries++
+		current[e.GetLocation()] = struct{}{}
+
+		lastmod := e.GetLastModified()
+		if lastmod != nil && (result.Newest == nil || lastmod.After(*result.Newest)) {
+			result.Newest = lastmod
+		}
+		return nil
+	}, m.opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	m.check(result, current)
+	m.previous = current
+	return result, nil
+}
+
+// EvaluateFromSite downloads sitemap from a site and checks it.
+func (m *Monitor) EvaluateFromSite(sitemapURL string) (*MonitorResult, error) {
+	res, err := newOptions(m.opts).getSitemap(context.Background(), sitemapURL)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	result, err := m.Evaluate(res.Body)
+	return result, withSource(err, sitemapURL)
+}
+
+func (m *Monitor) check(result *MonitorResult, current map[string]struct{}) {
+	e := m.expectations
+
+	if e.MinEntries > 0 && result.Entries < e.MinEntries {
+		result.Failures = append(result.Failures,
+			fmt.Sprintf("sitemap has %d entries, expected at least %d", result.Entries, e.MinEntries))
+	}
+
+	if e.MaxAge > 0 {
+		if result.Newest == nil {
+			result.Failures = append(result.Failures, "sitemap has no lastmod")
+		} else if age := m.now().Sub(*result.Newest); age > e.MaxAge {
+			result.Failures = append(result.Failures,
+				fmt.Sprintf("newest lastmod is %s old, expected at most %s", age, e.MaxAge))
+		}
+	}
+
+	if m.previous != nil {
+		for location := range current {
+			if _, ok := m.previous[location]; !ok {
+				result.Added++
+			}
+		}
+		for location := range m.previous {
+			if _, ok := current[location]; !ok {
+				result.Removed++
+			}
+		}
+		if len(m.previous) > 0 {
+			result.Churn = float64(result.Added+result.Removed) / float64(len(m.previous))
+		}
+
+		if e.MaxChurn > 0 && result.Churn > e.MaxChurn {
+			result.Failures = append(result.Failures,
+				fmt.Sprintf("churn is %.2f, expected at most %.2f", result.Churn, e.MaxChurn))
+		}
+	}
+
+	result.Passed = len(result.Failures) == 0
+}
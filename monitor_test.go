@@ -0,0 +1,60 @@
+package sitemap
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMonitor(t *testing.T) {
+	now := time.Date(2015, 5, 8, 0, 0, 0, 0, time.UTC)
+	monitor := NewMonitor(Expectations{
+		MaxAge:     48 * time.Hour,
+		MinEntries: 4,
+		MaxChurn:   0.5,
+	}, WithClock(func() time.Time { return now }))
+
+	file, err := os.Open("./testdata/sitemap.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	result, err := monitor.Evaluate(file)
+	if err != nil {
+		t.Fatalf("Evaluation failed with error %s", err)
+	}
+	if !result.Passed || result.Entries != 4 || result.Churn != 0 {
+		t.Errorf("Unexpected result %+v", result)
+	}
+
+	now = now.AddDate(0, 0, 7)
+	result, err = monitor.Evaluate(strings.NewReader(`<urlset>
+		<url><loc>http://HOST/</loc></url>
+		<url><loc>http://HOST/new/</loc></url>
+	</urlset>`))
+	if err != nil {
+		t.Fatalf("Evaluation failed with error %s", err)
+	}
+	if result.Passed || result.Added != 1 || result.Removed != 3 || result.Churn != 1 {
+		t.Errorf("Unexpected result %+v", result)
+	}
+	if len(result.Failures) != 3 {
+		t.Errorf("Expected 3 failures, but given %v", result.Failures)
+	}
+}
+
+func TestMonitor_Options(t *testing.T) {
+	monitor := NewMonitor(Expectations{}, WithLimits(Limits{MaxEntries: 1}))
+
+	_, err := monitor.Evaluate(strings.NewReader(`<urlset>
+		<url><loc>http://HOST/</loc></url>
+		<url><loc>http://HOST/new/</loc></url>
+	</urlset>`))
+	var limitErr *LimitExceededError
+	if !errors.As(err, &limitErr) || limitErr.Limit != LimitEntries {
+		t.Errorf("Expected limit of entries, but given %v", err)
+	}
+}
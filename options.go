@@ -0,0 +1,198 @@
+package sitemap
+
+import (
+	"compress/gzip"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/frase-io/gopher-parse-sitemap/stage"
+)
+
+// Option is a type represents an optional setting of parsing and fetching
+// functions. Options which are not related to a function are ignored by it.
+type Option func(*options)
+
+type options struct {
+	client      *http.Client
+	sampleEvery int
+	sampleLimit int
+	gzip        bool
+	now         func() time.Time
+	format      Format
+	transforms  []Transform
+
+	lastmodGranularity LastmodGranularity
+	lastmodUTC         bool
+	compact            bool
+	gzipLevel          int
+	tagPolicy          TagPolicy
+	defaultChangefreq  Frequency
+	defaultPriority    *float32
+
+	workers         int
+	entryBuffer     int
+	hostConcurrency int
+	ordered         bool
+
+	lintRules  []LintRule
+	sitemapURL string
+	sections   []string
+	topN       int
+	classifier Classifier
+
+	dateLayouts   []string
+	urlFilters    []func(string) bool
+	modifiedSince time.Time
+	maxEntries    int
+	normalize     bool
+	transcoding   bool
+	stages        []stage.Stage
+	rawEntries    bool
+
+	inheritLastModified bool
+
+	validators     *Validators
+	validatorStore StateStore
+	contentHash    bool
+	deletionStore  StateStore
+	deletions      DeletionConsumer
+	deletionGrace  int
+	resultStore    StateStore
+
+	retry          RetryPolicy
+	proxies        []string
+	proxyProvider  ProxyProvider
+	insecure       bool
+	parsedProxies  []*url.URL
+	proxyErr       error
+	resolve        []string
+	resolveErr     error
+	nextProxy      uint32
+	builtClient    *http.Client
+	requestRate    float64
+	redirects      *RedirectPolicy
+	softErrorRetry bool
+	challenges     ChallengeDetector
+	coverage       *Coverage
+	delivery       *deliveryTracker
+	archive        string
+	timeout        time.Duration
+	userAgent      string
+	rateLimiter    *rateLimiter
+
+	progressInterval time.Duration
+	progressFunc     func(ProgressInfo)
+	progress         *progress
+	events           *eventStream
+	counters         CounterFunc
+
+	limits   Limits
+	deadline time.Time
+	budget   Budget
+	quota    Quota
+	meter    *meter
+	schedule Schedule
+	fair     *fairTicket
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{sampleEvery: 1, now: time.Now, workers: defaultWorkers, entryBuffer: -1, gzipLevel: gzip.DefaultCompression, meter: new(meter)}
+	for _, opt := range opts {
+		opt(o)
+	}
+	o.prepareClient()
+	o.customizeClient()
+	o.rateLimiter = newRateLimiter(o.requestRate)
+	o.progress = newProgress(o.progressInterval, o.progressFunc)
+	if o.limits.Deadline > 0 {
+		o.deadline = time.Now().Add(o.limits.Deadline)
+	}
+	return o
+}
+
+// WithHTTPClient sets the client which is used to download sitemaps and pages.
+// By default http.DefaultClient is used.
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *options) {
+		o.client = client
+	}
+}
+
+// WithSample restricts checks which fetch pages to every n-th entry
+// of the sitemap and at most limit entries. Zero limit means no limit.
+func WithSample(every, limit int) Option {
+	return func(o *options) {
+		if every > 0 {
+			o.sampleEvery = every
+		}
+		o.sampleLimit = limit
+	}
+}
+
+// WithGzip enables gzip compression of files produced by writers.
+func WithGzip() Option {
+	return func(o *options) {
+		o.gzip = true
+	}
+}
+
+// WithClock sets the source of current time. It is useful for tests and
+// for evaluation of sitemaps at a fixed moment.
+func WithClock(now func() time.Time) Option {
+	return func(o *options) {
+		o.now = now
+	}
+}
+
+// WithDateLayouts sets time layouts which are tried to parse lastmod dates
+// before the default ones, e.g. "02.01.2006".
+func WithDateLayouts(layouts ...string) Option {
+	return func(o *options) {
+		o.dateLayouts = append(o.dateLayouts, layouts...)
+	}
+}
+
+// WithTimeout sets the time limit of each request including reading of the body.
+// By default the limit of the client is used.
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.timeout = timeout
+	}
+}
+
+// WithUserAgent sets User-Agent header of requests.
+func WithUserAgent(userAgent string) Option {
+	return func(o *options) {
+		o.userAgent = userAgent
+	}
+}
+
+// customizeClient sets the timeout and the redirect policy of the client if they are set.
+func (o *options) customizeClient() {
+	if o.timeout == 0 && o.redirects == nil {
+		return
+	}
+
+	c := *o.builtClient
+	if o.timeout > 0 {
+		c.Timeout = o.timeout
+	}
+	if o.redirects != nil {
+		c.CheckRedirect = o.checkRedirect
+	}
+	o.builtClient = &c
+}
+
+func (o *options) httpClient() *http.Client {
+	return o.builtClient
+}
+
+// sampled reports whether the entry with the zero-based index and the
+// given number of already sampled entries should be sampled.
+func (o *options) sampled(index, taken int) bool {
+	if o.sampleLimit > 0 && taken >= o.sampleLimit {
+		return false
+	}
+	return index%o.sampleEvery == 0
+}
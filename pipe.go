@@ -0,0 +1,120 @@
+package sitemap
+
+import (
+	"io"
+	"time"
+)
+
+// Transform is a type represents a step of a read-transform-write pipeline.
+// It returns the entry to write, which can be the given one, or nil to drop the entry.
+type Transform func(Entry) (Entry, error)
+
+// EntryWriter is an interface of a sink of a pipeline. It is implemented by
+// Writer, SplitWriter and JSONWriter.
+type EntryWriter interface {
+	WriteEntry(loc string, lastmod *time.Time, changefreq Frequency, priority *float32) error
+}
+
+// entryAdder is implemented by sinks which write whole entries, e.g. with
+// metadata, like JSONWriter.
+type entryAdder interface {
+	Add(Entry) error
+}
+
+// WithTransform appends the transform to the pipeline. Transforms are applied
+// in order they were given.
+func WithTransform(transform Transform) Option {
+	return func(o *options) {
+		o.transforms = append(o.transforms, transform)
+	}
+}
+
+// Pipe parses the sitemap which provides by the reader, passes each entry through
+// transforms given by WithTransform and writes results by the writer.
+// Extensions of entries aren't written, since EntryWriter has no tags of them.
+// It doesn't close the writer.
+func Pipe(reader io.Reader, writer EntryWriter, opts ...Option) error {
+	o := newOptions(opts)
+
+	return parseDocument(reader, o, func(e Entry) error {
+		e, err := o.transform(e)
+		if err != nil || e == nil {
+			return err
+		}
+		return writeEntry(writer, e)
+	}, nil)
+}
+
+// Tee returns the consumer which passes each entry to all the consumers in
+// order, so a sitemap is parsed once for several outputs. It stops at the
+// first error of a consumer.
+func Tee(consumers ...EntryConsumer) EntryConsumer {
+	return func(e Entry) error {
+		for _, consume := range consumers {
+			if err := consume(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// WriterConsumer returns the consumer which writes entries by the writer,
+// e.g. to pass it to Tee along with other consumers.
+func WriterConsumer(writer EntryWriter) EntryConsumer {
+	return func(e Entry) error {
+		return writeEntry(writer, e)
+	}
+}
+
+// writeEntry writes the entry by the writer. Only the change frequency and
+// the priority which the source has are written, see PresenceProvider.
+func writeEntry(writer EntryWriter, e Entry) error {
+	if adder, ok := writer.(entryAdder); ok {
+		return adder.Add(e)
+	}
+	changefreq, priority := e.GetChangeFrequency(), Priority(e.GetPriority())
+	if presence, ok := e.(PresenceProvider); ok {
+		if !presence.HasChangeFrequency() {
+			changefreq = ""
+		}
+		if !presence.HasPriority() {
+			priority = nil
+		}
+	}
+	return writer.WriteEntry(e.GetLocation(), e.GetLastModified(), changefreq, priority)
+}
+
+func (o *options) transform(e Entry) (Entry, error) {
+	var err error
+	for _, transform := range o.transforms {
+		if e, err = transform(e); err != nil || e == nil {
+			return nil, err
+		}
+	}
+	return e, nil
+}
+
+// copyEntry returns a copy of the entry which can be changed by transforms.
+func copyEntry(e Entry) *sitemapEntry {
+	if se, ok := e.(*sitemapEntry); ok {
+		c := *se
+		c.metadata = cloneMetadata(se.metadata)
+		return &c
+	}
+
+	c := &sitemapEntry{
+		Location:           e.GetLocation(),
+		LastModified:       lastModifiedRaw(e),
+		ParsedLastModified: e.GetLastModified(),
+		ChangeFrequency:    e.GetChangeFrequency(),
+		Priority:           e.GetPriority(),
+		metadata:           cloneMetadata(MetadataOf(e)),
+		hasChangeFrequency: true,
+		hasPriority:        true,
+	}
+	if presence, ok := e.(PresenceProvider); ok {
+		c.hasChangeFrequency, c.hasPriority = presence.HasChangeFrequency(), presence.HasPriority()
+	}
+	return c
+}
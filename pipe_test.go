@@ -0,0 +1,183 @@
+package sitemap
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPipe_ReweightPriority(t *testing.T) {
+	file, err := os.Open("./testdata/sitemap.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	now := time.Date(2015, 5, 8, 0, 0, 0, 0, time.UTC)
+	rules := PriorityRules{
+		Base:          0.8,
+		DepthPenalty:  0.2,
+		Sections:      map[string]float32{"/tools/": 0.5},
+		RecencyBoost:  0.2,
+		RecencyWindow: 14 * time.Hour,
+	}
+	dropRoot := func(e Entry) (Entry, error) {
+		if strings.HasSuffix(e.GetLocation(), "HOST/") {
+			return nil, nil
+		}
+		return e, nil
+	}
+
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	err = Pipe(file, w,
+		WithTransform(dropRoot),
+		WithTransform(ReweightPriority(rules, WithClock(func() time.Time { return now }))))
+	if err != nil {
+		t.Fatalf("Pipe failed with error %s", err)
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var result []string
+	err = Parse(&buf, func(e Entry) error {
+		result = append(result, fmt.Sprint(e.GetLocation(), " ", e.GetPriority()))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "http://HOST/tools/ 0.5,http://HOST/contribution-to-oss/ 0.6,http://HOST/page-1/ 0.8"
+	if strings.Join(result, ",") != expected {
+		t.Errorf("Unexpected result %v", result)
+	}
+}
+
+func TestPipe_PassThrough(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	err := Pipe(strings.NewReader("<urlset><url><loc>http://a/x</loc></url>"+
+		"<url><loc>http://a/y</loc><changefreq>daily</changefreq><priority>0.5</priority></url>"+
+		"<url><loc>http://a/z</loc><priority>0.0</priority></url></urlset>"), w)
+	if err != nil {
+		t.Fatalf("Pipe failed with error %s", err)
+	}
+	w.Close()
+
+	result := strings.Join(strings.Fields(buf.String()), " ")
+	if !strings.Contains(result, "<url> <loc>http://a/x</loc> </url>") ||
+		!strings.Contains(result, "<changefreq>daily</changefreq> <priority>0.5</priority>") ||
+		!strings.Contains(result, "<loc>http://a/z</loc> <priority>0</priority>") {
+		t.Errorf("Unexpected result %s", result)
+	}
+}
+
+func TestPipe_Transcoding(t *testing.T) {
+	utf16 := func(data string) string {
+		var b strings.Builder
+		b.WriteString("\xff\xfe")
+		for _, c := range data {
+			b.WriteRune(c)
+			b.WriteByte(0)
+		}
+		return b.String()
+	}
+
+	cases := []struct {
+		name     string
+		data     string
+		expected string
+	}{
+		{"utf-16", utf16(`<?xml version="1.0" encoding="UTF-16"?><urlset><url><loc>http://example.com/a</loc></url></urlset>`),
+			"http://example.com/a"},
+		{"latin-1", `<?xml version="1.0" encoding="ISO-8859-1"?><urlset><url><loc>http://example.com/caf` + "\xe9" + `</loc></url></urlset>`,
+			"http://example.com/café"},
+		{"invalid", "\xef\xbb\xbf<urlset><url><loc>http://example.com/\xff\xef\xbb\xbf</loc></url></urlset>",
+			"http://example.com/�"},
+	}
+	for _, c := range cases {
+		var buf bytes.Buffer
+		w := NewWriter(&buf)
+		if err := Pipe(strings.NewReader(c.data), w, WithTranscoding()); err != nil {
+			t.Errorf("Pipe of %s failed with error %s", c.name, err)
+			continue
+		}
+		w.Close()
+
+		var locations []string
+		if err := Parse(&buf, func(e Entry) error {
+			locations = append(locations, e.GetLocation())
+			return nil
+		}); err != nil {
+			t.Errorf("Parsing of %s output failed with error %s", c.name, err)
+		}
+		if len(locations) != 1 || locations[0] != c.expected {
+			t.Errorf("Expected %q of %s, but given %q", c.expected, c.name, locations)
+		}
+	}
+}
+
+func TestParse_Stages(t *testing.T) {
+	unescape := func(reader io.Reader) (io.Reader, error) {
+		data, err := ioutil.ReadAll(reader)
+		return strings.NewReader(strings.Replace(string(data), "&nbsp;", " ", -1)), err
+	}
+
+	var locations []string
+	err := Parse(strings.NewReader("<urlset><url><loc>http://example.com/&nbsp;a</loc></url></urlset>"), func(e Entry) error {
+		locations = append(locations, e.GetLocation())
+		return nil
+	}, WithStages(unescape))
+	if err != nil {
+		t.Fatalf("Parsing failed with error %s", err)
+	}
+	if len(locations) != 1 || locations[0] != "http://example.com/ a" {
+		t.Errorf("Expected location changed by the stage, but given %q", locations)
+	}
+}
+
+func TestTee(t *testing.T) {
+	file, err := os.Open("./testdata/sitemap.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	var locations []string
+	var buf bytes.Buffer
+	w := NewJSONWriter(&buf)
+	err = Parse(file, Tee(func(e Entry) error {
+		locations = append(locations, e.GetLocation())
+		return nil
+	}, WriterConsumer(w)))
+	if err != nil {
+		t.Fatalf("Parsing failed with error %s", err)
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(locations) == 0 || len(lines) != len(locations) || !strings.Contains(lines[0], locations[0]) {
+		t.Errorf("Unexpected locations %v and lines %v", locations, lines)
+	}
+
+	stop := fmt.Errorf("stop")
+	counter := 0
+	err = Parse(strings.NewReader("<urlset><url><loc>http://HOST/</loc></url></urlset>"), Tee(func(e Entry) error {
+		return stop
+	}, func(e Entry) error {
+		counter++
+		return nil
+	}))
+	if err != stop || counter != 0 {
+		t.Errorf("Expected the first error and no calls of the next consumer, but given %v and %d", err, counter)
+	}
+}
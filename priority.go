@@ -0,0 +1,78 @@
+package sitemap
+
+import (
+	"math"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// PriorityRules describes how priority of an entry is computed from its URL and
+// its last modification time.
+//
+// Base is priority of the root page of a site.
+// DepthPenalty is subtracted from Base for each segment of the URL path.
+// Sections maps path prefixes to multipliers, the longest matching prefix is used.
+// RecencyBoost is added to priority of entries modified within RecencyWindow.
+// Min and Max bound the result, zero Max means 1.0.
+//
+// The result is rounded to one decimal place.
+type PriorityRules struct {
+	Base          float32
+	DepthPenalty  float32
+	Sections      map[string]float32
+	RecencyBoost  float32
+	RecencyWindow time.Duration
+	Min           float32
+	Max           float32
+}
+
+// ReweightPriority returns a transform which replaces priority of each entry
+// by the value computed by the rules. WithClock option changes the source of
+// current time used to check recency.
+func ReweightPriority(rules PriorityRules, opts ...Option) Transform {
+	now := newOptions(opts).now
+
+	return func(e Entry) (Entry, error) {
+		c := copyEntry(e)
+		c.Priority, c.hasPriority = rules.priority(e, now()), true
+		return c, nil
+	}
+}
+
+func (r *PriorityRules) priority(e Entry, now time.Time) float32 {
+	path := "/"
+	if u, err := url.Parse(e.GetLocation()); err == nil && u.Path != "" {
+		path = u.Path
+	}
+
+	depth := len(strings.FieldsFunc(path, func(c rune) bool { return c == '/' }))
+	priority := r.Base - float32(depth)*r.DepthPenalty
+
+	prefix, weight := "", float32(1)
+	for section, w := range r.Sections {
+		if strings.HasPrefix(path, section) && len(section) > len(prefix) {
+			prefix, weight = section, w
+		}
+	}
+	priority *= weight
+
+	if r.RecencyWindow > 0 {
+		if lastmod := e.GetLastModified(); lastmod != nil && now.Sub(*lastmod) <= r.RecencyWindow {
+			priority += r.RecencyBoost
+		}
+	}
+
+	max := r.Max
+	if max == 0 {
+		max = 1
+	}
+	if priority < r.Min {
+		priority = r.Min
+	}
+	if priority > max {
+		priority = max
+	}
+
+	return float32(math.Round(float64(priority)*10) / 10)
+}
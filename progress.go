@@ -0,0 +1,142 @@
+package sitemap
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ProgressInfo is a snapshot of progress of parsing.
+//
+// BytesRead is the count of read bytes of documents after decompression.
+// SitemapsDone and SitemapsTotal are counts of processed and discovered sitemaps
+// of functions which walk indexes, like ParseIndexConcurrent and Crawler.Crawl,
+// they are zero for other functions. SitemapsTotal grows while indexes are parsed.
+type ProgressInfo struct {
+	BytesRead     int64
+	Entries       int64
+	Elapsed       time.Duration
+	SitemapsDone  int
+	SitemapsTotal int
+}
+
+// WithProgress sets the function which is called with progress of parsing at
+// most once per interval and once after each document. The function isn't
+// called concurrently, so it can update metrics without locks, but it blocks
+// parsing, so it should be fast.
+func WithProgress(interval time.Duration, report func(ProgressInfo)) Option {
+	return func(o *options) {
+		o.progressInterval = interval
+		o.progressFunc = report
+	}
+}
+
+// progress collects counters of parsing and reports them. A nil progress
+// doesn't collect anything.
+type progress struct {
+	// 64-bit counters are first to be aligned for atomic operations.
+	bytes      int64
+	entries    int64
+	lastReport int64
+
+	interval time.Duration
+	report   func(ProgressInfo)
+	started  time.Time
+
+	mu       sync.Mutex
+	sitemaps map[string]bool
+	done     int
+}
+
+func newProgress(interval time.Duration, report func(ProgressInfo)) *progress {
+	if report == nil {
+		return nil
+	}
+	return &progress{interval: interval, report: report, started: time.Now(), sitemaps: make(map[string]bool)}
+}
+
+// reader returns a reader which counts bytes of the reader.
+func (p *progress) reader(reader io.Reader) io.Reader {
+	if p == nil {
+		return reader
+	}
+	return &progressReader{reader: reader, p: p}
+}
+
+func (p *progress) entry() {
+	if p == nil {
+		return
+	}
+	atomic.AddInt64(&p.entries, 1)
+	p.tick()
+}
+
+// discover adds sitemaps which are going to be processed.
+func (p *progress) discover(locations ...string) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	for _, location := range locations {
+		if _, ok := p.sitemaps[location]; !ok {
+			p.sitemaps[location] = false
+		}
+	}
+	p.mu.Unlock()
+}
+
+// finish marks the sitemap processed and reports progress.
+func (p *progress) finish(location string) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	if !p.sitemaps[location] {
+		p.sitemaps[location] = true
+		p.done++
+	}
+	p.mu.Unlock()
+	p.flush()
+}
+
+// tick reports progress if the interval is passed since the last report.
+func (p *progress) tick() {
+	now := int64(time.Since(p.started))
+	last := atomic.LoadInt64(&p.lastReport)
+	if now-last < int64(p.interval) || !atomic.CompareAndSwapInt64(&p.lastReport, last, now) {
+		return
+	}
+	p.flush()
+}
+
+// flush reports progress unconditionally.
+func (p *progress) flush() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	elapsed := time.Since(p.started)
+	atomic.StoreInt64(&p.lastReport, int64(elapsed))
+	p.report(ProgressInfo{
+		BytesRead:     atomic.LoadInt64(&p.bytes),
+		Entries:       atomic.LoadInt64(&p.entries),
+		Elapsed:       elapsed,
+		SitemapsDone:  p.done,
+		SitemapsTotal: len(p.sitemaps),
+	})
+}
+
+type progressReader struct {
+	reader io.Reader
+	p      *progress
+}
+
+func (r *progressReader) Read(b []byte) (int, error) {
+	n, err := r.reader.Read(b)
+	atomic.AddInt64(&r.p.bytes, int64(n))
+	r.p.tick()
+	return n, err
+}
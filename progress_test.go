@@ -0,0 +1,57 @@
+package sitemap
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestParseFromFile_Progress(t *testing.T) {
+	var reports []ProgressInfo
+	err := ParseFromFile("./testdata/sitemap.xml", func(e Entry) error {
+		return nil
+	}, WithProgress(time.Hour, func(info ProgressInfo) {
+		reports = append(reports, info)
+	}))
+	if err != nil {
+		t.Fatalf("Parsing failed with error %s", err)
+	}
+
+	info, err := os.Stat("./testdata/sitemap.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reports) != 1 {
+		t.Fatalf("Expected 1 report, but given %d", len(reports))
+	}
+	if reports[0].Entries != 4 || reports[0].BytesRead != info.Size() {
+		t.Errorf("Unexpected report %+v", reports[0])
+	}
+}
+
+func TestParseIndexConcurrent_Progress(t *testing.T) {
+	server := newIndexServer(5, 10)
+	defer server.Close()
+
+	var last ProgressInfo
+	reports := 0
+	err := ParseIndexConcurrent(server.URL+"/index.xml", func(e Entry) error {
+		return nil
+	}, WithProgress(0, func(info ProgressInfo) {
+		if info.Entries < last.Entries || info.SitemapsDone < last.SitemapsDone {
+			t.Errorf("Progress went back from %+v to %+v", last, info)
+		}
+		last = info
+		reports++
+	}))
+	if err != nil {
+		t.Fatalf("Parsing failed with error %s", err)
+	}
+
+	if last.Entries != 50 || last.SitemapsDone != 6 || last.SitemapsTotal != 6 {
+		t.Errorf("Unexpected last report %+v", last)
+	}
+	if reports < 6 {
+		t.Errorf("Expected a report per sitemap at least, but given %d", reports)
+	}
+}
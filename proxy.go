@@ -0,0 +1,153 @@
+package sitemap
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// proxyListTimeout is the timeout of downloading a proxy list by ProxyListURL.
+	proxyListTimeout = 30 * time.Second
+	// defaultProxyRefresh is the interval of re-reading proxy lists of configurations.
+	defaultProxyRefresh = 5 * time.Minute
+)
+
+// ProxyProvider is an interface of a source of proxy URLs. Proxies is called
+// before each download, so a provider which reads a slow source should cache
+// the list, see ReloadProxies.
+type ProxyProvider interface {
+	Proxies() ([]string, error)
+}
+
+// ProxyProviderFunc is a type represents a function which is a ProxyProvider.
+type ProxyProviderFunc func() ([]string, error)
+
+// Proxies calls the function.
+func (f ProxyProviderFunc) Proxies() ([]string, error) {
+	return f()
+}
+
+// WithProxyProvider sets the provider of proxies which are used for downloads
+// like ones of WithProxies. Proxies of the provider are used after ones of
+// WithProxies.
+func WithProxyProvider(provider ProxyProvider) Option {
+	return func(o *options) {
+		o.proxyProvider = provider
+	}
+}
+
+// ProxyListFile returns a provider which reads proxies from the file, one URL
+// per line. Empty lines and lines starting with # are ignored. The file is read
+// on each call, wrap the provider by ReloadProxies to read it periodically.
+func ProxyListFile(path string) ProxyProvider {
+	return ProxyProviderFunc(func() ([]string, error) {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		return readProxyList(file)
+	})
+}
+
+// ProxyListURL returns a provider which downloads proxies from the URL in the
+// format of ProxyListFile, like lists of commercial proxy services. The list is
+// downloaded on each call, wrap the provider by ReloadProxies to download it
+// periodically.
+func ProxyListURL(location string) ProxyProvider {
+	client := &http.Client{Timeout: proxyListTimeout}
+	return ProxyProviderFunc(func() ([]string, error) {
+		res, err := client.Get(location)
+		if err != nil {
+			return nil, err
+		}
+		defer res.Body.Close()
+
+		if !isSuccess(res) {
+			return nil, fmt.Errorf("sitemap: unexpected status %q of proxy list %s", res.Status, location)
+		}
+		return readProxyList(res.Body)
+	})
+}
+
+// ReloadProxies returns a provider which caches proxies of the provider and
+// re-reads them when the interval is passed, so a long-lived service picks up
+// rotated proxies without a restart. If re-reading fails, the last list is kept
+// until the next interval. The returned provider is safe for concurrent use.
+func ReloadProxies(provider ProxyProvider, interval time.Duration) ProxyProvider {
+	return &reloadingProxies{provider: provider, interval: interval, now: time.Now}
+}
+
+type reloadingProxies struct {
+	provider ProxyProvider
+	interval time.Duration
+	now      func() time.Time
+
+	mu       sync.Mutex
+	proxies  []string
+	loaded   bool
+	loadedAt time.Time
+}
+
+func (r *reloadingProxies) Proxies() ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	if r.loaded && now.Sub(r.loadedAt) < r.interval {
+		return r.proxies, nil
+	}
+
+	proxies, err := r.provider.Proxies()
+	if err != nil {
+		if r.loaded {
+			r.loadedAt = now
+			return r.proxies, nil
+		}
+		return nil, err
+	}
+	r.proxies, r.loaded, r.loadedAt = proxies, true, now
+	return proxies, nil
+}
+
+func readProxyList(reader io.Reader) ([]string, error) {
+	var proxies []string
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		proxies = append(proxies, line)
+	}
+	return proxies, scanner.Err()
+}
+
+// proxyURLs returns proxies of WithProxies and of the provider.
+func (o *options) proxyURLs() ([]*url.URL, error) {
+	if o.proxyErr != nil || o.proxyProvider == nil {
+		return o.parsedProxies, o.proxyErr
+	}
+
+	provided, err := o.proxyProvider.Proxies()
+	if err != nil {
+		return nil, fmt.Errorf("sitemap: loading proxies failed: %w", err)
+	}
+	proxies := make([]*url.URL, len(o.parsedProxies), len(o.parsedProxies)+len(provided))
+	copy(proxies, o.parsedProxies)
+	for _, proxy := range provided {
+		u, err := url.Parse(proxy)
+		if err != nil {
+			return nil, err
+		}
+		proxies = append(proxies, u)
+	}
+	return proxies, nil
+}
@@ -0,0 +1,78 @@
+package sitemap
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseFromSite_ProxyProvider(t *testing.T) {
+	requested := 0
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested++
+		fmt.Fprint(w, "<urlset><url><loc>http://HOST/</loc></url></urlset>")
+	}))
+	defer proxy.Close()
+
+	file, err := ioutil.TempFile("", "proxies")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	fmt.Fprintf(file, "# proxies\n\n%s\n", proxy.URL)
+	file.Close()
+
+	counter := 0
+	err = ParseFromSite("http://sitemap.test/sitemap.xml", func(e Entry) error {
+		counter++
+		return nil
+	}, WithProxyProvider(ProxyListFile(file.Name())))
+	if err != nil {
+		t.Fatalf("Parsing failed with error %s", err)
+	}
+	if requested != 1 || counter != 1 {
+		t.Errorf("Expected 1 proxied request and 1 entry, but given %d and %d", requested, counter)
+	}
+}
+
+func TestReloadProxies(t *testing.T) {
+	calls := 0
+	lists := [][]string{{"http://proxy-1:3128"}, nil, {"http://proxy-2:3128"}}
+	provider := ReloadProxies(ProxyProviderFunc(func() ([]string, error) {
+		calls++
+		if lists[calls-1] == nil {
+			return nil, errors.New("unavailable")
+		}
+		return lists[calls-1], nil
+	}), time.Minute).(*reloadingProxies)
+
+	now := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	provider.now = func() time.Time { return now }
+
+	expected := [][]string{
+		{"http://proxy-1:3128"}, // loaded
+		{"http://proxy-1:3128"}, // cached
+		{"http://proxy-1:3128"}, // reloading failed
+		{"http://proxy-2:3128"}, // reloaded
+	}
+	steps := []time.Duration{0, 30 * time.Second, time.Minute, time.Minute}
+	for i, step := range steps {
+		now = now.Add(step)
+		proxies, err := provider.Proxies()
+		if err != nil {
+			t.Fatalf("Step %d failed with error %s", i, err)
+		}
+		if !reflect.DeepEqual(proxies, expected[i]) {
+			t.Errorf("Step %d: expected %v, but given %v", i, expected[i], proxies)
+		}
+	}
+	if calls != 3 {
+		t.Errorf("Expected 3 calls of the provider, but given %d", calls)
+	}
+}
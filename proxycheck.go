@@ -0,0 +1,132 @@
+package sitemap
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+)
+
+// proxyCheckBodySize is the max size of a response body read by proxy checks.
+const proxyCheckBodySize = 64 << 10
+
+// identifyingHeaders matches echoed headers which proxies add to requests
+// with the address of the client.
+var identifyingHeaders = regexp.MustCompile(`(?i)\b(x-forwarded-for|forwarded|via|x-real-ip)"?\s*:`)
+
+// ProxyStatus is a result of a check of a proxy.
+//
+// Latency is the time of downloading the target through the proxy.
+// Anonymous reports whether the response doesn't mention headers which reveal
+// the client, like X-Forwarded-For or Via. It is useful only if the target echoes
+// request headers, e.g. an endpoint like https://httpbin.org/headers.
+// Err is the error of the download, it is HTTPError for non-2xx statuses.
+type ProxyStatus struct {
+	Proxy     string
+	Latency   time.Duration
+	Anonymous bool
+	Err       error
+}
+
+// OK reports whether the target is successfully downloaded through the proxy.
+func (s ProxyStatus) OK() bool {
+	return s.Err == nil
+}
+
+// CheckProxies downloads the target through each of proxies by a pool of workers
+// (see WithWorkers) and returns their statuses ranked from the best one: working
+// proxies go first, anonymous ones before others, then faster ones before slower.
+// Other options like WithTimeout and WithUserAgent are applied to downloads,
+// proxies of the options are ignored.
+func CheckProxies(ctx context.Context, target string, proxies []string, opts ...Option) []ProxyStatus {
+	statuses := make([]ProxyStatus, len(proxies))
+	indexes := make(chan int)
+	workers := newOptions(opts).workers
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				statuses[i] = checkProxy(ctx, target, proxies[i], opts)
+			}
+		}()
+	}
+	for i := range proxies {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	sort.SliceStable(statuses, func(i, j int) bool {
+		a, b := statuses[i], statuses[j]
+		if a.OK() != b.OK() {
+			return a.OK()
+		}
+		if a.Anonymous != b.Anonymous {
+			return a.Anonymous
+		}
+		return a.Latency < b.Latency
+	})
+	return statuses
+}
+
+// UsableProxies returns proxies of working statuses in order of the statuses,
+// only anonymous ones if anonymousOnly is set. The result can be passed to WithProxies.
+func UsableProxies(statuses []ProxyStatus, anonymousOnly bool) []string {
+	var proxies []string
+	for _, status := range statuses {
+		if status.OK() && (status.Anonymous || !anonymousOnly) {
+			proxies = append(proxies, status.Proxy)
+		}
+	}
+	return proxies
+}
+
+// HealthyProxies returns a provider which checks proxies of the provider by
+// CheckProxies on each call and returns usable ones from the best one. Wrap
+// the result by ReloadProxies to check proxies periodically:
+//
+//	provider := sitemap.ReloadProxies(sitemap.HealthyProxies(list, target, false), 10*time.Minute)
+func HealthyProxies(provider ProxyProvider, target string, anonymousOnly bool, opts ...Option) ProxyProvider {
+	return ProxyProviderFunc(func() ([]string, error) {
+		proxies, err := provider.Proxies()
+		if err != nil {
+			return nil, err
+		}
+		return UsableProxies(CheckProxies(context.Background(), target, proxies, opts...), anonymousOnly), nil
+	})
+}
+
+func checkProxy(ctx context.Context, target, proxy string, opts []Option) ProxyStatus {
+	o := newOptions(append(append([]Option(nil), opts...), func(o *options) {
+		o.proxies = []string{proxy}
+		o.proxyProvider = nil
+	}))
+	status := ProxyStatus{Proxy: proxy}
+
+	start := time.Now()
+	res, err := o.get(ctx, target)
+	if err == nil {
+		err = checkStatus(res, target)
+	}
+	if err != nil {
+		status.Err = err
+		return status
+	}
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(io.LimitReader(res.Body, proxyCheckBodySize))
+	status.Latency = time.Since(start)
+	if err != nil {
+		status.Err = err
+		return status
+	}
+
+	status.Anonymous = !identifyingHeaders.Match(body)
+	return status
+}
@@ -0,0 +1,49 @@
+package sitemap
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCheckProxies(t *testing.T) {
+	newProxy := func(status int, delay time.Duration, header string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(delay)
+			w.WriteHeader(status)
+			fmt.Fprintf(w, `{"headers": {"Host": %q%s}}`, r.Host, header)
+		}))
+	}
+	slow := newProxy(http.StatusOK, 20*time.Millisecond, "")
+	defer slow.Close()
+	fast := newProxy(http.StatusOK, 0, "")
+	defer fast.Close()
+	transparent := newProxy(http.StatusOK, 0, `, "Via": "1.1 proxy"`)
+	defer transparent.Close()
+	broken := newProxy(http.StatusBadGateway, 0, "")
+	defer broken.Close()
+
+	statuses := CheckProxies(context.Background(), "http://echo.test/headers",
+		[]string{broken.URL, transparent.URL, slow.URL, fast.URL})
+
+	var ranked []string
+	for _, status := range statuses {
+		ranked = append(ranked, status.Proxy)
+	}
+	expected := []string{fast.URL, slow.URL, transparent.URL, broken.URL}
+	if !reflect.DeepEqual(ranked, expected) {
+		t.Errorf("Expected ranking %v, but given %v", expected, ranked)
+	}
+	if _, ok := statuses[3].Err.(*HTTPError); !ok {
+		t.Errorf("Expected HTTPError of the broken proxy, but given %v", statuses[3].Err)
+	}
+
+	usable := UsableProxies(statuses, true)
+	if !reflect.DeepEqual(usable, []string{fast.URL, slow.URL}) {
+		t.Errorf("Unexpected usable proxies %v", usable)
+	}
+}
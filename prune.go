@@ -0,0 +1,60 @@
+package sitemap
+
+import (
+	"context"
+	"io"
+	"net/http"
+)
+
+// Removal describes an URL which was removed from a sitemap by Prune.
+type Removal struct {
+	Location   string
+	StatusCode int
+}
+
+// RemovalConsumer is a type represents consumer of removal report records.
+type RemovalConsumer func(Removal) error
+
+// Prune parses the sitemap which provides by the reader, checks each page and
+// writes entries of reachable pages by the writer, while pages which respond
+// with 404 Not Found or 410 Gone are passed to the report consumer instead.
+// Pages which can't be checked due to network errors are kept.
+//
+// Transforms given by WithTransform are applied before the check.
+// Pages are checked one by one in order of the sitemap. It doesn't close the writer.
+//
+// Keep in mind. Kept entries are written like Pipe does, by loc, lastmod,
+// changefreq and priority only, so image, video, news and xhtml:link
+// extensions aren't in the pruned sitemap.
+func Prune(reader io.Reader, writer EntryWriter, report RemovalConsumer, opts ...Option) error {
+	o := newOptions(opts)
+	check := func(e Entry) (Entry, error) {
+		status, err := o.status(context.Background(), e.GetLocation())
+		if err != nil || (status != http.StatusNotFound && status != http.StatusGone) {
+			return e, nil
+		}
+		return nil, report(Removal{Location: e.GetLocation(), StatusCode: status})
+	}
+
+	return Pipe(reader, writer, append(opts[:len(opts):len(opts)], WithTransform(check))...)
+}
+
+// status returns the status code of the page. It uses HEAD request and falls
+// back to GET if the server doesn't allow HEAD.
+func (o *options) status(ctx context.Context, location string) (int, error) {
+	status, err := o.request(ctx, http.MethodHead, location)
+	if err == nil && (status == http.StatusMethodNotAllowed || status == http.StatusNotImplemented) {
+		status, err = o.request(ctx, http.MethodGet, location)
+	}
+	return status, err
+}
+
+func (o *options) request(ctx context.Context, method, location string) (int, error) {
+	res, err := o.do(ctx, method, location)
+	if err != nil {
+		return 0, err
+	}
+	res.Body.Close()
+
+	return res.StatusCode, nil
+}
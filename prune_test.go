@@ -0,0 +1,85 @@
+package sitemap
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPrune(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/missed":
+			http.NotFound(w, r)
+		case "/gone":
+			w.WriteHeader(http.StatusGone)
+		case "/no-head":
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			http.NotFound(w, r)
+		case "/broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	sitemap := `<urlset xmlns:image="http://www.google.com/schemas/sitemap-image/1.1">
+		<url><loc>` + server.URL + `/ok</loc><image:image><image:loc>http://HOST/a.png</image:loc></image:image></url>
+		<url><loc>` + server.URL + `/missed</loc></url>
+		<url><loc>` + server.URL + `/gone</loc></url>
+		<url><loc>` + server.URL + `/no-head</loc></url>
+		<url><loc>` + server.URL + `/broken</loc></url>
+	</urlset>`
+
+	var buf bytes.Buffer
+	var removals []Removal
+	w := NewWriter(&buf)
+	err := Prune(strings.NewReader(sitemap), w, func(r Removal) error {
+		r.Location = strings.TrimPrefix(r.Location, server.URL)
+		removals = append(removals, r)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Pruning failed with error %s", err)
+	}
+	w.Close()
+
+	expected := []Removal{{"/missed", 404}, {"/gone", 410}, {"/no-head", 404}}
+	if !reflect.DeepEqual(removals, expected) {
+		t.Errorf("Unexpected removals %v", removals)
+	}
+
+	var kept []string
+	images := 0
+	Parse(&buf, func(e Entry) error {
+		kept = append(kept, strings.TrimPrefix(e.GetLocation(), server.URL))
+		images += len(e.(ImagesProvider).GetImages())
+		return nil
+	})
+	if strings.Join(kept, " ") != "/ok /broken" {
+		t.Errorf("Unexpected kept entries %v", kept)
+	}
+	if images != 0 {
+		t.Errorf("Expected extensions dropped, but given %d images", images)
+	}
+}
+
+func TestPrune_KeepsOptions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	opts := make([]Option, 0, 1)
+	err := Prune(strings.NewReader("<urlset><url><loc>"+server.URL+"/</loc></url></urlset>"), NewWriter(&bytes.Buffer{}),
+		func(r Removal) error { return nil }, opts...)
+	if err != nil {
+		t.Fatalf("Pruning failed with error %s", err)
+	}
+	if opts[:1][0] != nil {
+		t.Error("Options of the caller are overwritten")
+	}
+}
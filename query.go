@@ -0,0 +1,113 @@
+package sitemap
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Issue codes of QueryPolicy rule.
+const (
+	IssueStrippedQuery IssueCode = "stripped-query-parameter" // An URL has parameters which must be stripped
+	IssueRejectedQuery IssueCode = "rejected-query-parameter" // An URL has parameters which aren't allowed at all
+)
+
+// QueryPolicy describes which query parameters are allowed in sitemap URLs.
+// Parameter names can end with * to match all names with the prefix, e.g. utm_*.
+//
+// Strip lists parameters which are removed.
+// Allow lists parameters which are kept, others are removed. Empty Allow keeps all.
+// Reject makes URLs which still have parameters after removal to be rejected.
+type QueryPolicy struct {
+	Strip  []string
+	Allow  []string
+	Reject bool
+}
+
+// Transform returns a transform which removes parameters by the policy and
+// drops rejected entries.
+func (p QueryPolicy) Transform() Transform {
+	return func(e Entry) (Entry, error) {
+		location, rejected := p.apply(e.GetLocation())
+		if rejected {
+			return nil, nil
+		}
+		if location == e.GetLocation() {
+			return e, nil
+		}
+
+		c := copyEntry(e)
+		c.Location = location
+		return c, nil
+	}
+}
+
+// LintRule returns a lint rule which reports URLs violating the policy.
+func (p QueryPolicy) LintRule() LintRule {
+	return queryRule{p}
+}
+
+type queryRule struct {
+	policy QueryPolicy
+}
+
+func (r queryRule) Check(e Entry) []Issue {
+	location, rejected := r.policy.apply(e.GetLocation())
+	switch {
+	case rejected:
+		return []Issue{{
+			Code:     IssueRejectedQuery,
+			Severity: SeverityWarning,
+			Location: e.GetLocation(),
+			Message:  "URL has query parameters",
+		}}
+	case location != e.GetLocation():
+		return []Issue{{
+			Code:       IssueStrippedQuery,
+			Severity:   SeverityWarning,
+			Location:   e.GetLocation(),
+			Message:    "URL has query parameters which must be stripped",
+			Suggestion: location,
+		}}
+	}
+	return nil
+}
+
+// apply returns the URL with parameters removed by the policy and whether the
+// URL is rejected.
+func (p *QueryPolicy) apply(location string) (string, bool) {
+	u, err := url.Parse(location)
+	if err != nil || u.RawQuery == "" {
+		return location, false
+	}
+
+	query := u.Query()
+	changed := false
+	for name := range query {
+		if matchParam(p.Strip, name) || (len(p.Allow) > 0 && !matchParam(p.Allow, name)) {
+			delete(query, name)
+			changed = true
+		}
+	}
+
+	if p.Reject && len(query) > 0 {
+		return location, true
+	}
+	if !changed {
+		return location, false
+	}
+
+	u.RawQuery = query.Encode()
+	return u.String(), false
+}
+
+func matchParam(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if strings.HasSuffix(pattern, "*") && strings.HasPrefix(name, pattern[:len(pattern)-1]) {
+			return true
+		}
+		if pattern == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+package sitemap
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+)
+
+// WithRawEntries makes parsing functions keep XML of elements of entries, so
+// it can be read by RawProvider. XML is kept as it is read by the parser, so
+// it is exact for UTF-8 documents only, use WithTranscoding for others. Raw
+// XML isn't kept for documents in other encodings without transcoding.
+func WithRawEntries() Option {
+	return func(o *options) {
+		o.rawEntries = true
+	}
+}
+
+// rawRecorder is a reader which records bytes read by the XML decoder since
+// the last mark. It implements io.ByteReader, so the decoder doesn't read ahead.
+type rawRecorder struct {
+	reader *bufio.Reader
+	buf    []byte
+	start  int
+	// disabled is set if the decoder switches to another encoding.
+	disabled bool
+}
+
+func newRawRecorder(reader io.Reader) *rawRecorder {
+	return &rawRecorder{reader: bufio.NewReader(reader)}
+}
+
+func (r *rawRecorder) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.buf = append(r.buf, p[:n]...)
+	return n, err
+}
+
+func (r *rawRecorder) ReadByte() (byte, error) {
+	b, err := r.reader.ReadByte()
+	if err == nil {
+		r.buf = append(r.buf, b)
+	}
+	return b, err
+}
+
+// mark drops recorded bytes, an element is recorded since the last mark.
+func (r *rawRecorder) mark() {
+	if r != nil {
+		r.buf, r.start = r.buf[:0], 0
+	}
+}
+
+// begin marks the start tag which is just read as the start of the element.
+// Attribute values can't contain '<', so the tag starts at the last one.
+func (r *rawRecorder) begin() {
+	if r == nil {
+		return
+	}
+	if r.start = bytes.LastIndexByte(r.buf, '<'); r.start < 0 {
+		r.start = 0
+	}
+}
+
+// element returns a copy of the element which is read since begin. A nil
+// recorder returns nil.
+func (r *rawRecorder) element() []byte {
+	if r == nil || r.disabled {
+		return nil
+	}
+	return append([]byte(nil), r.buf[r.start:]...)
+}
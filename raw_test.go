@@ -0,0 +1,56 @@
+package sitemap
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse_OptionalInterfaces(t *testing.T) {
+	sitemap := `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" xmlns:image="http://www.google.com/schemas/sitemap-image/1.1">
+	<!-- first -->
+	<url><loc>http://example.com/a</loc><image:image><image:loc>http://example.com/a.png</image:loc></image:image></url>
+	<url a="b"><loc>http://example.com/b</loc><custom>value</custom></url>
+	<url/>
+</urlset>`
+
+	var raws []string
+	err := Parse(strings.NewReader(sitemap), func(e Entry) error {
+		_, images := e.(ImagesProvider)
+		_, videos := e.(VideosProvider)
+		_, news := e.(NewsProvider)
+		_, alternates := e.(AlternatesProvider)
+		if !images || !videos || !news || !alternates {
+			t.Errorf("Entry %s doesn't implement optional interfaces", e.GetLocation())
+		}
+		raws = append(raws, string(e.(RawProvider).GetRaw()))
+		return nil
+	}, WithRawEntries())
+	if err != nil {
+		t.Fatalf("Parsing failed with error %s", err)
+	}
+
+	expected := []string{
+		`<url><loc>http://example.com/a</loc><image:image><image:loc>http://example.com/a.png</image:loc></image:image></url>`,
+		`<url a="b"><loc>http://example.com/b</loc><custom>value</custom></url>`,
+		`<url/>`,
+	}
+	if len(raws) != len(expected) {
+		t.Fatalf("Expected %d entries, but given %d", len(expected), len(raws))
+	}
+	for i, raw := range raws {
+		if raw != expected[i] {
+			t.Errorf("Expected raw XML %q, but given %q", expected[i], raw)
+		}
+	}
+
+	err = Parse(strings.NewReader(sitemap), func(e Entry) error {
+		if raw := e.(RawProvider).GetRaw(); raw != nil {
+			t.Errorf("Expected no raw XML without WithRawEntries, but given %q", raw)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Parsing failed with error %s", err)
+	}
+}
@@ -0,0 +1,73 @@
+package sitemap
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// WithResolve pins hosts to fixed addresses like --resolve option of curl,
+// e.g. for validation of a site before launch or for bypassing of flaky DNS.
+// An entry is "host:port:address", e.g. "example.com:443:10.0.0.5", several
+// addresses are separated by commas and tried in order, IPv6 addresses are
+// in brackets like "[::1]". Requests keep the host for headers and TLS.
+//
+// Pins apply to connections of the client, so with proxies they pin addresses
+// of proxies. Like proxies they are used unless the client set by
+// WithHTTPClient has a transport other than *http.Transport.
+func WithResolve(entries ...string) Option {
+	return func(o *options) {
+		o.resolve = append(o.resolve, entries...)
+	}
+}
+
+// parseResolve returns addresses of entries of WithResolve keyed by host:port.
+func parseResolve(entries []string) (map[string][]string, error) {
+	pins := make(map[string][]string, len(entries))
+	for _, entry := range entries {
+		parts := strings.SplitN(entry, ":", 3)
+		if len(parts) != 3 || parts[0] == "" {
+			return nil, fmt.Errorf("sitemap: invalid resolve entry %q, expected host:port:address", entry)
+		}
+		if port, err := strconv.Atoi(parts[1]); err != nil || port <= 0 || port > 65535 {
+			return nil, fmt.Errorf("sitemap: invalid port of resolve entry %q", entry)
+		}
+
+		var addresses []string
+		for _, address := range strings.Split(parts[2], ",") {
+			address = strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(address), "["), "]")
+			if net.ParseIP(address) == nil {
+				return nil, fmt.Errorf("sitemap: invalid address %q of resolve entry %q", address, entry)
+			}
+			addresses = append(addresses, net.JoinHostPort(address, parts[1]))
+		}
+		pins[net.JoinHostPort(strings.ToLower(parts[0]), parts[1])] = addresses
+	}
+	return pins, nil
+}
+
+// pinTransport makes the transport dial pinned addresses instead of resolved ones.
+func pinTransport(transport *http.Transport, pins map[string][]string) {
+	dial := transport.DialContext
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+	transport.DialContext = func(ctx context.Context, network, address string) (net.Conn, error) {
+		addresses, ok := pins[strings.ToLower(address)]
+		if !ok {
+			return dial(ctx, network, address)
+		}
+
+		var err error
+		for _, pinned := range addresses {
+			var conn net.Conn
+			if conn, err = dial(ctx, network, pinned); err == nil {
+				return conn, nil
+			}
+		}
+		return nil, err
+	}
+}
@@ -0,0 +1,40 @@
+package sitemap
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWithResolve(t *testing.T) {
+	var hosts []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hosts = append(hosts, r.Host)
+		fmt.Fprint(w, "<urlset><url><loc>http://HOST/</loc></url></urlset>")
+	}))
+	defer server.Close()
+	_, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+
+	// the server listens 127.0.0.1 only, so the second address is used
+	resolve := fmt.Sprintf("Sitemap.test:%s:127.0.0.2,[::ffff:127.0.0.1]", port)
+	location := fmt.Sprintf("http://sitemap.test:%s/sitemap.xml", port)
+	err := ParseFromSite(location, func(e Entry) error { return nil }, WithResolve(resolve))
+	if err != nil {
+		t.Fatalf("Parsing failed with error %s", err)
+	}
+	if len(hosts) != 1 || hosts[0] != "sitemap.test:"+port {
+		t.Errorf("Expected a request of host sitemap.test, but given %v", hosts)
+	}
+}
+
+func TestWithResolve_Invalid(t *testing.T) {
+	for _, entry := range []string{"example.com", ":80:127.0.0.1", "example.com:http:127.0.0.1", "example.com:80:localhost"} {
+		err := ParseFromSite("http://example.com/sitemap.xml", func(e Entry) error { return nil }, WithResolve(entry))
+		if err == nil || !strings.Contains(err.Error(), "resolve entry") {
+			t.Errorf("Expected an error of resolve entry %q, but given %v", entry, err)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package sitemap
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// snippetSize is the max size of a body snippet kept in HTTPError.
+const snippetSize = 512
+
+// sniffSize is the size of the beginning of a body which is checked for HTML
+// and for markers of soft errors.
+const sniffSize = 4096
+
+// ErrNotSitemap is returned when a downloaded document is an HTML page, like an
+// error page or a login form, instead of a sitemap. Check it by errors.Is.
+var ErrNotSitemap = errors.New("sitemap: not a sitemap")
+
+// HTTPError is returned when a sitemap is responded with a non-2xx status.
+//
+// Location is the target of a redirect which wasn't followed, see WithRedirectPolicy.
+// Snippet is the beginning of the response body.
+type HTTPError struct {
+	URL        string
+	StatusCode int
+	Status     string
+	Location   string
+	Snippet    string
+}
+
+func (e *HTTPError) Error() string {
+	if e.Location != "" {
+		return fmt.Sprintf("sitemap: unexpected status %q of %s redirecting to %s", e.Status, e.URL, e.Location)
+	}
+	return fmt.Sprintf("sitemap: unexpected status %q of %s", e.Status, e.URL)
+}
+
+// RedirectAction is a type represents an action of RedirectPolicy for
+// redirects which change the scheme.
+type RedirectAction = string
+
+// Redirect actions constants set.
+const (
+	RedirectAllow RedirectAction = ""     // The redirect is followed
+	RedirectWarn  RedirectAction = "warn" // The redirect is followed and reported as EventWarning
+	RedirectDeny  RedirectAction = "deny" // The redirect isn't followed
+)
+
+// defaultMaxRedirects is the count of followed redirects of http.Client.
+const defaultMaxRedirects = 10
+
+// RedirectPolicy describes which redirects are followed while downloading.
+//
+// MaxRedirects is the max count of followed redirects, zero means the default
+// 10 and a negative count disables redirects.
+// SameHost disables redirects to other hosts.
+// Upgrade is the action for http to https redirects and Downgrade is the
+// action for https to http ones, the latter expose requests to tampering.
+// Warnings are passed to the handler of WithEvents.
+type RedirectPolicy struct {
+	MaxRedirects int
+	SameHost     bool
+	Upgrade      RedirectAction
+	Downgrade    RedirectAction
+}
+
+// WithRedirectPolicy sets the policy of redirects. By default up to 10 redirects
+// are followed to any host and scheme. Redirects which aren't followed are
+// HTTPError.
+func WithRedirectPolicy(policy RedirectPolicy) Option {
+	return func(o *options) {
+		o.redirects = &policy
+	}
+}
+
+func (o *options) checkRedirect(req *http.Request, via []*http.Request) error {
+	p := o.redirects
+	max := p.MaxRedirects
+	if max == 0 {
+		max = defaultMaxRedirects
+	}
+	if len(via) > max {
+		return http.ErrUseLastResponse
+	}
+	if p.SameHost && !strings.EqualFold(req.URL.Host, via[0].URL.Host) {
+		return http.ErrUseLastResponse
+	}
+
+	from := via[len(via)-1].URL
+	action := RedirectAllow
+	switch {
+	case strings.EqualFold(from.Scheme, "http") && strings.EqualFold(req.URL.Scheme, "https"):
+		action = p.Upgrade
+	case strings.EqualFold(from.Scheme, "https") && strings.EqualFold(req.URL.Scheme, "http"):
+		action = p.Downgrade
+	}
+	switch action {
+	case RedirectDeny:
+		return http.ErrUseLastResponse
+	case RedirectWarn:
+		o.events.emit(Event{Kind: EventWarning, URL: via[0].URL.String(),
+			Message: fmt.Sprintf("redirect from %s to %s changes the scheme", from, req.URL)})
+	}
+	return nil
+}
+
+// getSitemap downloads a sitemap like get does. It returns HTTPError for non-2xx
+// statuses and ErrNotSitemap for HTML pages.
+func (o *options) getSitemap(ctx context.Context, location string) (*http.Response, error) {
+	res, err := o.get(ctx, location)
+	if err != nil {
+		return nil, err
+	}
+	return checkSitemap(res, location)
+}
+
+// checkSitemap checks the status and the body of the response. The body is
+// closed if the response is rejected.
+func checkSitemap(res *http.Response, location string) (*http.Response, error) {
+	if err := checkStatus(res, location); err != nil {
+		return nil, err
+	}
+
+	body, err := checkBody(res.Body, location, res.Header.Get("Content-Type"))
+	if err != nil {
+		res.Body.Close()
+		return nil, err
+	}
+	res.Body = &readCloser{Reader: body, Closer: res.Body}
+	return res, nil
+}
+
+// checkStatus returns HTTPError and closes the body if the status isn't 2xx.
+func checkStatus(res *http.Response, location string) error {
+	if isSuccess(res) {
+		return nil
+	}
+
+	defer res.Body.Close()
+	snippet, _ := ioutil.ReadAll(io.LimitReader(res.Body, snippetSize))
+	return &HTTPError{
+		URL:        location,
+		StatusCode: res.StatusCode,
+		Status:     res.Status,
+		Location:   res.Header.Get("Location"),
+		Snippet:    string(snippet),
+	}
+}
+
+// checkBody returns SoftError if the body is an HTML page of an error or of
+// a captcha, ErrNotSitemap if it is another HTML page, otherwise it returns
+// a reader of the whole body.
+func checkBody(body io.Reader, location, contentType string) (io.Reader, error) {
+	reader, head, html := sniffPage(body)
+	if html {
+		if reason := softErrorReason(head); reason != "" {
+			return nil, newSoftError(location, contentType, reason, head)
+		}
+		return nil, fmt.Errorf("%w: %s is an HTML page of type %q", ErrNotSitemap, location, contentType)
+	}
+	return reader, nil
+}
+
+// sniffHTML reports whether the data starts like an HTML page. The returned
+// reader returns the whole data.
+func sniffHTML(reader io.Reader) (io.Reader, bool) {
+	reader, _, html := sniffPage(reader)
+	return reader, html
+}
+
+// sniffPage is like sniffHTML, but it returns the beginning of the data too,
+// up to sniffSize bytes of HTML pages and up to snippetSize bytes of others.
+func sniffPage(reader io.Reader) (io.Reader, []byte, bool) {
+	buffered := bufio.NewReaderSize(reader, snippetSize)
+	peeked, _ := buffered.Peek(snippetSize)
+
+	head := bytes.TrimLeft(bytes.TrimPrefix(peeked, []byte("\xef\xbb\xbf")), " \t\r\n")
+	for len(head) > 0 && bytes.HasPrefix(head, []byte("<!--")) {
+		end := bytes.Index(head, []byte("-->"))
+		if end < 0 {
+			break
+		}
+		head = bytes.TrimLeft(head[end+3:], " \t\r\n")
+	}
+
+	lower := bytes.ToLower(head)
+	html := bytes.HasPrefix(lower, []byte("<!doctype html")) || bytes.HasPrefix(lower, []byte("<html")) ||
+		bytes.HasPrefix(lower, []byte("<head")) || bytes.HasPrefix(lower, []byte("<body"))
+	if !html {
+		return buffered, peeked, false
+	}
+	page := bufio.NewReaderSize(buffered, sniffSize)
+	peeked, _ = page.Peek(sniffSize)
+	return page, peeked, true
+}
+
+// finalURL returns URL of the response after redirects.
+func finalURL(res *http.Response, location string) string {
+	if res.Request != nil && res.Request.URL != nil {
+		return res.Request.URL.String()
+	}
+	return location
+}
@@ -0,0 +1,159 @@
+package sitemap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newResponseServer() *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/missing.xml", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, "<html><body>Page not found</body></html>")
+	})
+	mux.HandleFunc("/login.xml", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, "\n<!-- login -->\n<!DOCTYPE html><html><form></form></html>")
+	})
+	mux.HandleFunc("/moved.xml", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/sitemap.xml", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/external.xml", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://other.test/sitemap.xml", http.StatusFound)
+	})
+	mux.HandleFunc("/sitemap.xml", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml")
+		fmt.Fprint(w, "<urlset><url><loc>http://example.com/</loc></url></urlset>")
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestParseFromSite_HTTPError(t *testing.T) {
+	server := newResponseServer()
+	defer server.Close()
+
+	err := ParseFromSite(server.URL+"/missing.xml", func(e Entry) error {
+		return nil
+	})
+	httpErr, ok := err.(*HTTPError)
+	if !ok {
+		t.Fatalf("Expected HTTPError, but given %v", err)
+	}
+	if httpErr.StatusCode != http.StatusNotFound || !strings.Contains(httpErr.Snippet, "Page not found") {
+		t.Errorf("Unexpected HTTPError %+v", httpErr)
+	}
+}
+
+func TestParseFromSite_NotSitemap(t *testing.T) {
+	server := newResponseServer()
+	defer server.Close()
+
+	err := ParseFromSite(server.URL+"/login.xml", func(e Entry) error {
+		return nil
+	})
+	if !errors.Is(err, ErrNotSitemap) {
+		t.Errorf("Expected ErrNotSitemap, but given %v", err)
+	}
+}
+
+func TestParseFromSite_RedirectPolicy(t *testing.T) {
+	server := newResponseServer()
+	defer server.Close()
+
+	consumer := func(e Entry) error {
+		return nil
+	}
+	if err := ParseFromSite(server.URL+"/moved.xml", consumer, WithRedirectPolicy(RedirectPolicy{MaxRedirects: 1, SameHost: true})); err != nil {
+		t.Errorf("Expected followed redirect to the same host, but given error %v", err)
+	}
+
+	err := ParseFromSite(server.URL+"/external.xml", consumer, WithRedirectPolicy(RedirectPolicy{MaxRedirects: 1, SameHost: true}))
+	if httpErr, ok := err.(*HTTPError); !ok || httpErr.Location != "http://other.test/sitemap.xml" {
+		t.Errorf("Expected HTTPError of not followed redirect, but given %v", err)
+	}
+
+	if err = ParseFromSite(server.URL+"/moved.xml", consumer, WithRedirectPolicy(RedirectPolicy{Downgrade: RedirectDeny})); err != nil {
+		t.Errorf("Redirect with the default count failed with error %s", err)
+	}
+	err = ParseFromSite(server.URL+"/moved.xml", consumer, WithRedirectPolicy(RedirectPolicy{MaxRedirects: -1}))
+	if httpErr, ok := err.(*HTTPError); !ok || httpErr.StatusCode != http.StatusMovedPermanently {
+		t.Errorf("Expected HTTPError of disabled redirects, but given %v", err)
+	}
+}
+
+func TestParseFromSite_SchemeRedirects(t *testing.T) {
+	plain := newResponseServer()
+	defer plain.Close()
+	secure := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, plain.URL+"/sitemap.xml", http.StatusMovedPermanently)
+	}))
+	defer secure.Close()
+
+	consumer := func(e Entry) error {
+		return nil
+	}
+	cases := []struct {
+		action   RedirectAction
+		followed bool
+		warnings int
+	}{
+		{RedirectAllow, true, 0},
+		{RedirectWarn, true, 1},
+		{RedirectDeny, false, 0},
+	}
+	for _, c := range cases {
+		warnings := 0
+		err := ParseFromSite(secure.URL+"/sitemap.xml", consumer,
+			WithRedirectPolicy(RedirectPolicy{MaxRedirects: 10, Downgrade: c.action, Upgrade: RedirectDeny}),
+			WithEvents(func(e Event) {
+				if e.Kind == EventWarning {
+					warnings++
+				}
+			}))
+		if c.followed && err != nil {
+			t.Errorf("Expected followed downgrade of action %q, but given error %v", c.action, err)
+		}
+		if httpErr, ok := err.(*HTTPError); !c.followed && (!ok || httpErr.Location != plain.URL+"/sitemap.xml") {
+			t.Errorf("Expected HTTPError of denied downgrade, but given %v", err)
+		}
+		if warnings != c.warnings {
+			t.Errorf("Expected %d warnings of action %q, but given %d", c.warnings, c.action, warnings)
+		}
+	}
+}
+
+func TestCrawler_FinalURL(t *testing.T) {
+	server := newResponseServer()
+	defer server.Close()
+
+	report, err := NewCrawler().Crawl(context.Background(), server.URL+"/moved.xml", func(e Entry) error {
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Crawling failed with error %s", err)
+	}
+
+	r := report.Sitemaps[0]
+	if r.FinalURL != server.URL+"/sitemap.xml" || r.ContentType != "application/xml" || r.Entries != 1 {
+		t.Errorf("Unexpected report %+v", r)
+	}
+}
+
+func TestSniffHTML(t *testing.T) {
+	tests := map[string]bool{
+		"<?xml version=\"1.0\"?><urlset></urlset>": false,
+		"\xef\xbb\xbf  <HTML lang=\"en\">":         true,
+		"<!-- generated --><urlset></urlset>":      false,
+		"http://example.com/\n":                    false,
+	}
+	for data, expected := range tests {
+		if _, html := sniffHTML(strings.NewReader(data)); html != expected {
+			t.Errorf("Expected %t for %q, but given %t", expected, data, html)
+		}
+	}
+}
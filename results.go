@@ -0,0 +1,201 @@
+package sitemap
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	resultsPrefix = "results/"
+	// defaultPageSize is the count of results of a page if the query has no limit.
+	defaultPageSize = 100
+)
+
+// errPageFull stops scanning of the store when a page is filled.
+var errPageFull = errors.New("sitemap: page is full")
+
+// WithResults makes walks keep consumed entries in the store, so they can be
+// browsed by QueryResults without crawling again. An entry is kept by its
+// location, so it is replaced by the same URL of the next crawl. Use separate
+// stores (or tenant options, see Tenant) to keep results of sites apart.
+func WithResults(store StateStore) Option {
+	return func(o *options) {
+		o.resultStore = store
+	}
+}
+
+// Result is a stored entry. Sitemap is the sitemap which had the entry, Crawled
+// is the time when the entry was consumed.
+type Result struct {
+	Entry   Entry
+	Sitemap string
+	Crawled time.Time
+}
+
+// ResultQuery selects stored results.
+//
+// Host selects results of the host, all hosts if it is empty. PathPrefix selects
+// results which paths start with it, e.g. "/blog/". ModifiedFrom and ModifiedTo
+// select results with lastmod in [ModifiedFrom, ModifiedTo), results without
+// lastmod are skipped if any of them is set. Limit is the size of a page which
+// is 100 by default. Cursor is ResultPage.Next of the previous page, empty for
+// the first one.
+type ResultQuery struct {
+	Host         string
+	PathPrefix   string
+	ModifiedFrom time.Time
+	ModifiedTo   time.Time
+	Limit        int
+	Cursor       string
+}
+
+// ResultPage is a page of results ordered by host and path. Next is the cursor
+// of the next page, it is empty for the last one.
+type ResultPage struct {
+	Results []Result
+	Next    string
+}
+
+// storedResult is a stored record of a consumed entry.
+type storedResult struct {
+	Location        string     `json:"loc"`
+	LastModified    string     `json:"lastmod,omitempty"`
+	Modified        *time.Time `json:"modified,omitempty"`
+	ChangeFrequency Frequency  `json:"changefreq,omitempty"`
+	Priority        float32    `json:"priority,omitempty"`
+	Sitemap         string     `json:"sitemap"`
+	Crawled         time.Time  `json:"crawled"`
+}
+
+// QueryResults returns a page of results kept in the store by WithResults.
+// Pages are read by a prefix scan of the store which starts after the cursor,
+// so a query reads only results of the host and the path prefix if they are
+// set and skips nothing of previous pages.
+func QueryResults(store StateStore, q ResultQuery) (*ResultPage, error) {
+	limit := q.Limit
+	if limit <= 0 {
+		limit = defaultPageSize
+	}
+
+	prefix := resultsPrefix
+	if q.Host != "" {
+		path := q.PathPrefix
+		if !strings.HasPrefix(path, "/") {
+			path = "/" + path
+		}
+		prefix += strings.ToLower(q.Host) + path
+	}
+	// The zero byte makes the smallest key after the cursor.
+	start := ""
+	if q.Cursor != "" {
+		start = resultsPrefix + q.Cursor + "\x00"
+	}
+
+	page := &ResultPage{}
+	var last string
+	err := store.Scan(prefix, start, func(key string, value []byte) error {
+		if q.Host == "" && q.PathPrefix != "" && !strings.HasPrefix(resultPath(key), q.PathPrefix) {
+			return nil
+		}
+
+		var stored storedResult
+		if err := json.Unmarshal(value, &stored); err != nil {
+			return err
+		}
+		if !stored.modifiedIn(q.ModifiedFrom, q.ModifiedTo) {
+			return nil
+		}
+		if len(page.Results) == limit {
+			page.Next = last[len(resultsPrefix):]
+			return errPageFull
+		}
+
+		page.Results = append(page.Results, stored.result())
+		last = key
+		return nil
+	})
+	if err != nil && err != errPageFull {
+		return nil, err
+	}
+	return page, nil
+}
+
+// storeResult keeps the entry consumed from the sitemap if WithResults is set.
+func (o *options) storeResult(sitemap string, e Entry) error {
+	if o.resultStore == nil {
+		return nil
+	}
+	key, ok := resultKey(e.GetLocation())
+	if !ok {
+		return nil
+	}
+
+	data, err := json.Marshal(storedResult{
+		Location:        e.GetLocation(),
+		LastModified:    lastModifiedRaw(e),
+		Modified:        e.GetLastModified(),
+		ChangeFrequency: e.GetChangeFrequency(),
+		Priority:        e.GetPriority(),
+		Sitemap:         sitemap,
+		Crawled:         o.now(),
+	})
+	if err != nil {
+		return err
+	}
+	return o.resultStore.Put(key, data)
+}
+
+// resultKey returns the key of the location, which orders results by host and
+// path. Results of the same URL with different schemes share the key.
+func resultKey(location string) (string, bool) {
+	u, err := url.Parse(location)
+	if err != nil || u.Host == "" {
+		return "", false
+	}
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	return resultsPrefix + strings.ToLower(u.Host) + path, true
+}
+
+// resultPath returns the path of the result key.
+func resultPath(key string) string {
+	key = key[len(resultsPrefix):]
+	if i := strings.Index(key, "/"); i >= 0 {
+		return key[i:]
+	}
+	return "/"
+}
+
+func (r *storedResult) modifiedIn(from, to time.Time) bool {
+	if from.IsZero() && to.IsZero() {
+		return true
+	}
+	if r.Modified == nil {
+		return false
+	}
+	return !r.Modified.Before(from) && (to.IsZero() || r.Modified.Before(to))
+}
+
+func (r *storedResult) result() Result {
+	return Result{
+		Entry: &sitemapEntry{
+			Location:           r.Location,
+			LastModified:       r.LastModified,
+			ParsedLastModified: r.Modified,
+			ChangeFrequency:    r.ChangeFrequency,
+			Priority:           r.Priority,
+			hasChangeFrequency: true,
+			hasPriority:        true,
+		},
+		Sitemap: r.Sitemap,
+		Crawled: r.Crawled,
+	}
+}
@@ -0,0 +1,84 @@
+package sitemap
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestQueryResults(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<urlset>
+<url><loc>https://a.com/blog/1</loc><lastmod>2020-01-01</lastmod></url>
+<url><loc>https://a.com/blog/2</loc><lastmod>2020-02-01</lastmod></url>
+<url><loc>https://a.com/shop/1</loc><lastmod>2020-03-01</lastmod></url>
+<url><loc>https://a.com/blog/3</loc></url>
+<url><loc>https://b.com/blog/1</loc><lastmod>2020-04-01</lastmod></url>
+</urlset>`)
+	}))
+	defer server.Close()
+
+	store := NewMemoryStateStore()
+	crawler := NewCrawler(WithResults(store))
+	if _, err := crawler.Crawl(context.Background(), server.URL, func(e Entry) error { return nil }); err != nil {
+		t.Fatalf("Crawling failed with error %s", err)
+	}
+
+	query := func(q ResultQuery) ([]string, string) {
+		page, err := QueryResults(store, q)
+		if err != nil {
+			t.Fatalf("Query failed with error %s", err)
+		}
+		var locations []string
+		for _, r := range page.Results {
+			if r.Sitemap != server.URL {
+				t.Errorf("Expected sitemap %s, but given %s", server.URL, r.Sitemap)
+			}
+			locations = append(locations, r.Entry.GetLocation())
+		}
+		return locations, page.Next
+	}
+
+	cases := []struct {
+		query    ResultQuery
+		expected []string
+	}{
+		{ResultQuery{}, []string{"https://a.com/blog/1", "https://a.com/blog/2", "https://a.com/blog/3", "https://a.com/shop/1", "https://b.com/blog/1"}},
+		{ResultQuery{Host: "A.com"}, []string{"https://a.com/blog/1", "https://a.com/blog/2", "https://a.com/blog/3", "https://a.com/shop/1"}},
+		{ResultQuery{Host: "a.com", PathPrefix: "/shop"}, []string{"https://a.com/shop/1"}},
+		{ResultQuery{PathPrefix: "/blog/1"}, []string{"https://a.com/blog/1", "https://b.com/blog/1"}},
+		{ResultQuery{
+			ModifiedFrom: time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC),
+			ModifiedTo:   time.Date(2020, 4, 1, 0, 0, 0, 0, time.UTC),
+		}, []string{"https://a.com/blog/2", "https://a.com/shop/1"}},
+	}
+	for _, c := range cases {
+		if locations, next := query(c.query); !reflect.DeepEqual(locations, c.expected) || next != "" {
+			t.Errorf("Expected results %v of query %+v, but given %v with cursor %q", c.expected, c.query, locations, next)
+		}
+	}
+
+	var pages [][]string
+	q := ResultQuery{Host: "a.com", Limit: 2}
+	for {
+		locations, next := query(q)
+		pages = append(pages, locations)
+		if next == "" {
+			break
+		}
+		q.Cursor = next
+	}
+	expected := [][]string{{"https://a.com/blog/1", "https://a.com/blog/2"}, {"https://a.com/blog/3", "https://a.com/shop/1"}}
+	if !reflect.DeepEqual(pages, expected) {
+		t.Errorf("Expected pages %v, but given %v", expected, pages)
+	}
+
+	page, _ := QueryResults(store, ResultQuery{Host: "b.com"})
+	if lastmod := page.Results[0].Entry.GetLastModified(); lastmod == nil || !lastmod.Equal(time.Date(2020, 4, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected lastmod of the stored entry, but given %v", lastmod)
+	}
+}
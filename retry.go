@@ -0,0 +1,371 @@
+package sitemap
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// RetryPolicy describes how failed downloads are retried.
+//
+// Attempts is the max count of attempts including the first one.
+// BaseDelay is the delay before the first retry, each next delay is doubled.
+// MaxDelay bounds delays including ones requested by Retry-After headers.
+// Jitter is a share of a delay which is randomized, from 0.0 to 1.0.
+// Statuses lists HTTP statuses which are retried, transient network errors
+// are always retried.
+type RetryPolicy struct {
+	Attempts  int
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	Jitter    float64
+	Statuses  []int
+}
+
+// DefaultRetryPolicy returns a policy of 4 attempts with delays starting from
+// half a second, which retries 429 and 5xx statuses except 501.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		Attempts:  4,
+		BaseDelay: 500 * time.Millisecond,
+		MaxDelay:  30 * time.Second,
+		Jitter:    0.5,
+		Statuses: []int{
+			http.StatusTooManyRequests,
+			http.StatusInternalServerError,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+	}
+}
+
+// WithRetry enables retries of failed downloads by the policy.
+// By default downloads aren't retried.
+func WithRetry(policy RetryPolicy) Option {
+	return func(o *options) {
+		o.retry = policy
+	}
+}
+
+// WithProxies sets URLs of proxies which are used for downloads. Each download
+// starts with the next proxy of the list and each retry switches to the next one.
+// Proxies are used unless the client set by WithHTTPClient has a transport
+// other than *http.Transport.
+func WithProxies(proxies ...string) Option {
+	return func(o *options) {
+		o.proxies = append(o.proxies, proxies...)
+	}
+}
+
+// WithRequestRate limits downloads to perSecond requests per second including
+// retries. By default requests aren't limited.
+func WithRequestRate(perSecond float64) Option {
+	return func(o *options) {
+		o.requestRate = perSecond
+	}
+}
+
+type proxyKey struct{}
+
+// get downloads the URL retrying it by the policy.
+func (o *options) get(ctx context.Context, location string) (*http.Response, error) {
+	return o.do(ctx, http.MethodGet, location)
+}
+
+// do sends a request without body retrying it by the policy. It returns the
+// response of the last attempt, even if its status is a retried one.
+func (o *options) do(ctx context.Context, method, location string) (*http.Response, error) {
+	return o.doHeader(ctx, method, location, nil)
+}
+
+// doHeader sends a request with the header like do does. The header can be nil.
+func (o *options) doHeader(ctx context.Context, method, location string, header http.Header) (*http.Response, error) {
+	if o.resolveErr != nil {
+		return nil, o.resolveErr
+	}
+	proxies, err := o.proxyURLs()
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, location, nil)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	if o.userAgent != "" {
+		req.Header.Set("User-Agent", o.userAgent)
+	}
+
+	attempts := o.retry.Attempts
+	if attempts < 1 {
+		attempts = 1
+	}
+	start := 0
+	if len(proxies) > 0 {
+		start = int(atomic.AddUint32(&o.nextProxy, 1)-1) % len(proxies)
+	}
+
+	for attempt := 0; ; attempt++ {
+		attemptReq := req
+		if len(proxies) > 0 {
+			proxy := proxies[(start+attempt)%len(proxies)]
+			attemptReq = req.WithContext(context.WithValue(ctx, proxyKey{}, proxy))
+		}
+
+		if err = o.waitSchedule(ctx); err != nil {
+			return nil, err
+		}
+		if err = o.rateLimiter.wait(ctx); err != nil {
+			return nil, err
+		}
+		if err = o.deadlineExceeded(); err != nil {
+			return nil, err
+		}
+		if err = o.countRequest(); err != nil {
+			return nil, err
+		}
+		o.events.emit(Event{Kind: FetchStarted, URL: location})
+		o.count(CounterRequests, 1)
+		started := time.Now()
+		res, err := o.httpClient().Do(attemptReq)
+		o.emitFetched(location, res, err, time.Since(started))
+		if err != nil {
+			o.count(CounterRequestErrors, 1)
+		}
+		if res != nil {
+			o.meterResponse(res, len(proxies) > 0)
+			o.limitResponse(res)
+		}
+
+		if challenge, challengeErr := o.detectChallenge(location, attempt, res); challenge != nil {
+			if challenge.UserAgent != "" {
+				req.Header.Set("User-Agent", challenge.UserAgent)
+			}
+			err = challengeErr
+			if attempt+1 >= attempts || !challenge.Retry || ctx.Err() != nil {
+				res.Body.Close()
+				return nil, err
+			}
+		} else if attempt+1 >= attempts || !o.retriable(ctx, res, err) {
+			return res, err
+		}
+
+		delay := o.retry.delay(attempt, res)
+		if res != nil {
+			res.Body.Close()
+		}
+		o.emitRetry(location, res, err, delay)
+		o.count(CounterRetries, 1)
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (o *options) retriable(ctx context.Context, res *http.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		return isTransient(err)
+	}
+	for _, status := range o.retry.Statuses {
+		if res.StatusCode == status {
+			return true
+		}
+	}
+	return o.isSoftError(res)
+}
+
+// delay returns the delay before the next attempt.
+func (p *RetryPolicy) delay(attempt int, res *http.Response) time.Duration {
+	// Delays are doubled up to MaxDelay, so shifts of late attempts don't overflow.
+	bound := p.MaxDelay
+	if bound <= 0 {
+		bound = math.MaxInt64
+	}
+	delay := p.BaseDelay
+	for i := 0; i < attempt && delay < bound; i++ {
+		if delay > bound/2 {
+			delay = bound
+			break
+		}
+		delay <<= 1
+	}
+	if p.Jitter > 0 {
+		delay -= time.Duration(rand.Float64() * p.Jitter * float64(delay))
+	}
+
+	if res != nil {
+		if after, ok := retryAfter(res.Header.Get("Retry-After")); ok && after > delay {
+			delay = after
+		}
+	}
+
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return delay
+}
+
+// retryAfter parses a Retry-After header which is either seconds or a date.
+func retryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return time.Until(date), true
+	}
+	return 0, false
+}
+
+// isTransient reports whether a request error is worth a retry.
+func isTransient(err error) bool {
+	if urlErr, ok := err.(*url.Error); ok {
+		err = urlErr.Err
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return dnsErr.IsTimeout || dnsErr.IsTemporary
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// insecureTLS disables verification of TLS certificates unless a client is set
+// by WithHTTPClient. ParseFromSite uses it for backward compatibility.
+func insecureTLS(o *options) {
+	o.insecure = true
+}
+
+// rateLimiter spaces requests evenly by the interval.
+type rateLimiter struct {
+	interval time.Duration
+	mu       sync.Mutex
+	next     time.Time
+}
+
+func newRateLimiter(perSecond float64) *rateLimiter {
+	if perSecond <= 0 {
+		return nil
+	}
+	return &rateLimiter{interval: time.Duration(float64(time.Second) / perSecond)}
+}
+
+// wait blocks until the next request is allowed. A nil limiter doesn't wait.
+func (l *rateLimiter) wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	at := l.next
+	if now := time.Now(); at.Before(now) {
+		at = now
+	}
+	l.next = at.Add(l.interval)
+	l.mu.Unlock()
+
+	delay := time.Until(at)
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// prepareClient parses proxies and pins of hosts and configures the transport
+// for proxies, pins and insecure TLS if required.
+func (o *options) prepareClient() {
+	for _, proxy := range o.proxies {
+		u, err := url.Parse(proxy)
+		if err != nil {
+			o.proxyErr = err
+			return
+		}
+		o.parsedProxies = append(o.parsedProxies, u)
+	}
+
+	pins, err := parseResolve(o.resolve)
+	if err != nil {
+		o.resolveErr = err
+	}
+
+	client := o.client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	insecure := o.insecure && o.client == nil
+	useProxies := len(o.proxies) > 0 || o.proxyProvider != nil
+	if !useProxies && !insecure && len(pins) == 0 {
+		o.builtClient = client
+		return
+	}
+
+	var transport *http.Transport
+	switch t := client.Transport.(type) {
+	case nil:
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	case *http.Transport:
+		transport = t.Clone()
+	default:
+		o.builtClient = client
+		return
+	}
+
+	if insecure {
+		if transport.TLSClientConfig == nil {
+			transport.TLSClientConfig = new(tls.Config)
+		}
+		transport.TLSClientConfig.InsecureSkipVerify = true
+	}
+	if useProxies {
+		fallback := transport.Proxy
+		transport.Proxy = func(req *http.Request) (*url.URL, error) {
+			if proxy, ok := req.Context().Value(proxyKey{}).(*url.URL); ok {
+				return proxy, nil
+			}
+			if fallback != nil {
+				return fallback(req)
+			}
+			return nil, nil
+		}
+	}
+	if len(pins) > 0 {
+		pinTransport(transport, pins)
+	}
+
+	c := *client
+	c.Transport = transport
+	o.builtClient = &c
+}
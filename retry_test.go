@@ -0,0 +1,111 @@
+package sitemap
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func testRetryPolicy() RetryPolicy {
+	policy := DefaultRetryPolicy()
+	policy.BaseDelay = time.Millisecond
+	policy.MaxDelay = 10 * time.Millisecond
+	return policy
+}
+
+func TestParseFromSite_Retry(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, "<urlset><url><loc>http://HOST/</loc></url></urlset>")
+	}))
+	defer server.Close()
+
+	counter := 0
+	err := ParseFromSite(server.URL, func(e Entry) error {
+		counter++
+		return nil
+	}, WithRetry(testRetryPolicy()))
+	if err != nil {
+		t.Fatalf("Parsing failed with error %s", err)
+	}
+	if attempts != 3 || counter != 1 {
+		t.Errorf("Expected 3 attempts and 1 entry, but given %d and %d", attempts, counter)
+	}
+}
+
+func TestParseIndexFromSite_ProxyRotation(t *testing.T) {
+	var requested []string
+	newProxy := func(name string, status int) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requested = append(requested, name+" "+r.URL.String())
+			w.WriteHeader(status)
+			fmt.Fprint(w, "<sitemapindex><sitemap><loc>http://HOST/sitemap.xml</loc></sitemap></sitemapindex>")
+		}))
+	}
+	broken := newProxy("broken", http.StatusBadGateway)
+	defer broken.Close()
+	working := newProxy("working", http.StatusOK)
+	defer working.Close()
+
+	counter := 0
+	err := ParseIndexFromSite("http://sitemap.test/index.xml", func(e IndexEntry) error {
+		counter++
+		return nil
+	}, WithProxies(broken.URL, working.URL), WithRetry(testRetryPolicy()))
+	if err != nil {
+		t.Fatalf("Parsing failed with error %s", err)
+	}
+
+	if counter != 1 || len(requested) != 2 ||
+		requested[0] != "broken http://sitemap.test/index.xml" ||
+		requested[1] != "working http://sitemap.test/index.xml" {
+		t.Errorf("Unexpected requests %v", requested)
+	}
+}
+
+func TestRetryPolicy_Delay(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: time.Second, MaxDelay: 10 * time.Second}
+	if d := policy.delay(2, nil); d != 4*time.Second {
+		t.Errorf("Expected 4s delay, but given %s", d)
+	}
+
+	res := &http.Response{Header: http.Header{"Retry-After": {"120"}}}
+	if d := policy.delay(0, res); d != 10*time.Second {
+		t.Errorf("Expected delay bounded by 10s, but given %s", d)
+	}
+	for _, attempt := range []int{4, 64, 1000} {
+		if d := policy.delay(attempt, nil); d != 10*time.Second {
+			t.Errorf("Expected delay bounded by 10s at attempt %d, but given %s", attempt, d)
+		}
+	}
+
+	unbounded := RetryPolicy{BaseDelay: time.Second}
+	if d := unbounded.delay(1000, nil); d != math.MaxInt64 {
+		t.Errorf("Expected the longest delay, but given %s", d)
+	}
+}
+
+func TestParseFromSite_KeepsOptions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<urlset><url><loc>http://HOST/</loc></url></urlset>")
+	}))
+	defer server.Close()
+
+	opts := make([]Option, 1, 2)
+	opts[0] = WithTimeout(time.Second)
+	if err := ParseFromSite(server.URL, func(e Entry) error { return nil }, opts...); err != nil {
+		t.Fatalf("Parsing failed with error %s", err)
+	}
+	if opts[:2][1] != nil {
+		t.Error("Options of the caller are overwritten")
+	}
+}
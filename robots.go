@@ -0,0 +1,94 @@
+package sitemap
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/url"
+	"strings"
+)
+
+// DiscoverFromRobots downloads /robots.txt of the site and returns URLs of
+// sitemaps declared by its Sitemap directives in order of declaration.
+func DiscoverFromRobots(siteURL string, opts ...Option) ([]string, error) {
+	robotsURL, err := url.Parse(siteURL)
+	if err != nil {
+		return nil, err
+	}
+	robotsURL = robotsURL.ResolveReference(&url.URL{Path: "/robots.txt"})
+
+	body, err := newOptions(opts).fetch(context.Background(), robotsURL.String())
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	return parseRobots(body, robotsURL)
+}
+
+// ParseFromRobots discovers sitemaps of the site by DiscoverFromRobots, downloads
+// and parses each of them and for each sitemap entry calls the consumer's function.
+// Sitemap indexes are walked recursively, gzipped sitemaps are decompressed.
+func ParseFromRobots(siteURL string, consumer EntryConsumer, opts ...Option) error {
+	sitemaps, err := DiscoverFromRobots(siteURL, opts...)
+	if err != nil {
+		return err
+	}
+
+	w := newWalker(context.Background(), newOptions(opts), consumer)
+	for _, sitemapURL := range sitemaps {
+		if err = w.walk(sitemapURL, 0); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// maxRobotsLine is the maximum length of a line of robots.txt which is parsed.
+const maxRobotsLine = 64 * 1024
+
+func parseRobots(reader io.Reader, base *url.URL) ([]string, error) {
+	var sitemaps []string
+	seen := make(map[string]bool)
+
+	// Lines longer than the buffer, e.g. huge comments or rules, are skipped
+	// instead of stopping the reading, so later Sitemap lines aren't lost.
+	buffered := bufio.NewReaderSize(reader, maxRobotsLine)
+	for skip := false; ; {
+		data, isPrefix, err := buffered.ReadLine()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return sitemaps, err
+		}
+		if skip || isPrefix {
+			skip = isPrefix
+			continue
+		}
+
+		line := string(data)
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+
+		i := strings.IndexByte(line, ':')
+		if i < 0 || !strings.EqualFold(strings.TrimSpace(line[:i]), "sitemap") {
+			continue
+		}
+
+		location, err := base.Parse(strings.TrimSpace(line[i+1:]))
+		if err != nil || location.Host == "" {
+			continue
+		}
+
+		value := location.String()
+		if !seen[value] {
+			seen[value] = true
+			sitemaps = append(sitemaps, value)
+		}
+	}
+
+	return sitemaps, nil
+}
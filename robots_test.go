@@ -0,0 +1,84 @@
+package sitemap
+
+import (
+	"compress/gzip"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func newSiteServer() *httptest.Server {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+
+	mux.HandleFunc("/robots.txt", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "User-agent: *\nDisallow: /private/\n\n")
+		fmt.Fprint(w, "Sitemap: /sitemap-index.xml # index\n")
+		fmt.Fprintf(w, "sitemap:%s/sitemap-news.xml\n", server.URL)
+		fmt.Fprint(w, "SITEMAP: /sitemap-index.xml\n")
+	})
+	mux.HandleFunc("/sitemap-index.xml", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "<sitemapindex><sitemap><loc>%s/sitemap-1.xml.gz</loc></sitemap></sitemapindex>", server.URL)
+	})
+	mux.HandleFunc("/sitemap-1.xml.gz", func(w http.ResponseWriter, r *http.Request) {
+		gz := gzip.NewWriter(w)
+		fmt.Fprintf(gz, "<urlset><url><loc>%s/a</loc></url><url><loc>%s/b</loc></url></urlset>", server.URL, server.URL)
+		gz.Close()
+	})
+	mux.HandleFunc("/sitemap-news.xml", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "<urlset><url><loc>%s/news</loc></url></urlset>", server.URL)
+	})
+
+	return server
+}
+
+func TestDiscoverFromRobots(t *testing.T) {
+	server := newSiteServer()
+	defer server.Close()
+
+	sitemaps, err := DiscoverFromRobots(server.URL + "/some/page?q=1")
+	if err != nil {
+		t.Fatalf("Discovering failed with error %s", err)
+	}
+
+	expected := server.URL + "/sitemap-index.xml " + server.URL + "/sitemap-news.xml"
+	if strings.Join(sitemaps, " ") != expected {
+		t.Errorf("Unexpected sitemaps %v", sitemaps)
+	}
+}
+
+func TestParseFromRobots(t *testing.T) {
+	server := newSiteServer()
+	defer server.Close()
+
+	var locations []string
+	err := ParseFromRobots(server.URL, func(e Entry) error {
+		locations = append(locations, strings.TrimPrefix(e.GetLocation(), server.URL))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Parsing failed with error %s", err)
+	}
+
+	sort.Strings(locations)
+	if strings.Join(locations, " ") != "/a /b /news" {
+		t.Errorf("Unexpected locations %v", locations)
+	}
+}
+
+func TestParseRobots_LongLine(t *testing.T) {
+	base, _ := url.Parse("http://example.com/robots.txt")
+	robots := "Sitemap: /first.xml\n# " + strings.Repeat("x", 3*maxRobotsLine) + "\r\nSitemap: /second.xml"
+
+	sitemaps, err := parseRobots(strings.NewReader(robots), base)
+	if err != nil {
+		t.Fatalf("Parsing failed with error %s", err)
+	}
+	if strings.Join(sitemaps, " ") != "http://example.com/first.xml http://example.com/second.xml" {
+		t.Errorf("Unexpected sitemaps %v", sitemaps)
+	}
+}
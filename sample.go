@@ -0,0 +1,246 @@
+package sitemap
+
+import (
+	"math/rand"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// PriorityBand is a type represents a range of priorities of entries.
+type PriorityBand = string
+
+// Priority bands constants set.
+const (
+	PriorityHigh   PriorityBand = "high"   // Priority is 0.8 or higher
+	PriorityMedium PriorityBand = "medium" // Priority is from 0.5 up to 0.8
+	PriorityLow    PriorityBand = "low"    // Priority is lower than 0.5
+)
+
+// Stratum is a group of entries of a sample. Section is the longest matching
+// prefix of WithSections or the first segment of the path like "/blog/" if no
+// sections are set, it is empty for entries out of any section.
+type Stratum struct {
+	Host    string
+	Section string
+	Band    PriorityBand
+}
+
+// StratumSample is a sample of a stratum. Total is the count of added entries
+// of the stratum, Entries are sampled ones in order of adding.
+type StratumSample struct {
+	Stratum
+	Total   int
+	Entries []Entry
+}
+
+// Sampler selects a stratified sample of entries for manual QA or spot checks
+// of reachability. Entries are grouped into strata by host, path section and
+// priority band, every stratum gets a share of the sample proportional to its
+// size, but at least one entry, so small sections aren't missed like they are
+// by sampling of the head of a sitemap. If there are more strata than the
+// sample size, the largest strata get an entry each. Entries of a stratum are
+// chosen uniformly at random.
+//
+// Samples are reproducible: the same entries added in the same order give the
+// same sample. Keep in mind, the sampler keeps up to the sample size entries
+// per stratum.
+//
+//	sampler := sitemap.NewSampler(100, sitemap.WithSections("/blog/", "/shop/"))
+//	report, err := crawler.Crawl(ctx, url, func(e sitemap.Entry) error {
+//		sampler.Add(e)
+//		return nil
+//	})
+//	sample := sampler.Sample()
+type Sampler struct {
+	o      *options
+	size   int
+	random *rand.Rand
+	strata map[Stratum]*reservoir
+}
+
+// reservoir keeps a uniform random sample of added entries.
+type reservoir struct {
+	total int
+	items []sampledEntry
+}
+
+type sampledEntry struct {
+	entry Entry
+	seq   int
+}
+
+// NewSampler creates a sampler of the sample of size entries. WithSections
+// option sets sections of strata.
+func NewSampler(size int, opts ...Option) *Sampler {
+	return &Sampler{
+		o:      newOptions(opts),
+		size:   size,
+		random: rand.New(rand.NewSource(1)),
+		strata: make(map[Stratum]*reservoir),
+	}
+}
+
+// Add adds the entry to the sampler.
+func (s *Sampler) Add(e Entry) {
+	if s.size <= 0 {
+		return
+	}
+
+	stratum := s.stratum(e)
+	r := s.strata[stratum]
+	if r == nil {
+		r = new(reservoir)
+		s.strata[stratum] = r
+	}
+
+	r.total++
+	item := sampledEntry{entry: e, seq: r.total}
+	if len(r.items) < s.size {
+		r.items = append(r.items, item)
+		return
+	}
+	if i := s.random.Intn(r.total); i < s.size {
+		r.items[i] = item
+	}
+}
+
+// Sample returns samples of strata ordered by host, section and band from
+// the high one to the low one. Strata without sampled entries are omitted.
+func (s *Sampler) Sample() []StratumSample {
+	strata := make([]Stratum, 0, len(s.strata))
+	for stratum := range s.strata {
+		strata = append(strata, stratum)
+	}
+	shares := s.shares(strata)
+
+	sort.Slice(strata, func(i, j int) bool { return strataLess(strata[i], strata[j]) })
+
+	var samples []StratumSample
+	for _, stratum := range strata {
+		share := shares[stratum]
+		if share == 0 {
+			continue
+		}
+		r := s.strata[stratum]
+		items := make([]sampledEntry, len(r.items))
+		copy(items, r.items)
+		s.random.Shuffle(len(items), func(i, j int) { items[i], items[j] = items[j], items[i] })
+		items = items[:share]
+		sort.Slice(items, func(i, j int) bool { return items[i].seq < items[j].seq })
+
+		sample := StratumSample{Stratum: stratum, Total: r.total}
+		for _, item := range items {
+			sample.Entries = append(sample.Entries, item.entry)
+		}
+		samples = append(samples, sample)
+	}
+	return samples
+}
+
+// shares allocates the sample between strata: one entry each for the largest
+// strata, then the rest proportionally to sizes by the largest remainders.
+func (s *Sampler) shares(strata []Stratum) map[Stratum]int {
+	bySize := append([]Stratum(nil), strata...)
+	sort.Slice(bySize, func(i, j int) bool {
+		a, b := s.strata[bySize[i]].total, s.strata[bySize[j]].total
+		if a != b {
+			return a > b
+		}
+		return strataLess(bySize[i], bySize[j])
+	})
+
+	shares := make(map[Stratum]int, len(strata))
+	left, total := s.size, 0
+	for _, stratum := range bySize {
+		if left == 0 {
+			break
+		}
+		shares[stratum] = 1
+		left--
+		total += s.strata[stratum].total - 1
+	}
+	if left == 0 || total == 0 {
+		return shares
+	}
+
+	type remainder struct {
+		stratum Stratum
+		value   float64
+	}
+	var remainders []remainder
+	allocated := 0
+	for _, stratum := range bySize {
+		rest := s.strata[stratum].total - 1
+		exact := float64(left) * float64(rest) / float64(total)
+		share := int(exact)
+		if share > rest {
+			share = rest
+		}
+		shares[stratum] += share
+		allocated += share
+		remainders = append(remainders, remainder{stratum, exact - float64(share)})
+	}
+	sort.SliceStable(remainders, func(i, j int) bool { return remainders[i].value > remainders[j].value })
+	for i := 0; allocated < left && i < len(remainders); i++ {
+		stratum := remainders[i].stratum
+		if shares[stratum] < s.strata[stratum].total && shares[stratum] < len(s.strata[stratum].items) {
+			shares[stratum]++
+			allocated++
+		}
+	}
+	return shares
+}
+
+func (s *Sampler) stratum(e Entry) Stratum {
+	stratum := Stratum{Band: priorityBand(e.GetPriority())}
+	u, err := url.Parse(e.GetLocation())
+	if err != nil {
+		return stratum
+	}
+	stratum.Host = strings.ToLower(u.Hostname())
+
+	if len(s.o.sections) > 0 {
+		for _, prefix := range s.o.sections {
+			if len(prefix) > len(stratum.Section) && strings.HasPrefix(u.Path, prefix) {
+				stratum.Section = prefix
+			}
+		}
+		return stratum
+	}
+	stratum.Section = "/"
+	if path := strings.TrimPrefix(u.Path, "/"); strings.Contains(path, "/") {
+		stratum.Section += path[:strings.Index(path, "/")+1]
+	}
+	return stratum
+}
+
+func priorityBand(priority float32) PriorityBand {
+	switch {
+	case priority >= 0.8:
+		return PriorityHigh
+	case priority >= 0.5:
+		return PriorityMedium
+	}
+	return PriorityLow
+}
+
+func bandOrder(band PriorityBand) int {
+	switch band {
+	case PriorityHigh:
+		return 0
+	case PriorityMedium:
+		return 1
+	}
+	return 2
+}
+
+func strataLess(a, b Stratum) bool {
+	if a.Host != b.Host {
+		return a.Host < b.Host
+	}
+	if a.Section != b.Section {
+		return a.Section < b.Section
+	}
+	return bandOrder(a.Band) < bandOrder(b.Band)
+}
@@ -0,0 +1,63 @@
+package sitemap
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestSampler(t *testing.T) {
+	sampler := NewSampler(10, WithSections("/blog/"))
+	add := func(count int, format string, priority float32) {
+		for i := 0; i < count; i++ {
+			sampler.Add(&sitemapEntry{Location: fmt.Sprintf(format, i), Priority: priority})
+		}
+	}
+	add(1000, "https://a.com/shop/%d", 0.5)
+	add(3, "https://a.com/blog/%d", 0.9)
+	add(50, "https://a.com/blog/old/%d", 0.1)
+	add(1, "https://B.com/blog/%d", 0.5)
+
+	var strata []Stratum
+	var totals, sizes []int
+	seen := make(map[string]bool)
+	for _, sample := range sampler.Sample() {
+		strata = append(strata, sample.Stratum)
+		totals = append(totals, sample.Total)
+		sizes = append(sizes, len(sample.Entries))
+		for _, e := range sample.Entries {
+			if seen[e.GetLocation()] {
+				t.Errorf("Entry %s is sampled twice", e.GetLocation())
+			}
+			seen[e.GetLocation()] = true
+		}
+	}
+
+	expected := []Stratum{
+		{"a.com", "", PriorityMedium},
+		{"a.com", "/blog/", PriorityHigh},
+		{"a.com", "/blog/", PriorityLow},
+		{"b.com", "/blog/", PriorityMedium},
+	}
+	if !reflect.DeepEqual(strata, expected) {
+		t.Errorf("Expected strata %v, but given %v", expected, strata)
+	}
+	if !reflect.DeepEqual(totals, []int{1000, 3, 50, 1}) || !reflect.DeepEqual(sizes, []int{7, 1, 1, 1}) {
+		t.Errorf("Unexpected totals %v and sizes %v of samples", totals, sizes)
+	}
+}
+
+func TestSampler_Sections(t *testing.T) {
+	sampler := NewSampler(2)
+	for _, location := range []string{"https://a.com/", "https://a.com/blog/1", "https://a.com/blog/2", "https://a.com/page", "https://a.com/blog/3"} {
+		sampler.Add(&sitemapEntry{Location: location, Priority: 0.5})
+	}
+
+	sample := sampler.Sample()
+	if len(sample) != 2 || sample[0].Section != "/" || sample[0].Total != 2 || sample[1].Section != "/blog/" || sample[1].Total != 3 {
+		t.Fatalf("Unexpected sample %+v", sample)
+	}
+	if len(sample[0].Entries) != 1 || len(sample[1].Entries) != 1 {
+		t.Errorf("Expected an entry of each stratum, but given %+v", sample)
+	}
+}
@@ -0,0 +1,186 @@
+package sitemap
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// day is the length of a day of windows.
+const day = 24 * time.Hour
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// Window is a daily time window when crawling is allowed.
+//
+// Start and End are offsets from midnight, a window with End before Start
+// crosses midnight, e.g. 22:00-02:00. Weekdays are days when the window starts,
+// any day if it is empty. Location is the time zone of the window, like the one
+// of the origin, UTC if it is nil.
+type Window struct {
+	Start    time.Duration
+	End      time.Duration
+	Weekdays []time.Weekday
+	Location *time.Location
+}
+
+// Schedule is a set of windows when crawling is allowed. An empty schedule
+// allows crawling at any time.
+type Schedule []Window
+
+// ParseWindow parses a window like "01:00-05:00" or "Mon-Fri 22:00-02:00" or
+// "Sat,Sun 00:00-24:00" in the location, which is UTC if it is nil.
+func ParseWindow(value string, location *time.Location) (Window, error) {
+	w := Window{Location: location}
+	fields := strings.Fields(value)
+	switch len(fields) {
+	case 1:
+	case 2:
+		days, err := parseWeekdays(fields[0])
+		if err != nil {
+			return w, err
+		}
+		w.Weekdays = days
+		fields = fields[1:]
+	default:
+		return w, fmt.Errorf("sitemap: invalid window %q", value)
+	}
+
+	bounds := strings.Split(fields[0], "-")
+	if len(bounds) != 2 {
+		return w, fmt.Errorf("sitemap: invalid window %q", value)
+	}
+	var err error
+	if w.Start, err = parseClock(bounds[0]); err != nil {
+		return w, fmt.Errorf("sitemap: invalid window %q: %v", value, err)
+	}
+	if w.End, err = parseClock(bounds[1]); err != nil {
+		return w, fmt.Errorf("sitemap: invalid window %q: %v", value, err)
+	}
+	return w, nil
+}
+
+// WithSchedule restricts downloads to windows of the schedule. Outside of them
+// requests wait until the next window opens, so crawling pauses automatically.
+// Time is taken from the clock set by WithClock.
+func WithSchedule(schedule Schedule) Option {
+	return func(o *options) {
+		o.schedule = schedule
+	}
+}
+
+// Next returns the time when the schedule allows crawling, it is the given
+// time if crawling is allowed at it.
+func (s Schedule) Next(t time.Time) time.Time {
+	if len(s) == 0 {
+		return t
+	}
+
+	var next time.Time
+	for _, w := range s {
+		if at := w.next(t); next.IsZero() || at.Before(next) {
+			next = at
+		}
+	}
+	return next
+}
+
+func (w Window) next(t time.Time) time.Time {
+	location := w.Location
+	if location == nil {
+		location = time.UTC
+	}
+	local := t.In(location)
+	end := w.End
+	if end <= w.Start {
+		end += day
+	}
+
+	var next time.Time
+	// a window of the previous day can still be open
+	for offset := -1; offset <= 7; offset++ {
+		midnight := time.Date(local.Year(), local.Month(), local.Day()+offset, 0, 0, 0, 0, location)
+		if !w.on(midnight.Weekday()) {
+			continue
+		}
+		start, stop := midnight.Add(w.Start), midnight.Add(end)
+		if !t.Before(start) && t.Before(stop) {
+			return t
+		}
+		if start.After(t) && (next.IsZero() || start.Before(next)) {
+			next = start
+		}
+	}
+	return next
+}
+
+func (w Window) on(weekday time.Weekday) bool {
+	if len(w.Weekdays) == 0 {
+		return true
+	}
+	for _, d := range w.Weekdays {
+		if d == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+// waitSchedule blocks until the schedule allows requests. The wait is computed
+// once from the clock of WithClock, so an injected clock which doesn't move
+// doesn't make it wait forever.
+func (o *options) waitSchedule(ctx context.Context) error {
+	now := o.now()
+	next := o.schedule.Next(now)
+	if !next.After(now) {
+		return nil
+	}
+
+	timer := time.NewTimer(next.Sub(now))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func parseWeekdays(value string) ([]time.Weekday, error) {
+	var days []time.Weekday
+	for _, part := range strings.Split(value, ",") {
+		bounds := strings.Split(strings.ToLower(part), "-")
+		first, ok := weekdays[bounds[0]]
+		last := first
+		if ok && len(bounds) == 2 {
+			last, ok = weekdays[bounds[1]]
+		}
+		if !ok || len(bounds) > 2 {
+			return nil, fmt.Errorf("sitemap: invalid weekdays %q", value)
+		}
+		for d := first; ; d = (d + 1) % 7 {
+			days = append(days, d)
+			if d == last {
+				break
+			}
+		}
+	}
+	return days, nil
+}
+
+// parseClock parses a time of a day like "05:30", "24:00" is the end of the day.
+func parseClock(value string) (time.Duration, error) {
+	var hours, minutes int
+	if _, err := fmt.Sscanf(value, "%d:%d", &hours, &minutes); err != nil {
+		return 0, err
+	}
+	clock := time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute
+	if hours < 0 || minutes < 0 || minutes > 59 || clock > day {
+		return 0, fmt.Errorf("invalid time %q", value)
+	}
+	return clock, nil
+}
@@ -0,0 +1,129 @@
+package sitemap
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSchedule_Next(t *testing.T) {
+	night, err := ParseWindow("22:00-02:00", nil)
+	if err != nil {
+		t.Fatalf("Parsing failed with error %s", err)
+	}
+	weekend, err := ParseWindow("Sat,Sun 10:00-12:00", nil)
+	if err != nil {
+		t.Fatalf("Parsing failed with error %s", err)
+	}
+	schedule := Schedule{night, weekend}
+
+	tests := []struct {
+		now, next string
+	}{
+		{"2019-01-04T23:00:00Z", "2019-01-04T23:00:00Z"}, // Friday, inside the night window
+		{"2019-01-05T01:00:00Z", "2019-01-05T01:00:00Z"}, // after midnight of the night window
+		{"2019-01-04T03:00:00Z", "2019-01-04T22:00:00Z"}, // Friday, waiting for the night
+		{"2019-01-05T03:00:00Z", "2019-01-05T10:00:00Z"}, // Saturday, the weekend window is earlier
+		{"2019-01-05T11:59:00Z", "2019-01-05T11:59:00Z"},
+	}
+	for _, test := range tests {
+		now, _ := time.Parse(time.RFC3339, test.now)
+		expected, _ := time.Parse(time.RFC3339, test.next)
+		if next := schedule.Next(now); !next.Equal(expected) {
+			t.Errorf("Expected %s at %s, but given %s", expected, now, next)
+		}
+	}
+}
+
+func TestParseWindow_Location(t *testing.T) {
+	location := time.FixedZone("UTC+3", 3*60*60)
+	w, err := ParseWindow("Mon-Fri 01:00-05:00", location)
+	if err != nil {
+		t.Fatalf("Parsing failed with error %s", err)
+	}
+	if len(w.Weekdays) != 5 || w.Weekdays[0] != time.Monday || w.Weekdays[4] != time.Friday {
+		t.Errorf("Unexpected weekdays %v", w.Weekdays)
+	}
+
+	now := time.Date(2019, 1, 6, 23, 0, 0, 0, time.UTC) // Monday 02:00 in UTC+3
+	if next := (Schedule{w}).Next(now); !next.Equal(now) {
+		t.Errorf("Expected the window to be open at %s, but given %s", now, next)
+	}
+
+	for _, value := range []string{"01:00", "Mon-Fri", "Mon-Fun 01:00-02:00", "01:00-25:00", "a b c"} {
+		if _, err := ParseWindow(value, nil); err == nil {
+			t.Errorf("Expected an error of %q", value)
+		}
+	}
+}
+
+func TestCrawler_Schedule(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprint(w, "<urlset><url><loc>http://HOST/</loc></url></urlset>")
+	}))
+	defer server.Close()
+
+	window, _ := ParseWindow("01:00-05:00", nil)
+	for _, test := range []struct {
+		clock    time.Time
+		requests int
+	}{
+		{time.Date(2019, 1, 1, 0, 30, 0, 0, time.UTC), 0},
+		{time.Date(2019, 1, 1, 2, 0, 0, 0, time.UTC), 1},
+	} {
+		requests = 0
+		clock := test.clock
+		crawler := NewCrawler(WithSchedule(Schedule{window}), WithClock(func() time.Time { return clock }))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		_, err := crawler.Crawl(ctx, server.URL, func(e Entry) error {
+			return nil
+		})
+		cancel()
+
+		if requests != test.requests {
+			t.Errorf("Expected %d requests at %s, but given %d", test.requests, clock, requests)
+		}
+		if (test.requests == 0) != (err == context.DeadlineExceeded) {
+			t.Errorf("Unexpected error %v at %s", err, clock)
+		}
+	}
+}
+
+func TestCrawler_ScheduleFixedClock(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprint(w, "<urlset><url><loc>http://HOST/</loc></url></urlset>")
+	}))
+	defer server.Close()
+
+	window, _ := ParseWindow("01:00-05:00", nil)
+	clock := time.Date(2019, 1, 1, 1, 0, 0, 0, time.UTC).Add(-20 * time.Millisecond)
+	crawler := NewCrawler(WithSchedule(Schedule{window}), WithClock(func() time.Time { return clock }))
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := crawler.Crawl(context.Background(), server.URL, func(e Entry) error {
+			return nil
+		})
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Crawling failed with error %s", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Crawling with fixed clock didn't finish")
+	}
+	if requests != 1 {
+		t.Errorf("Expected 1 request, but given %d", requests)
+	}
+}
@@ -0,0 +1,241 @@
+package sitemap
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxShards limits probing of open-ended numbered patterns, e.g. for servers
+// which respond to any path.
+const maxShards = 10000
+
+// commonShardTemplates are paths of common numbered sitemap names.
+var commonShardTemplates = []string{
+	"/sitemap{n}.xml",
+	"/sitemap-{n}.xml",
+	"/sitemap_{n}.xml",
+	"/sitemap{n}.xml.gz",
+	"/sitemap-{n}.xml.gz",
+	"/sitemaps/sitemap-{n}.xml",
+}
+
+// ShardPattern describes predictable names of sitemap shards of sites which
+// don't publish an index.
+//
+// Template is the URL of shards with a placeholder. "{n}" is a number from
+// From to To, "{n:3}" is the number padded by zeros to 3 digits. "{date}" is
+// a date from Since to Until in the 2006-01-02 format, "{date:200601}" has
+// the layout of the time package, dates step by days, or by months or years
+// if the layout has no days or months. A template has a single placeholder.
+//
+// From is 1 by default. Until is the current time by default.
+// Gaps is the count of consecutive missed shards which probing of numbered
+// shards tolerates, by default it stops at the first missed one.
+type ShardPattern struct {
+	Template string
+	From     int
+	To       int
+	Since    time.Time
+	Until    time.Time
+	Gaps     int
+}
+
+// placeholder is a parsed placeholder of a template.
+type placeholder struct {
+	prefix, suffix string
+	date           bool
+	width          int
+	layout         string
+}
+
+func (p *ShardPattern) parse() (*placeholder, error) {
+	start := strings.IndexByte(p.Template, '{')
+	end := strings.IndexByte(p.Template, '}')
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("sitemap: template %q has no placeholder", p.Template)
+	}
+	if strings.ContainsAny(p.Template[end+1:], "{}") {
+		return nil, fmt.Errorf("sitemap: template %q has several placeholders", p.Template)
+	}
+
+	ph := &placeholder{prefix: p.Template[:start], suffix: p.Template[end+1:]}
+	name, arg := p.Template[start+1:end], ""
+	if i := strings.IndexByte(name, ':'); i >= 0 {
+		name, arg = name[:i], name[i+1:]
+	}
+	switch name {
+	case "n":
+		if arg != "" {
+			width, err := strconv.Atoi(arg)
+			if err != nil || width <= 0 {
+				return nil, fmt.Errorf("sitemap: invalid width of placeholder of template %q", p.Template)
+			}
+			ph.width = width
+		}
+	case "date":
+		ph.date, ph.layout = true, arg
+		if ph.layout == "" {
+			ph.layout = "2006-01-02"
+		}
+		if p.Since.IsZero() {
+			return nil, fmt.Errorf("sitemap: template %q requires Since", p.Template)
+		}
+	default:
+		return nil, fmt.Errorf("sitemap: unknown placeholder %q of template %q", name, p.Template)
+	}
+	return ph, nil
+}
+
+func (ph *placeholder) number(n int) string {
+	return ph.prefix + fmt.Sprintf("%0*d", ph.width, n) + ph.suffix
+}
+
+// dates returns names of dates of the range in order.
+func (ph *placeholder) dates(since, until time.Time) []string {
+	day := time.Date(2001, 2, 3, 0, 0, 0, 0, time.UTC)
+	step := func(t time.Time) time.Time { return t.AddDate(1, 0, 0) }
+	switch {
+	case day.Format(ph.layout) != day.AddDate(0, 0, 1).Format(ph.layout):
+		step = func(t time.Time) time.Time { return t.AddDate(0, 0, 1) }
+	case day.Format(ph.layout) != day.AddDate(0, 1, 0).Format(ph.layout):
+		step = func(t time.Time) time.Time { return t.AddDate(0, 1, 0) }
+	}
+
+	var names []string
+	seen := make(map[string]bool)
+	for t := since; !t.After(until); t = step(t) {
+		name := ph.prefix + t.Format(ph.layout) + ph.suffix
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func (p *ShardPattern) from() int {
+	if p.From == 0 {
+		return 1
+	}
+	return p.From
+}
+
+func (p *ShardPattern) until() time.Time {
+	if p.Until.IsZero() {
+		return time.Now()
+	}
+	return p.Until
+}
+
+// Expand returns URLs of all shards of the pattern in order. Numbered patterns
+// require To.
+func (p ShardPattern) Expand() ([]string, error) {
+	ph, err := p.parse()
+	if err != nil {
+		return nil, err
+	}
+	if ph.date {
+		return ph.dates(p.Since, p.until()), nil
+	}
+
+	if p.To < p.from() {
+		return nil, fmt.Errorf("sitemap: template %q requires To", p.Template)
+	}
+	var urls []string
+	for n := p.from(); n <= p.To; n++ {
+		urls = append(urls, ph.number(n))
+	}
+	return urls, nil
+}
+
+// ProbeShards probes shards of the pattern and returns URLs of existing ones
+// in order. Numbered shards are probed from From until To or until more than
+// Gaps consecutive shards are missed, dated shards are probed for all dates of
+// the range. A shard exists if it is responded with a 2xx status and isn't an
+// HTML page. HEAD requests are used, with a fallback to GET if the server
+// doesn't allow them.
+func ProbeShards(pattern ShardPattern, opts ...Option) ([]string, error) {
+	ph, err := pattern.parse()
+	if err != nil {
+		return nil, err
+	}
+	o := newOptions(opts)
+	ctx := context.Background()
+
+	var found []string
+	if ph.date {
+		for _, location := range ph.dates(pattern.Since, pattern.until()) {
+			exists, err := o.probe(ctx, location)
+			if err != nil {
+				return found, err
+			}
+			if exists {
+				found = append(found, location)
+			}
+		}
+		return found, nil
+	}
+
+	last := pattern.To
+	if last <= 0 {
+		last = pattern.from() + maxShards - 1
+	}
+	missed := 0
+	for n := pattern.from(); n <= last && missed <= pattern.Gaps; n++ {
+		location := ph.number(n)
+		exists, err := o.probe(ctx, location)
+		if err != nil {
+			return found, err
+		}
+		if exists {
+			found = append(found, location)
+			missed = 0
+		} else {
+			missed++
+		}
+	}
+	return found, nil
+}
+
+// DiscoverShards probes common numbered names of sitemaps of the site, like
+// /sitemap1.xml, /sitemap-1.xml or /sitemap_1.xml.gz, by ProbeShards and returns
+// URLs of existing shards. Patterns which have no first shard are skipped.
+func DiscoverShards(siteURL string, opts ...Option) ([]string, error) {
+	base, err := url.Parse(siteURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var found []string
+	for _, template := range commonShardTemplates {
+		pattern := ShardPattern{Template: base.Scheme + "://" + base.Host + template}
+		shards, err := ProbeShards(pattern, opts...)
+		if err != nil {
+			return found, err
+		}
+		found = append(found, shards...)
+	}
+	return found, nil
+}
+
+// probe reports whether the document exists.
+func (o *options) probe(ctx context.Context, location string) (bool, error) {
+	res, err := o.do(ctx, http.MethodHead, location)
+	if err == nil && (res.StatusCode == http.StatusMethodNotAllowed || res.StatusCode == http.StatusNotImplemented) {
+		res.Body.Close()
+		res, err = o.do(ctx, http.MethodGet, location)
+	}
+	if err != nil {
+		return false, err
+	}
+	res.Body.Close()
+
+	contentType, _, _ := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	return isSuccess(res) && contentType != "text/html", nil
+}
@@ -0,0 +1,91 @@
+package sitemap
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestShardPattern_Expand(t *testing.T) {
+	tests := []struct {
+		pattern  ShardPattern
+		expected string
+	}{
+		{ShardPattern{Template: "/sitemap-{n}.xml", To: 3}, "/sitemap-1.xml /sitemap-2.xml /sitemap-3.xml"},
+		{ShardPattern{Template: "/sitemap-{n:3}.xml", From: 9, To: 10}, "/sitemap-009.xml /sitemap-010.xml"},
+		{ShardPattern{Template: "/{date}.xml", Since: time.Date(2020, 2, 28, 0, 0, 0, 0, time.UTC),
+			Until: time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC)}, "/2020-02-28.xml /2020-02-29.xml /2020-03-01.xml"},
+		{ShardPattern{Template: "/posts-{date:2006-01}.xml", Since: time.Date(2019, 11, 15, 0, 0, 0, 0, time.UTC),
+			Until: time.Date(2020, 1, 20, 0, 0, 0, 0, time.UTC)}, "/posts-2019-11.xml /posts-2019-12.xml /posts-2020-01.xml"},
+	}
+	for _, test := range tests {
+		urls, err := test.pattern.Expand()
+		if err != nil {
+			t.Errorf("Expanding of %s failed with error %s", test.pattern.Template, err)
+			continue
+		}
+		if strings.Join(urls, " ") != test.expected {
+			t.Errorf("Expected %s, but given %v", test.expected, urls)
+		}
+	}
+
+	for _, pattern := range []ShardPattern{
+		{Template: "/sitemap.xml"},
+		{Template: "/sitemap-{n}.xml"},
+		{Template: "/sitemap-{n}-{n}.xml", To: 2},
+		{Template: "/sitemap-{date}.xml"},
+		{Template: "/sitemap-{id}.xml"},
+	} {
+		if _, err := pattern.Expand(); err == nil {
+			t.Errorf("Expected error of template %s", pattern.Template)
+		}
+	}
+}
+
+func TestProbeShards(t *testing.T) {
+	existing := map[string]bool{"/sitemap1.xml": true, "/sitemap2.xml": true, "/sitemap4.xml": true,
+		"/sitemap-1.xml.gz": true, "/news-2020-01-02.xml": true}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/sitemap-1.xml" {
+			w.Header().Set("Content-Type", "text/html")
+			return
+		}
+		if !existing[r.URL.Path] {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/xml")
+	}))
+	defer server.Close()
+
+	shards, err := ProbeShards(ShardPattern{Template: server.URL + "/sitemap{n}.xml"})
+	if err != nil {
+		t.Fatalf("Probing failed with error %s", err)
+	}
+	if !reflect.DeepEqual(shards, []string{server.URL + "/sitemap1.xml", server.URL + "/sitemap2.xml"}) {
+		t.Errorf("Unexpected shards %v", shards)
+	}
+
+	shards, err = ProbeShards(ShardPattern{Template: server.URL + "/sitemap{n}.xml", Gaps: 1})
+	if err != nil || len(shards) != 3 {
+		t.Errorf("Expected 3 shards with a gap, but given %v and error %v", shards, err)
+	}
+
+	shards, err = ProbeShards(ShardPattern{Template: server.URL + "/news-{date}.xml",
+		Since: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), Until: time.Date(2020, 1, 5, 0, 0, 0, 0, time.UTC)})
+	if err != nil || !reflect.DeepEqual(shards, []string{server.URL + "/news-2020-01-02.xml"}) {
+		t.Errorf("Unexpected dated shards %v and error %v", shards, err)
+	}
+
+	shards, err = DiscoverShards(server.URL + "/")
+	if err != nil {
+		t.Fatalf("Discovering failed with error %s", err)
+	}
+	expected := []string{server.URL + "/sitemap1.xml", server.URL + "/sitemap2.xml", server.URL + "/sitemap-1.xml.gz"}
+	if !reflect.DeepEqual(shards, expected) {
+		t.Errorf("Expected %v, but given %v", expected, shards)
+	}
+}
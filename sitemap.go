@@ -3,10 +3,8 @@
 package sitemap
 
 import (
-	"crypto/tls"
-	"encoding/xml"
+	"context"
 	"io"
-	"net/http"
 	"os"
 	"time"
 )
@@ -50,6 +48,93 @@ type Entry interface {
 	GetPriority() float32
 }
 
+// ExtendedEntry is an interface describes an element \ an URL in the sitemap file
+// with Google sitemap extensions. Each Entry passed to EntryConsumer implements it,
+// so you can get extensions by a type assertion.
+//
+// GetImages returns images of the page from image:image elements.
+// GetImages returns nil if the page has no images.
+//
+// GetVideos returns videos of the page from video:video elements.
+// GetVideos returns nil if the page has no videos.
+//
+// GetNews returns news article metadata from news:news element.
+// GetNews returns nil if the page isn't a news article.
+//
+// GetAlternates returns language and regional versions of the page from
+// xhtml:link rel="alternate" elements.
+// GetAlternates returns nil if the page has no alternates.
+//
+// You shouldn't implement this interface in your types.
+type ExtendedEntry interface {
+	Entry
+	GetImages() []Image
+	GetVideos() []Video
+	GetNews() *News
+	GetAlternates() []Alternate
+}
+
+// PresenceProvider is an interface of entries which report whether their
+// change frequency and priority are in the sitemap. Getters of Entry return
+// defaults for absent values, so writers use it to pass documents through
+// without adding tags. Each Entry passed to EntryConsumer implements it.
+//
+// You shouldn't implement this interface in your types.
+type PresenceProvider interface {
+	HasChangeFrequency() bool
+	HasPriority() bool
+}
+
+// Optional interfaces of entries. Each Entry passed to EntryConsumer implements
+// all of them and each IndexEntry implements RawLastModifiedProvider, so consumers can get extras by a type assertion of a single
+// capability without depending on the whole ExtendedEntry. Unlike Entry, you
+// can implement them in your types, e.g. for entries made by transforms.
+//
+// ImagesProvider returns images of the page, see ExtendedEntry.GetImages.
+// VideosProvider returns videos of the page, see ExtendedEntry.GetVideos.
+// NewsProvider returns news metadata of the page, see ExtendedEntry.GetNews.
+// AlternatesProvider returns alternates of the page, see ExtendedEntry.GetAlternates.
+//
+// RawProvider returns XML of the element of the entry as it is in the sitemap,
+// e.g. to read extensions which aren't supported. GetRaw returns nil unless
+// WithRawEntries option is set and for entries of plain text sitemaps.
+//
+// MetadataProvider returns custom metadata attached by AttachMetadata, it
+// returns nil if there is none. Don't change the returned map.
+//
+// InheritedProvider reports whether the lastmod of the entry is inherited from
+// the parent index, see WithInheritedLastModified.
+//
+// RawLastModifiedProvider returns lastmod value as it is in the sitemap, so it
+// can be parsed by your own rules. It returns an empty string if there is no
+// lastmod.
+type (
+	ImagesProvider interface {
+		GetImages() []Image
+	}
+	VideosProvider interface {
+		GetVideos() []Video
+	}
+	NewsProvider interface {
+		GetNews() *News
+	}
+	AlternatesProvider interface {
+		GetAlternates() []Alternate
+	}
+	RawProvider interface {
+		GetRaw() []byte
+	}
+	MetadataProvider interface {
+		GetMetadata() map[string]interface{}
+	}
+	InheritedProvider interface {
+		IsLastModifiedInherited() bool
+	}
+	RawLastModifiedProvider interface {
+		GetLastModifiedRaw() string
+	}
+)
+
 // IndexEntry is an interface describes an element \ an URL in a sitemap index file.
 // Keep in mind. It is implemented by a totally immutable entity so you should
 // minimize calls count because it can produce additional memory allocations.
@@ -71,45 +156,43 @@ type IndexEntry interface {
 type EntryConsumer func(Entry) error
 
 // Parse parses data which provides by the reader and for each sitemap
-// entry calls the consumer's function.
-func Parse(reader io.Reader, consumer EntryConsumer) error {
-	return parseLoop(reader, func(d *xml.Decoder, se *xml.StartElement) error {
-		return entryParser(d, se, consumer)
-	})
+// entry calls the consumer's function. Besides XML sitemaps it parses plain
+// text sitemaps and RSS / Atom feeds, see WithFormat.
+func Parse(reader io.Reader, consumer EntryConsumer, opts ...Option) error {
+	return parseDocument(reader, newOptions(opts), consumer, nil)
 }
 
 // ParseFromFile reads sitemap from a file, parses it and for each sitemap
 // entry calls the consumer's function.
-func ParseFromFile(sitemapPath string, consumer EntryConsumer) error {
+func ParseFromFile(sitemapPath string, consumer EntryConsumer, opts ...Option) error {
 	sitemapFile, err := os.OpenFile(sitemapPath, os.O_RDONLY, os.ModeExclusive)
 	if err != nil {
 		return err
 	}
 	defer sitemapFile.Close()
 
-	return Parse(sitemapFile, consumer)
+	return withSource(Parse(sitemapFile, consumer, opts...), sitemapPath)
 }
 
 // ParseFromSite downloads sitemap from a site, parses it and for each sitemap
-// entry calls the consumer's function.
-func ParseFromSite(url string, consumer EntryConsumer) error {
-	tr := &http.Transport{
-		TLSClientConfig: &tls.Config{
-			InsecureSkipVerify: true,
-		},
-	}
-
-	client := &http.Client{
-		Transport: tr,
-	}
-	
-	res, err := client.Get(url)
+// entry calls the consumer's function. Unless a client is set by WithHTTPClient,
+// TLS certificates of the site aren't verified. See WithRetry and WithProxies
+// to configure downloading and WithConditional to skip unchanged sitemaps.
+// Non-2xx statuses are returned as HTTPError and HTML pages as ErrNotSitemap,
+// error and captcha pages of 2xx statuses as SoftError.
+func ParseFromSite(url string, consumer EntryConsumer, opts ...Option) error {
+	// The full slice expression makes append copy, so caller's options aren't touched.
+	o := newOptions(append(opts[:len(opts):len(opts)], insecureTLS))
+	res, err := o.getConditional(context.Background(), url)
 	if err != nil {
 		return err
 	}
 	defer res.Body.Close()
 
-	return Parse(res.Body, consumer)
+	if err = Parse(res.Body, consumer, append([]Option{WithSitemapURL(url)}, opts...)...); err != nil {
+		return withSource(err, url)
+	}
+	return o.storeValidators(url, res)
 }
 
 // IndexEntryConsumer is a type represents consumer of parsed sitemaps indexes entries
@@ -118,9 +201,12 @@ type IndexEntryConsumer func(IndexEntry) error
 // ParseIndex parses data which provides by the reader and for each sitemap index
 // entry calls the consumer's function.
 func ParseIndex(reader io.Reader, consumer IndexEntryConsumer) error {
-	return parseLoop(reader, func(d *xml.Decoder, se *xml.StartElement) error {
-		return indexEntryParser(d, se, consumer)
-	})
+	return parseIndex(reader, consumer, newOptions(nil))
+}
+
+// parseIndex parses a sitemap index like ParseIndex does, but with the options.
+func parseIndex(reader io.Reader, consumer IndexEntryConsumer, o *options) error {
+	return parseDocument(reader, o, nil, consumer)
 }
 
 // ParseIndexFromFile reads sitemap index from a file, parses it and for each sitemap
@@ -132,17 +218,23 @@ func ParseIndexFromFile(sitemapPath string, consumer IndexEntryConsumer) error {
 	}
 	defer sitemapFile.Close()
 
-	return ParseIndex(sitemapFile, consumer)
+	return withSource(ParseIndex(sitemapFile, consumer), sitemapPath)
 }
 
 // ParseIndexFromSite downloads sitemap index from a site, parses it and for each sitemap
-// index entry calls the consumer's function.
-func ParseIndexFromSite(sitemapURL string, consumer IndexEntryConsumer) error {
-	res, err := http.Get(sitemapURL)
+// index entry calls the consumer's function. See WithRetry and WithProxies
+// to configure downloading and WithConditional to skip unchanged indexes.
+// The options apply to parsing too, e.g. WithLimits and WithDateLayouts.
+func ParseIndexFromSite(sitemapURL string, consumer IndexEntryConsumer, opts ...Option) error {
+	o := newOptions(append([]Option{WithSitemapURL(sitemapURL)}, opts...))
+	res, err := o.getConditional(context.Background(), sitemapURL)
 	if err != nil {
 		return err
 	}
 	defer res.Body.Close()
 
-	return ParseIndex(res.Body, consumer)
+	if err = parseIndex(res.Body, consumer, o); err != nil {
+		return withSource(err, sitemapURL)
+	}
+	return o.storeValidators(sitemapURL, res)
 }
\ No newline at end of file
@@ -0,0 +1,82 @@
+package sitemap
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"runtime"
+	"testing"
+)
+
+// generatedSitemap is a reader of a sitemap of the given size which is
+// generated on the fly, so it doesn't occupy memory itself.
+type generatedSitemap struct {
+	size    int
+	written int
+	index   int
+	buf     bytes.Buffer
+	done    bool
+}
+
+func newGeneratedSitemap(size int) *generatedSitemap {
+	g := &generatedSitemap{size: size}
+	g.buf.WriteString(urlsetHeader)
+	return g
+}
+
+func (g *generatedSitemap) Read(p []byte) (int, error) {
+	for g.buf.Len() < len(p) && !g.done {
+		if g.written+g.buf.Len() >= g.size {
+			g.buf.WriteString(urlsetFooter)
+			g.done = true
+			break
+		}
+		g.index++
+		fmt.Fprintf(&g.buf, "  <url>\n    <loc>http://HOST/page-%d/</loc>\n    <lastmod>2015-05-07T19:13:09+09:00</lastmod>\n    <priority>0.5</priority>\n  </url>\n", g.index)
+	}
+
+	if g.buf.Len() == 0 {
+		return 0, io.EOF
+	}
+	n, _ := g.buf.Read(p)
+	g.written += n
+	return n, nil
+}
+
+func benchmarkParse(b *testing.B, size int) {
+	b.ReportAllocs()
+	b.SetBytes(int64(size))
+
+	var peak uint64
+	var stats runtime.MemStats
+	for i := 0; i < b.N; i++ {
+		count := 0
+		err := Parse(newGeneratedSitemap(size), func(e Entry) error {
+			count++
+			if count%1000 == 0 {
+				runtime.ReadMemStats(&stats)
+				if stats.HeapInuse > peak {
+					peak = stats.HeapInuse
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	b.ReportMetric(float64(peak)/(1024*1024), "peak-heap-MB")
+}
+
+func BenchmarkParse_1MB(b *testing.B) {
+	benchmarkParse(b, 1024*1024)
+}
+
+func BenchmarkParse_50MB(b *testing.B) {
+	benchmarkParse(b, 50*1024*1024)
+}
+
+func BenchmarkParse_200MB(b *testing.B) {
+	benchmarkParse(b, 200*1024*1024)
+}
@@ -11,7 +11,7 @@ func entryParser(decoder *xml.Decoder, se *xml.StartElement, consume EntryConsum
 	if se.Name.Local == "url" {
 		entry := newSitemapEntry()
 
-		decodeError := decoder.DecodeElement(entry, se)
+		decodeError := entry.decode(decoder, se)
 		if decodeError != nil {
 			return decodeError
 		}
@@ -45,9 +45,67 @@ func indexEntryParser(decoder *xml.Decoder, se *xml.StartElement, consume IndexE
 
 type elementParser func(*xml.Decoder, *xml.StartElement) error
 
+// parseDocument parses a document of any supported format. Entries are passed
+// to the consume, index entries are passed to the consumeIndex, any of them can be nil.
+func parseDocument(reader io.Reader, o *options, consume EntryConsumer, consumeIndex IndexEntryConsumer) error {
+	err := parseDocumentAt(reader, o, o.sitemapURL, consume, consumeIndex)
+	o.progress.flush()
+	return err
+}
+
+// parseDocumentAt parses a document like parseDocument does, relative locations
+// are resolved against the base URL if the normalization is enabled.
+func parseDocumentAt(reader io.Reader, o *options, base string, consume EntryConsumer, consumeIndex IndexEntryConsumer) error {
+	reader = o.limitDocument(o.progress.reader(reader))
+	reader, err := o.prepare(reader)
+	if err != nil {
+		return err
+	}
+	format := o.format
+	if format == FormatAuto {
+		var text bool
+		if text, reader = sniffText(reader); text {
+			format = FormatText
+		}
+	}
+
+	state := newParseState(o, base)
+	consume = state.entryConsumer(consume)
+	consumeIndex = state.indexConsumer(consumeIndex)
+
+	if format == FormatText {
+		if consume == nil {
+			return nil
+		}
+		return state.result(parseText(reader, consume))
+	}
+
+	reader = o.limitElements(reader)
+	if o.rawEntries {
+		state.recorder = newRawRecorder(reader)
+		reader = state.recorder
+	}
+
+	var parser elementParser
+	return state.result(parseLoop(reader, func(d *xml.Decoder, se *xml.StartElement) error {
+		if parser == nil {
+			parser = rootParser(format, se.Name.Local, consume, consumeIndex)
+		}
+		state.recorder.begin()
+		defer state.recorder.mark()
+		return parser(d, se)
+	}))
+}
+
 func parseLoop(reader io.Reader, parser elementParser) error {
 	decoder := xml.NewDecoder(reader)
 	decoder.CharsetReader = charset.NewReaderLabel
+	if recorder, ok := reader.(*rawRecorder); ok {
+		decoder.CharsetReader = func(label string, input io.Reader) (io.Reader, error) {
+			recorder.disabled = true
+			return charset.NewReaderLabel(label, input)
+		}
+	}
 
 	for {
 		t, tokenError := decoder.Token()
@@ -55,7 +113,7 @@ func parseLoop(reader io.Reader, parser elementParser) error {
 		if tokenError == io.EOF {
 			break
 		} else if tokenError != nil {
-			return tokenError
+			return newParseError(decoder, tokenError)
 		}
 
 		se, ok := t.(xml.StartElement)
@@ -64,8 +122,10 @@ func parseLoop(reader io.Reader, parser elementParser) error {
 		}
 
 		parserError := parser(decoder, &se)
-		if parserError != nil {
+		if _, ok := parserError.(consumerError); ok {
 			return parserError
+		} else if parserError != nil {
+			return newParseError(decoder, parserError)
 		}
 	}
 
@@ -125,6 +125,96 @@ func TestParseSitemapIndex(t *testing.T) {
 	}
 }
 
+func TestParseSitemap_Extensions(t *testing.T) {
+	var sb strings.Builder
+	err := ParseFromFile("./testdata/sitemap-extensions.xml", func(e Entry) error {
+		ext, ok := e.(ExtendedEntry)
+		if !ok {
+			t.Fatal("Entry doesn't implement ExtendedEntry")
+		}
+
+		fmt.Fprintln(&sb, ext.GetLocation())
+		for _, image := range ext.GetImages() {
+			fmt.Fprintln(&sb, "image", image.Location, image.Caption)
+		}
+		for _, video := range ext.GetVideos() {
+			fmt.Fprintln(&sb, "video", video.Title, video.ContentLocation, video.Duration, video.Tags)
+			fmt.Fprintln(&sb, "video", video.PublicationTime().Format(time.RFC3339))
+		}
+		if news := ext.GetNews(); news != nil {
+			fmt.Fprintln(&sb, "news", news.PublicationName, news.PublicationLanguage, news.Title)
+			fmt.Fprintln(&sb, "news", news.PublicationTime().Format(time.RFC3339))
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		t.Errorf("Parsing failed with error %s", err)
+	}
+
+	expected, err := ioutil.ReadFile("./testdata/sitemap-extensions.golden")
+	if err != nil {
+		t.Errorf("Can't read golden file due to %s", err)
+	}
+
+	if sb.String() != string(expected) {
+		t.Errorf("Unxepected result\n%s", sb.String())
+	}
+}
+
+func TestParseSitemap_Formats(t *testing.T) {
+	tests := []struct {
+		path     string
+		format   Format
+		expected string
+	}{
+		{"./testdata/sitemap.txt", FormatAuto, "http://HOST/ http://HOST/tools/ http://HOST/page-1/"},
+		{"./testdata/sitemap.xml", FormatText, "<?xml"},
+		{"./testdata/feed.rss", FormatAuto, "http://HOST/tools/ 2015-05-07T19:13:09+09:00"},
+		{"./testdata/feed.atom", FormatAuto, "http://HOST/tools/ 2015-05-07T19:13:09+09:00"},
+		{"./testdata/feed.atom", FormatRSS, ""},
+	}
+
+	for _, test := range tests {
+		var result []string
+		err := ParseFromFile(test.path, func(e Entry) error {
+			result = append(result, e.GetLocation())
+			if lastmod := e.GetLastModified(); lastmod != nil {
+				result = append(result, lastmod.Format(time.RFC3339))
+			}
+			return nil
+		}, WithFormat(test.format))
+
+		if err != nil {
+			t.Errorf("Parsing of %s failed with error %s", test.path, err)
+		}
+		if !strings.HasPrefix(strings.Join(result, " "), test.expected) {
+			t.Errorf("Unexpected result of %s as %q: %v", test.path, test.format, result)
+		}
+	}
+}
+
+func TestParseSitemap_ParseError(t *testing.T) {
+	sitemap := "<urlset>\n<url><loc>http://HOST/</loc></url>\n<url><loc>http://HOST/tools/</loc></url>\n<url><loc>broken</url>\n</urlset>"
+	err := Parse(strings.NewReader(sitemap), func(e Entry) error {
+		return nil
+	})
+
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("Expected ParseError, but given %v", err)
+	}
+	if parseErr.Line != 4 || parseErr.LastLocation != "http://HOST/tools/" || parseErr.Offset == 0 {
+		t.Errorf("Unexpected error details %+v", parseErr)
+	}
+
+	err = ParseFromFile("./testdata/sitemap-broken.xml", func(e Entry) error { return nil })
+	if !errors.As(err, &parseErr) || parseErr.Source != "./testdata/sitemap-broken.xml" {
+		t.Errorf("Expected ParseError with source, but given %v", err)
+	}
+}
+
 /*
  * Private API tests
  */
@@ -139,3 +229,55 @@ func TestParseShortDateTime(t *testing.T) {
 		t.Errorf("Date was parsed wrong %s", res.Format(time.RFC3339))
 	}
 }
+
+func TestParseDateTime_Variants(t *testing.T) {
+	expected := time.Date(2019, 3, 1, 10, 30, 0, 0, time.UTC)
+	values := []string{
+		"2019-03-01T10:30:00Z",
+		"2019-03-01T13:30:00+03:00",
+		"2019-03-01T10:30:00.000Z",
+		"2019-03-01T13:30+03:00",
+		"2019-03-01T13:30:00+0300",
+		"2019-03-01T10:30:00",
+		"2019-03-01 10:30:00",
+		" 2019-03-01T10:30:00Z ",
+		"Fri, 01 Mar 2019 10:30:00 +0000",
+		"Fri, 1 Mar 2019 10:30:00 GMT",
+		"1551436200",
+	}
+
+	for _, value := range values {
+		res := parseDateTime(value)
+		if res == nil || !res.Equal(expected) {
+			t.Errorf("Date time %q was parsed wrong %v", value, res)
+		}
+	}
+
+	if res := parseDateTime("2019-03"); res == nil || res.Month() != 3 {
+		t.Errorf("Month was parsed wrong %v", res)
+	}
+	if res := parseDateTime("yesterday"); res != nil {
+		t.Errorf("Expected nil for invalid date time, but given %v", res)
+	}
+}
+
+func TestParse_DateLayouts(t *testing.T) {
+	data := "<urlset><url><loc>http://example.com/</loc><lastmod>01.03.2019</lastmod></url></urlset>"
+
+	var raw string
+	var lastmod *time.Time
+	err := Parse(strings.NewReader(data), func(e Entry) error {
+		raw, lastmod = e.(RawLastModifiedProvider).GetLastModifiedRaw(), e.GetLastModified()
+		return nil
+	}, WithDateLayouts("02.01.2006"))
+	if err != nil {
+		t.Fatalf("Parsing failed with error %s", err)
+	}
+
+	if raw != "01.03.2019" {
+		t.Errorf("Expected raw lastmod 01.03.2019, but given %q", raw)
+	}
+	if lastmod == nil || !lastmod.Equal(time.Date(2019, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected lastmod 2019-03-01, but given %v", lastmod)
+	}
+}
@@ -1,6 +1,12 @@
 package sitemap
 
-import "time"
+import (
+	"encoding/xml"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
 
 type sitemapEntry struct {
 	Location           string `xml:"loc"`
@@ -8,23 +14,71 @@ type sitemapEntry struct {
 	ParsedLastModified *time.Time
 	ChangeFrequency    Frequency `xml:"changefreq,omitempty"`
 	Priority           float32   `xml:"priority,omitempty"`
+	Images             []Image   `xml:"image,omitempty"`
+	Videos             []Video   `xml:"video,omitempty"`
+	News               *News     `xml:"news,omitempty"`
+	Links              []link    `xml:"link,omitempty"`
+
+	layouts  []string
+	label    string
+	raw      []byte
+	metadata map[string]interface{}
+	// inherited reports whether ParsedLastModified is inherited from the index.
+	inherited bool
+	// hasChangeFrequency and hasPriority report whether values are in the
+	// source, otherwise they are defaults.
+	hasChangeFrequency bool
+	hasPriority        bool
+}
+
+// link is a xhtml:link element of an URL.
+type link struct {
+	Rel      string `xml:"rel,attr"`
+	Hreflang string `xml:"hreflang,attr"`
+	Href     string `xml:"href,attr"`
+}
+
+func (l *link) isAlternate() bool {
+	return strings.EqualFold(l.Rel, "alternate") && l.Hreflang != "" && l.Href != ""
 }
 
 func newSitemapEntry() *sitemapEntry {
+	if e, ok := sitemapEntryPool.Get().(*sitemapEntry); ok {
+		*e = sitemapEntry{ChangeFrequency: Always, Priority: 0.5}
+		return e
+	}
 	return &sitemapEntry{ChangeFrequency: Always, Priority: 0.5}
 }
 
+// decode decodes the url element and records which optional values it has,
+// absent ones keep the defaults.
+func (e *sitemapEntry) decode(decoder *xml.Decoder, se *xml.StartElement) error {
+	e.ChangeFrequency, e.Priority = "", float32(math.NaN())
+	err := decoder.DecodeElement(e, se)
+	if e.hasChangeFrequency = e.ChangeFrequency != ""; !e.hasChangeFrequency {
+		e.ChangeFrequency = Always
+	}
+	if e.hasPriority = !math.IsNaN(float64(e.Priority)); !e.hasPriority {
+		e.Priority = 0.5
+	}
+	return err
+}
+
 func (e *sitemapEntry) GetLocation() string {
 	return e.Location
 }
 
 func (e *sitemapEntry) GetLastModified() *time.Time {
 	if e.ParsedLastModified == nil && e.LastModified != "" {
-		e.ParsedLastModified = parseDateTime(e.LastModified)
+		e.ParsedLastModified = parseDateTimeLayouts(e.LastModified, e.layouts)
 	}
 	return e.ParsedLastModified
 }
 
+func (e *sitemapEntry) GetLastModifiedRaw() string {
+	return e.LastModified
+}
+
 func (e *sitemapEntry) GetChangeFrequency() Frequency {
 	return e.ChangeFrequency
 }
@@ -33,10 +87,58 @@ func (e *sitemapEntry) GetPriority() float32 {
 	return e.Priority
 }
 
+func (e *sitemapEntry) GetImages() []Image {
+	return e.Images
+}
+
+func (e *sitemapEntry) GetVideos() []Video {
+	return e.Videos
+}
+
+func (e *sitemapEntry) GetNews() *News {
+	return e.News
+}
+
+func (e *sitemapEntry) GetAlternates() []Alternate {
+	var alternates []Alternate
+	for _, l := range e.Links {
+		if l.isAlternate() {
+			alternates = append(alternates, Alternate{Hreflang: l.Hreflang, Href: l.Href})
+		}
+	}
+	return alternates
+}
+
+func (e *sitemapEntry) GetLabel() string {
+	return e.label
+}
+
+func (e *sitemapEntry) GetRaw() []byte {
+	return e.raw
+}
+
+func (e *sitemapEntry) GetMetadata() map[string]interface{} {
+	return e.metadata
+}
+
+func (e *sitemapEntry) IsLastModifiedInherited() bool {
+	return e.inherited
+}
+
+func (e *sitemapEntry) HasChangeFrequency() bool {
+	return e.hasChangeFrequency
+}
+
+func (e *sitemapEntry) HasPriority() bool {
+	return e.hasPriority
+}
+
 type sitemapIndexEntry struct {
 	Location           string `xml:"loc"`
 	LastModified       string `xml:"lastmod,omitempty"`
 	ParsedLastModified *time.Time
+
+	layouts []string
 }
 
 func newSitemapIndexEntry() *sitemapIndexEntry {
@@ -49,25 +151,68 @@ func (e *sitemapIndexEntry) GetLocation() string {
 
 func (e *sitemapIndexEntry) GetLastModified() *time.Time {
 	if e.ParsedLastModified == nil && e.LastModified != "" {
-		e.ParsedLastModified = parseDateTime(e.LastModified)
+		e.ParsedLastModified = parseDateTimeLayouts(e.LastModified, e.layouts)
 	}
 	return e.ParsedLastModified
 }
 
+func (e *sitemapIndexEntry) GetLastModifiedRaw() string {
+	return e.LastModified
+}
+
+// dateTimeLayouts are layouts of W3C datetime variants and common deviations
+// which are met in real sitemaps and feeds.
+var dateTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05 Z07:00",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	"2006-01",
+	"2006",
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	time.RFC850,
+	time.RFC822Z,
+	time.RFC822,
+	time.ANSIC,
+}
+
 func parseDateTime(value string) *time.Time {
+	return parseDateTimeLayouts(value, nil)
+}
+
+// parseDateTimeLayouts tries the custom layouts, then the default ones and then
+// parses the value as Unix timestamp in seconds. Values without a zone are in UTC.
+func parseDateTimeLayouts(value string, layouts []string) *time.Time {
+	value = strings.TrimSpace(value)
 	if value == "" {
 		return nil
 	}
 
-	t, err := time.Parse(time.RFC3339, value)
-	if err != nil {
-		// second chance
-		// try parse as short format
-		t, err = time.Parse("2006-01-02", value)
-		if err != nil {
-			return nil
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t
+		}
+	}
+	for _, layout := range dateTimeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t
+		}
+	}
+
+	if len(value) > len("2006") {
+		if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+			t := time.Unix(seconds, 0).UTC()
+			return &t
 		}
 	}
 
-	return &t
+	return nil
 }
@@ -0,0 +1,40 @@
+// Package sitemapexpvar publishes counters of parsing and fetching (see
+// sitemap.WithCounters) through expvar, so services which already expose
+// /debug/vars get visibility of crawls without a metrics dependency. Keep in
+// mind, importing of expvar registers /debug/vars in http.DefaultServeMux,
+// that's why it is a separate package.
+//
+//	crawler := sitemap.NewCrawler(sitemapexpvar.Publish("sitemap"))
+package sitemapexpvar
+
+import (
+	"expvar"
+	"sync"
+
+	sitemap "github.com/frase-io/gopher-parse-sitemap"
+)
+
+// mu serializes publishing of maps.
+var mu sync.Mutex
+
+// Publish returns the option which adds counters to the expvar.Map with the
+// name. The map is published once and shared by all options with the same
+// name, counters are cumulative and never reset. It panics if the name is
+// taken by a variable other than *expvar.Map.
+func Publish(name string) sitemap.Option {
+	return sitemap.WithCounters(Map(name).Add)
+}
+
+// Map returns the published expvar.Map with the name, it publishes the map if
+// it isn't published yet.
+func Map(name string) *expvar.Map {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if v := expvar.Get(name); v != nil {
+		if m, ok := v.(*expvar.Map); ok {
+			return m
+		}
+	}
+	return expvar.NewMap(name)
+}
@@ -0,0 +1,38 @@
+package sitemapexpvar
+
+import (
+	"expvar"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	sitemap "github.com/frase-io/gopher-parse-sitemap"
+)
+
+func TestPublish(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<urlset><url><loc>http://example.com/a</loc></url><url><loc>http://example.com/b</loc></url></urlset>")
+	}))
+	defer server.Close()
+
+	for i := 0; i < 2; i++ {
+		err := sitemap.ParseFromSite(server.URL, func(e sitemap.Entry) error {
+			return nil
+		}, Publish("sitemap_test"))
+		if err != nil {
+			t.Fatalf("Parsing failed with error %s", err)
+		}
+	}
+
+	m := Map("sitemap_test")
+	for name, expected := range map[string]int64{sitemap.CounterRequests: 2, sitemap.CounterEntries: 4} {
+		if v, ok := m.Get(name).(*expvar.Int); !ok || v.Value() != expected {
+			t.Errorf("Expected %d of %s, but given %v", expected, name, m.Get(name))
+		}
+	}
+	if !strings.Contains(expvar.Get("sitemap_test").String(), `"bytes"`) {
+		t.Errorf("Expected bytes in %s", expvar.Get("sitemap_test"))
+	}
+}
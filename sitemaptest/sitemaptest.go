@@ -0,0 +1,252 @@
+// Package sitemaptest provides a corpus-driven harness of regression tests
+// of parsing. A corpus is a directory of documents, e.g. real-world sitemaps
+// with quirks, and of golden files with expected dumps of their entries. The
+// golden file of a document has the name of the document with the .golden
+// suffix, e.g. bom.xml and bom.xml.golden.
+//
+// The package has the builtin corpus of weird sitemaps, contributors of new
+// formats run it to check they don't regress existing parsing, and users can
+// run it along with their own corpora:
+//
+//	var update = flag.Bool("update", false, "update golden files")
+//
+//	func TestCorpus(t *testing.T) {
+//		corpus := sitemaptest.Corpus{Dirs: []string{sitemaptest.Builtin(), "testdata/corpus"}, Update: *update}
+//		corpus.Run(t)
+//	}
+//
+// Run "go test -update" to write golden files of new documents, review them
+// before committing.
+package sitemaptest
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	sitemap "github.com/frase-io/gopher-parse-sitemap"
+	"github.com/frase-io/gopher-parse-sitemap/stage"
+)
+
+// goldenSuffix is the suffix of golden files.
+const goldenSuffix = ".golden"
+
+// Builtin returns the directory of the builtin corpus. It requires sources of
+// the package, which are present in the module cache.
+func Builtin() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Join(filepath.Dir(file), "testdata", "corpus")
+}
+
+// Case is a document of a corpus and its golden file.
+type Case struct {
+	Name   string
+	Path   string
+	Golden string
+}
+
+// Cases returns cases of the corpus directory in order of names. Files which
+// start with a dot and golden files aren't documents.
+func Cases(dir string) ([]Case, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var cases []Case
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() || strings.HasPrefix(name, ".") || strings.HasSuffix(name, goldenSuffix) {
+			continue
+		}
+		path := filepath.Join(dir, name)
+		cases = append(cases, Case{Name: name, Path: path, Golden: path + goldenSuffix})
+	}
+	sort.Slice(cases, func(i, j int) bool { return cases[i].Name < cases[j].Name })
+	return cases, nil
+}
+
+// Dump parses the document which provides by the reader and returns a dump of
+// its entries and of its sitemap index entries in a line-oriented text format,
+// so differences are readable in diffs. Values are quoted, lastmods are given
+// as they are and parsed. An error of parsing is the last line of the dump.
+// Gzipped documents are decompressed. The options are passed to parsing of
+// entries.
+func Dump(reader io.Reader, opts ...sitemap.Option) ([]byte, error) {
+	reader, err := stage.Decompress(reader)
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	err = sitemap.Parse(bytes.NewReader(data), func(e sitemap.Entry) error {
+		dumpEntry(&buf, e)
+		return nil
+	}, opts...)
+	if err == nil {
+		err = sitemap.ParseIndex(bytes.NewReader(data), func(e sitemap.IndexEntry) error {
+			fmt.Fprintf(&buf, "sitemap %q\n", e.GetLocation())
+			dumpLastModified(&buf, rawLastModified(e), e.GetLastModified())
+			return nil
+		})
+	}
+	if err != nil {
+		fmt.Fprintf(&buf, "error %q\n", err.Error())
+	}
+	return buf.Bytes(), nil
+}
+
+// rawLastModified returns the raw lastmod of the entry or an empty string.
+func rawLastModified(e interface{}) string {
+	if p, ok := e.(sitemap.RawLastModifiedProvider); ok {
+		return p.GetLastModifiedRaw()
+	}
+	return ""
+}
+
+func dumpEntry(buf *bytes.Buffer, e sitemap.Entry) {
+	fmt.Fprintf(buf, "url %q\n", e.GetLocation())
+	dumpLastModified(buf, rawLastModified(e), e.GetLastModified())
+	fmt.Fprintf(buf, "  changefreq %q\n", e.GetChangeFrequency())
+	fmt.Fprintf(buf, "  priority %v\n", e.GetPriority())
+
+	if p, ok := e.(sitemap.ImagesProvider); ok {
+		for _, image := range p.GetImages() {
+			fmt.Fprintf(buf, "  image %q caption=%q title=%q\n", image.Location, image.Caption, image.Title)
+		}
+	}
+	if p, ok := e.(sitemap.VideosProvider); ok {
+		for _, video := range p.GetVideos() {
+			fmt.Fprintf(buf, "  video %q title=%q content=%q duration=%d\n",
+				video.ThumbnailLocation, video.Title, video.ContentLocation, video.Duration)
+		}
+	}
+	if p, ok := e.(sitemap.NewsProvider); ok {
+		if news := p.GetNews(); news != nil {
+			fmt.Fprintf(buf, "  news %q language=%q title=%q date=%q\n",
+				news.PublicationName, news.PublicationLanguage, news.Title, news.PublicationDate)
+		}
+	}
+	if p, ok := e.(sitemap.AlternatesProvider); ok {
+		for _, alternate := range p.GetAlternates() {
+			fmt.Fprintf(buf, "  alternate %q %q\n", alternate.Hreflang, alternate.Href)
+		}
+	}
+}
+
+func dumpLastModified(buf *bytes.Buffer, raw string, parsed *time.Time) {
+	if raw == "" && parsed == nil {
+		return
+	}
+	value := "invalid"
+	if parsed != nil {
+		value = parsed.Format(time.RFC3339Nano)
+	}
+	fmt.Fprintf(buf, "  lastmod %q %s\n", raw, value)
+}
+
+// Corpus is a set of corpus directories. Options are passed to Dump. Update
+// makes Run write golden files instead of comparing them.
+type Corpus struct {
+	Dirs    []string
+	Options []sitemap.Option
+	Update  bool
+}
+
+// Run runs a subtest per case of the corpus. A subtest fails if the dump of
+// the document differs from its golden file or the golden file is absent.
+func (c *Corpus) Run(t *testing.T) {
+	for _, dir := range c.Dirs {
+		cases, err := Cases(dir)
+		if err != nil {
+			t.Fatalf("Reading corpus %s failed with error %s", dir, err)
+		}
+		for _, cs := range cases {
+			cs := cs
+			t.Run(cs.Name, func(t *testing.T) {
+				if err := c.check(cs); err != nil {
+					t.Error(err)
+				}
+			})
+		}
+	}
+}
+
+// Check compares dumps of all cases of the corpus with golden files, or writes
+// them if Update is set. It returns descriptions of failed cases.
+func (c *Corpus) Check() ([]string, error) {
+	var failures []string
+	for _, dir := range c.Dirs {
+		cases, err := Cases(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, cs := range cases {
+			if err = c.check(cs); err != nil {
+				failures = append(failures, err.Error())
+			}
+		}
+	}
+	return failures, nil
+}
+
+func (c *Corpus) check(cs Case) error {
+	file, err := os.Open(cs.Path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	given, err := Dump(file, c.Options...)
+	if err != nil {
+		return fmt.Errorf("%s: reading failed with error %s", cs.Path, err)
+	}
+	if c.Update {
+		return ioutil.WriteFile(cs.Golden, given, 0644)
+	}
+
+	expected, err := ioutil.ReadFile(cs.Golden)
+	if err != nil {
+		return fmt.Errorf("%s: can't read golden file due to %s, run with Update to write it", cs.Path, err)
+	}
+	if line, ok := firstDifference(expected, given); ok {
+		return fmt.Errorf("%s: unexpected dump at line %d\nexpected: %s\ngiven:    %s", cs.Path, line.number, line.expected, line.given)
+	}
+	return nil
+}
+
+type difference struct {
+	number          int
+	expected, given string
+}
+
+// firstDifference returns the first different line of dumps.
+func firstDifference(expected, given []byte) (difference, bool) {
+	a := strings.Split(string(expected), "\n")
+	b := strings.Split(string(given), "\n")
+	for i := 0; i < len(a) || i < len(b); i++ {
+		d := difference{number: i + 1, expected: "<end>", given: "<end>"}
+		if i < len(a) {
+			d.expected = a[i]
+		}
+		if i < len(b) {
+			d.given = b[i]
+		}
+		if d.expected != d.given {
+			return d, true
+		}
+	}
+	return difference{}, false
+}
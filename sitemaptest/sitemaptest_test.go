@@ -0,0 +1,47 @@
+package sitemaptest
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update golden files of the builtin corpus")
+
+func TestBuiltin(t *testing.T) {
+	corpus := Corpus{Dirs: []string{Builtin()}, Update: *update}
+	corpus.Run(t)
+}
+
+func TestCorpus_Check(t *testing.T) {
+	dir, err := ioutil.TempDir("", "corpus")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "sitemap.xml")
+	ioutil.WriteFile(path, []byte("<urlset><url><loc>https://example.com/</loc></url></urlset>"), 0644)
+	corpus := Corpus{Dirs: []string{dir}}
+	if failures, err := corpus.Check(); err != nil || len(failures) != 1 || !strings.Contains(failures[0], "golden") {
+		t.Errorf("Expected a failure of the absent golden file, but given %v with error %v", failures, err)
+	}
+
+	corpus.Update = true
+	if failures, err := corpus.Check(); err != nil || len(failures) != 0 {
+		t.Fatalf("Updating failed with %v and error %v", failures, err)
+	}
+	corpus.Update = false
+	if failures, err := corpus.Check(); err != nil || len(failures) != 0 {
+		t.Errorf("Expected no failures after updating, but given %v with error %v", failures, err)
+	}
+
+	ioutil.WriteFile(path, []byte("<urlset><url><loc>https://example.com/changed</loc></url></urlset>"), 0644)
+	failures, err := corpus.Check()
+	if err != nil || len(failures) != 1 || !strings.Contains(failures[0], "line 1") {
+		t.Errorf("Expected a difference at line 1, but given %v with error %v", failures, err)
+	}
+}
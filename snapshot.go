@@ -0,0 +1,308 @@
+package sitemap
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/frase-io/gopher-parse-sitemap/stage"
+)
+
+// snapshotHeader is the first line of a snapshot, it versions the format.
+const snapshotHeader = "# sitemap snapshot v1"
+
+// ChangeKind is a type represents a kind of difference between two snapshots.
+type ChangeKind = string
+
+// Change kinds constants set.
+const (
+	EntryAdded    ChangeKind = "added"    // An URL is only in the new snapshot
+	EntryRemoved  ChangeKind = "removed"  // An URL is only in the old snapshot
+	EntryModified ChangeKind = "modified" // An URL has another lastmod, changefreq or priority
+)
+
+// SnapshotRecord is an entry of a snapshot. LastModified is nil if the entry
+// has no lastmod or it can't be parsed.
+type SnapshotRecord struct {
+	Location        string
+	LastModified    *time.Time
+	ChangeFrequency Frequency
+	Priority        float32
+}
+
+// Change describes a difference between two snapshots. Old is nil for added
+// URLs, New is nil for removed ones.
+type Change struct {
+	Kind     ChangeKind
+	Location string
+	Old      *SnapshotRecord
+	New      *SnapshotRecord
+}
+
+// ChangeConsumer is a type represents consumer of changes between snapshots.
+type ChangeConsumer func(Change) error
+
+// SnapshotWriter writes entries of crawls to a snapshot. A snapshot is a text
+// file with a record per line which is sorted by location, so snapshots of
+// the same site are diffable by CompareSnapshots or by diff tool.
+//
+// Records are normalized: schemes and hosts of locations are lowercased,
+// fragments are removed, lastmods are converted to UTC, duplicated locations
+// are written once with the latest added values. Gzip compression is enabled
+// by WithGzip option.
+//
+// Keep in mind. Records are kept in memory until Close, since entries are
+// sorted before writing.
+type SnapshotWriter struct {
+	w       io.Writer
+	o       *options
+	records []SnapshotRecord
+	closed  bool
+}
+
+// NewSnapshotWriter creates a new snapshot writer. You must call Close to write
+// the snapshot.
+func NewSnapshotWriter(w io.Writer, opts ...Option) *SnapshotWriter {
+	return &SnapshotWriter{w: w, o: newOptions(opts)}
+}
+
+// Add adds the entry to the snapshot. It can be used as EntryConsumer.
+func (s *SnapshotWriter) Add(e Entry) error {
+	if s.closed {
+		return ErrWriterClosed
+	}
+
+	record := SnapshotRecord{
+		Location:        normalizeSnapshotLocation(e.GetLocation()),
+		ChangeFrequency: e.GetChangeFrequency(),
+		Priority:        e.GetPriority(),
+	}
+	if lastmod := e.GetLastModified(); lastmod != nil {
+		utc := lastmod.UTC()
+		record.LastModified = &utc
+	}
+	s.records = append(s.records, record)
+	return nil
+}
+
+// Close sorts records and writes the snapshot. It doesn't close the underlying writer.
+func (s *SnapshotWriter) Close() error {
+	if s.closed {
+		return ErrWriterClosed
+	}
+	s.closed = true
+
+	sort.SliceStable(s.records, func(i, j int) bool {
+		return s.records[i].Location < s.records[j].Location
+	})
+
+	var gz *gzip.Writer
+	var w io.Writer = s.w
+	if s.o.gzip {
+		gz = gzip.NewWriter(w)
+		w = gz
+	}
+	buffered := bufio.NewWriter(w)
+
+	if _, err := fmt.Fprintln(buffered, snapshotHeader); err != nil {
+		return err
+	}
+	for i, record := range s.records {
+		if i+1 < len(s.records) && s.records[i+1].Location == record.Location {
+			continue
+		}
+		if _, err := fmt.Fprintln(buffered, record.line()); err != nil {
+			return err
+		}
+	}
+	s.records = nil
+
+	if err := buffered.Flush(); err != nil {
+		return err
+	}
+	if gz != nil {
+		return gz.Close()
+	}
+	return nil
+}
+
+// SnapshotReader reads records of a snapshot one by one.
+type SnapshotReader struct {
+	scanner *bufio.Scanner
+	line    int
+	last    string
+}
+
+// LoadSnapshot starts reading of the snapshot which provides by the reader.
+// Compressed snapshots are detected automatically.
+func LoadSnapshot(reader io.Reader) (*SnapshotReader, error) {
+	reader, err := stage.Decompress(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &SnapshotReader{scanner: bufio.NewScanner(reader), line: 1}
+	if !r.scanner.Scan() {
+		if err = r.scanner.Err(); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("sitemap: snapshot is empty")
+	}
+	if r.scanner.Text() != snapshotHeader {
+		return nil, fmt.Errorf("sitemap: unsupported snapshot header %q", r.scanner.Text())
+	}
+	return r, nil
+}
+
+// Next returns the next record. It returns io.EOF after the last record.
+func (r *SnapshotReader) Next() (*SnapshotRecord, error) {
+	if !r.scanner.Scan() {
+		if err := r.scanner.Err(); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+	r.line++
+
+	record, err := parseSnapshotLine(r.scanner.Text())
+	if err != nil {
+		return nil, fmt.Errorf("sitemap: invalid snapshot line %d: %v", r.line, err)
+	}
+	if r.line > 2 && record.Location <= r.last {
+		return nil, fmt.Errorf("sitemap: invalid snapshot line %d: records aren't sorted", r.line)
+	}
+	r.last = record.Location
+	return record, nil
+}
+
+// CompareSnapshots reads two snapshots side by side and passes differences to
+// the consumer in order of locations. Snapshots are streamed, so memory usage
+// doesn't depend on their size.
+func CompareSnapshots(previous io.Reader, current io.Reader, consumer ChangeConsumer) error {
+	oldReader, err := LoadSnapshot(previous)
+	if err != nil {
+		return err
+	}
+	newReader, err := LoadSnapshot(current)
+	if err != nil {
+		return err
+	}
+
+	next := func(r *SnapshotReader) (*SnapshotRecord, error) {
+		record, err := r.Next()
+		if err == io.EOF {
+			return nil, nil
+		}
+		return record, err
+	}
+
+	o, err := next(oldReader)
+	if err != nil {
+		return err
+	}
+	n, err := next(newReader)
+	if err != nil {
+		return err
+	}
+
+	for o != nil || n != nil {
+		var change *Change
+		advanceOld, advanceNew := false, false
+		switch {
+		case n == nil || (o != nil && o.Location < n.Location):
+			change = &Change{Kind: EntryRemoved, Location: o.Location, Old: o}
+			advanceOld = true
+		case o == nil || n.Location < o.Location:
+			change = &Change{Kind: EntryAdded, Location: n.Location, New: n}
+			advanceNew = true
+		default:
+			if !o.equal(n) {
+				change = &Change{Kind: EntryModified, Location: n.Location, Old: o, New: n}
+			}
+			advanceOld, advanceNew = true, true
+		}
+
+		if change != nil {
+			if err = consumer(*change); err != nil {
+				return err
+			}
+		}
+		if advanceOld {
+			if o, err = next(oldReader); err != nil {
+				return err
+			}
+		}
+		if advanceNew {
+			if n, err = next(newReader); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+var snapshotEscaper = strings.NewReplacer("\t", "%09", "\n", "%0A", "\r", "%0D")
+
+func normalizeSnapshotLocation(location string) string {
+	if u, err := url.Parse(location); err == nil {
+		u.Fragment = ""
+		u.Scheme = strings.ToLower(u.Scheme)
+		u.Host = strings.ToLower(u.Host)
+		location = u.String()
+	}
+	return snapshotEscaper.Replace(location)
+}
+
+// line returns the record as a tab separated line: location, lastmod,
+// changefreq and priority.
+func (r *SnapshotRecord) line() string {
+	lastmod := ""
+	if r.LastModified != nil {
+		lastmod = r.LastModified.Format(time.RFC3339Nano)
+	}
+	priority := ""
+	if r.Priority != 0 {
+		priority = strconv.FormatFloat(float64(r.Priority), 'f', -1, 32)
+	}
+	return strings.Join([]string{r.Location, lastmod, string(r.ChangeFrequency), priority}, "\t")
+}
+
+func parseSnapshotLine(line string) (*SnapshotRecord, error) {
+	fields := strings.Split(line, "\t")
+	if len(fields) != 4 {
+		return nil, fmt.Errorf("expected 4 fields, but given %d", len(fields))
+	}
+
+	record := &SnapshotRecord{Location: fields[0], ChangeFrequency: Frequency(fields[2])}
+	if fields[1] != "" {
+		lastmod, err := time.Parse(time.RFC3339Nano, fields[1])
+		if err != nil {
+			return nil, err
+		}
+		record.LastModified = &lastmod
+	}
+	if fields[3] != "" {
+		priority, err := strconv.ParseFloat(fields[3], 32)
+		if err != nil {
+			return nil, err
+		}
+		record.Priority = float32(priority)
+	}
+	return record, nil
+}
+
+func (r *SnapshotRecord) equal(other *SnapshotRecord) bool {
+	if (r.LastModified == nil) != (other.LastModified == nil) {
+		return false
+	}
+	if r.LastModified != nil && !r.LastModified.Equal(*other.LastModified) {
+		return false
+	}
+	return r.ChangeFrequency == other.ChangeFrequency && r.Priority == other.Priority
+}
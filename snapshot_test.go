@@ -0,0 +1,79 @@
+package sitemap
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeSnapshot(t *testing.T, data string, opts ...Option) *bytes.Buffer {
+	var buf bytes.Buffer
+	w := NewSnapshotWriter(&buf, opts...)
+	if err := Parse(strings.NewReader(data), w.Add); err != nil {
+		t.Fatalf("Parsing failed with error %s", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Writing failed with error %s", err)
+	}
+	return &buf
+}
+
+func TestSnapshotWriter(t *testing.T) {
+	buf := writeSnapshot(t, `<urlset>
+<url><loc>HTTPS://Example.com/b#top</loc><lastmod>2020-01-01T10:00:00+02:00</lastmod><priority>0.5</priority></url>
+<url><loc>https://example.com/a</loc><changefreq>daily</changefreq></url>
+<url><loc>https://example.com/b</loc><lastmod>2020-01-02</lastmod></url>
+</urlset>`)
+
+	expected := snapshotHeader + "\n" +
+		"https://example.com/a\t\tdaily\t0.5\n" +
+		"https://example.com/b\t2020-01-02T00:00:00Z\talways\t0.5\n"
+	if buf.String() != expected {
+		t.Errorf("Expected snapshot %q, but given %q", expected, buf.String())
+	}
+}
+
+func TestCompareSnapshots(t *testing.T) {
+	previous := writeSnapshot(t, `<urlset>
+<url><loc>https://example.com/a</loc></url>
+<url><loc>https://example.com/b</loc><lastmod>2020-01-01</lastmod></url>
+<url><loc>https://example.com/c</loc><priority>0.3</priority></url>
+</urlset>`, WithGzip())
+	current := writeSnapshot(t, `<urlset>
+<url><loc>https://example.com/d</loc></url>
+<url><loc>https://example.com/c</loc><priority>0.3</priority></url>
+<url><loc>https://example.com/b</loc><lastmod>2020-01-02</lastmod></url>
+</urlset>`)
+
+	var changes []string
+	err := CompareSnapshots(previous, current, func(c Change) error {
+		changes = append(changes, c.Kind+" "+c.Location)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Comparing failed with error %s", err)
+	}
+
+	expected := []string{"removed https://example.com/a", "modified https://example.com/b", "added https://example.com/d"}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("Expected changes %v, but given %v", expected, changes)
+	}
+}
+
+func TestLoadSnapshot_Invalid(t *testing.T) {
+	if _, err := LoadSnapshot(strings.NewReader("https://example.com/\t\t\t\n")); err == nil {
+		t.Error("Expected an error of a snapshot without header")
+	}
+
+	r, err := LoadSnapshot(strings.NewReader(snapshotHeader + "\nhttps://example.com/b\t\t\t\nhttps://example.com/a\t\t\t\n"))
+	if err != nil {
+		t.Fatalf("Loading failed with error %s", err)
+	}
+	if _, err = r.Next(); err != nil {
+		t.Fatalf("Reading failed with error %s", err)
+	}
+	if _, err = r.Next(); err == nil {
+		t.Error("Expected an error of unsorted records")
+	}
+}
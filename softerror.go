@@ -0,0 +1,128 @@
+package sitemap
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrSoftError is matched by SoftError. Check it by errors.Is.
+var ErrSoftError = errors.New("sitemap: soft error")
+
+// SoftErrorReason is a type represents a reason of SoftError.
+type SoftErrorReason = string
+
+// Soft error reasons constants set.
+const (
+	SoftErrorCaptcha SoftErrorReason = "captcha" // A captcha or a challenge of an anti-bot layer
+	SoftErrorPage    SoftErrorReason = "error"   // An error page like "Not Found" or "Access Denied"
+)
+
+// SoftError is returned when a sitemap is responded with a 2xx status, but the
+// body is an HTML page of an error or of a captcha, like anti-bot layers and
+// misconfigured servers respond. Detection is heuristic: it looks for markers
+// in the title, the first heading and the beginning of the page.
+//
+// Snippet is the beginning of the response body. SoftError matches both
+// ErrSoftError and ErrNotSitemap by errors.Is.
+type SoftError struct {
+	URL         string
+	ContentType string
+	Reason      SoftErrorReason
+	Snippet     string
+}
+
+func (e *SoftError) Error() string {
+	return fmt.Sprintf("sitemap: %s is an HTML %s page of type %q", e.URL, e.Reason, e.ContentType)
+}
+
+// Is makes SoftError match ErrSoftError and ErrNotSitemap.
+func (e *SoftError) Is(target error) bool {
+	return target == ErrSoftError || target == ErrNotSitemap
+}
+
+func newSoftError(location, contentType string, reason SoftErrorReason, head []byte) *SoftError {
+	if len(head) > snippetSize {
+		head = head[:snippetSize]
+	}
+	return &SoftError{URL: location, ContentType: contentType, Reason: reason, Snippet: string(head)}
+}
+
+// WithSoftErrorRetry makes soft errors, see SoftError, retried like retried
+// statuses by the policy of WithRetry, so each retry switches to the next proxy
+// of WithProxies. It helps when some proxies are blocked by an anti-bot layer.
+// The soft error of the last attempt is returned as usual.
+func WithSoftErrorRetry() Option {
+	return func(o *options) {
+		o.softErrorRetry = true
+	}
+}
+
+// isSoftError reports whether the response is a soft error with retries of them
+// enabled. The body of the response stays readable.
+func (o *options) isSoftError(res *http.Response) bool {
+	if !o.softErrorRetry || !isSuccess(res) {
+		return false
+	}
+	reader, head, html := sniffPage(res.Body)
+	res.Body = &readCloser{Reader: reader, Closer: res.Body}
+	return html && softErrorReason(head) != ""
+}
+
+// Markers of captchas are searched in the whole beginning of the page, markers
+// of error pages only in the title and in the first heading.
+var (
+	captchaMarkers = []string{
+		"captcha", "cf-chl-", "/cdn-cgi/challenge-platform", "challenge-form",
+		"just a moment...", "attention required!", "verify you are human",
+		"are you a robot", "unusual traffic", "pardon our interruption",
+		"request unsuccessful. incapsula", "_incapsula_resource",
+	}
+	errorPageMarkers = []string{
+		"not found", "404", "403", "500", "502", "503", "error", "access denied",
+		"forbidden", "unavailable", "too many requests", "rate limit", "blocked",
+		"maintenance", "unauthorized",
+	}
+)
+
+// softErrorReason returns the reason of the soft error if the beginning of
+// an HTML page looks like an error or a captcha, otherwise it returns "".
+func softErrorReason(head []byte) SoftErrorReason {
+	lower := bytes.ToLower(head)
+	for _, marker := range captchaMarkers {
+		if bytes.Contains(lower, []byte(marker)) {
+			return SoftErrorCaptcha
+		}
+	}
+	for _, tag := range []string{"title", "h1"} {
+		text := tagText(lower, tag)
+		for _, marker := range errorPageMarkers {
+			if bytes.Contains(text, []byte(marker)) {
+				return SoftErrorPage
+			}
+		}
+	}
+	return ""
+}
+
+// tagText returns the content of the first element with the tag.
+func tagText(lower []byte, tag string) []byte {
+	start := bytes.Index(lower, []byte("<"+tag))
+	if start < 0 {
+		return nil
+	}
+	rest := lower[start+len(tag)+1:]
+	if len(rest) == 0 || (rest[0] != '>' && rest[0] != ' ' && rest[0] != '\t' && rest[0] != '\n' && rest[0] != '\r') {
+		return nil
+	}
+	open := bytes.IndexByte(rest, '>')
+	if open < 0 {
+		return nil
+	}
+	rest = rest[open+1:]
+	if end := bytes.Index(rest, []byte("</"+tag)); end >= 0 {
+		return rest[:end]
+	}
+	return rest
+}
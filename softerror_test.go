@@ -0,0 +1,85 @@
+package sitemap
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const captchaPage = `<!DOCTYPE html><html><head><title>Just a moment...</title></head>
+<body><form id="challenge-form" action="/cdn-cgi/challenge-platform/h/b"></form></body></html>`
+
+func TestSoftErrorReason(t *testing.T) {
+	tests := map[string]SoftErrorReason{
+		captchaPage: SoftErrorCaptcha,
+		"<html><head><title>404 Not Found</title></head><body></body></html>":                SoftErrorPage,
+		"<html><body><h1 class=\"big\">Access Denied</h1></body></html>":                     SoftErrorPage,
+		"<html><head><title>Sitemap of example.com</title></head><body>Errors</body></html>": "",
+		"<html><head><titles>Error</titles></head></html>":                                   "",
+	}
+	for page, expected := range tests {
+		if given := softErrorReason([]byte(page)); given != expected {
+			t.Errorf("Expected %q for %q, but given %q", expected, page, given)
+		}
+	}
+}
+
+func TestParseFromSite_SoftError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, captchaPage)
+	}))
+	defer server.Close()
+
+	err := ParseFromSite(server.URL+"/sitemap.xml", func(e Entry) error {
+		return nil
+	})
+	var softErr *SoftError
+	if !errors.As(err, &softErr) {
+		t.Fatalf("Expected SoftError, but given %v", err)
+	}
+	if softErr.Reason != SoftErrorCaptcha || softErr.ContentType != "text/html" ||
+		!strings.HasPrefix(softErr.Snippet, "<!DOCTYPE html>") {
+		t.Errorf("Unexpected SoftError %+v", softErr)
+	}
+	if !errors.Is(err, ErrSoftError) || !errors.Is(err, ErrNotSitemap) {
+		t.Errorf("Expected ErrSoftError and ErrNotSitemap, but given %v", err)
+	}
+}
+
+func TestWithSoftErrorRetry(t *testing.T) {
+	var requested []string
+	newProxy := func(name, body string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requested = append(requested, name)
+			fmt.Fprint(w, body)
+		}))
+	}
+	blocked := newProxy("blocked", captchaPage)
+	defer blocked.Close()
+	working := newProxy("working", "<urlset><url><loc>http://sitemap.test/</loc></url></urlset>")
+	defer working.Close()
+
+	counter := 0
+	err := ParseFromSite("http://sitemap.test/sitemap.xml", func(e Entry) error {
+		counter++
+		return nil
+	}, WithProxies(blocked.URL, working.URL), WithRetry(testRetryPolicy()), WithSoftErrorRetry())
+	if err != nil {
+		t.Fatalf("Parsing failed with error %s", err)
+	}
+	if counter != 1 || strings.Join(requested, " ") != "blocked working" {
+		t.Errorf("Unexpected entries %d and requests %v", counter, requested)
+	}
+
+	requested = nil
+	err = ParseFromSite("http://sitemap.test/sitemap.xml", func(e Entry) error {
+		return nil
+	}, WithProxies(blocked.URL), WithRetry(testRetryPolicy()), WithSoftErrorRetry())
+	if !errors.Is(err, ErrSoftError) || len(requested) != testRetryPolicy().Attempts {
+		t.Errorf("Expected SoftError after %d attempts, but given %v after %d", testRetryPolicy().Attempts, err, len(requested))
+	}
+}
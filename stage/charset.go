@@ -0,0 +1,136 @@
+package stage
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html/charset"
+)
+
+// HeadSize is the size of the head of a document which is inspected for the
+// byte order mark and the XML declaration.
+const HeadSize = 512
+
+var (
+	utf8BOM             = []byte("\xef\xbb\xbf")
+	declarationEncoding = regexp.MustCompile(`encoding\s*=\s*["']([^"']*)["']`)
+)
+
+// Encoding describes the encoding of a document detected by its head.
+//
+// UTF16 is the byte order of UTF-16 content, "le" or "be", it is empty for
+// 8-bit encodings. BOM is true if the content starts with a byte order mark.
+// Declared is true if the content starts with a XML declaration and Label is
+// the encoding of the declaration, it can be empty.
+type Encoding struct {
+	UTF16    string
+	BOM      bool
+	Declared bool
+	Label    string
+}
+
+// UTF8 reports whether the label of the declaration means UTF-8, an empty
+// label means UTF-8 by XML specification.
+func (e Encoding) UTF8() bool {
+	label := strings.ToLower(strings.TrimSpace(e.Label))
+	return label == "" || label == "utf-8" || label == "utf8"
+}
+
+// Detect detects the encoding of the document by its head. The returned reader
+// reads the whole document.
+func Detect(reader io.Reader) (Encoding, io.Reader, error) {
+	buffered := bufio.NewReaderSize(reader, HeadSize)
+	head, err := buffered.Peek(HeadSize)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return Encoding{}, nil, err
+	}
+	return detect(head), buffered, nil
+}
+
+func detect(head []byte) Encoding {
+	var e Encoding
+	switch {
+	case bytes.HasPrefix(head, []byte{0xff, 0xfe}):
+		e.UTF16, e.BOM, head = "le", true, narrow(head[2:], 0)
+	case bytes.HasPrefix(head, []byte{0xfe, 0xff}):
+		e.UTF16, e.BOM, head = "be", true, narrow(head[2:], 1)
+	case len(head) >= 2 && head[0] == '<' && head[1] == 0:
+		e.UTF16, head = "le", narrow(head, 0)
+	case len(head) >= 2 && head[0] == 0 && head[1] == '<':
+		e.UTF16, head = "be", narrow(head, 1)
+	case bytes.HasPrefix(head, utf8BOM):
+		e.BOM, head = true, head[len(utf8BOM):]
+	}
+
+	if !bytes.HasPrefix(head, []byte("<?xml")) {
+		return e
+	}
+	e.Declared = true
+	if end := bytes.Index(head, []byte("?>")); end >= 0 {
+		head = head[:end]
+	}
+	if m := declarationEncoding.FindSubmatch(head); m != nil {
+		e.Label = string(m[1])
+	}
+	return e
+}
+
+// narrow returns bytes of UTF-16 content at the offset of each code unit, it
+// keeps ASCII of the declaration readable.
+func narrow(data []byte, offset int) []byte {
+	narrowed := make([]byte, 0, len(data)/2)
+	for i := offset; i < len(data); i += 2 {
+		narrowed = append(narrowed, data[i])
+	}
+	return narrowed
+}
+
+// Charset is a stage which transcodes UTF-16 documents and documents in
+// encodings of their declarations to UTF-8. The leading byte order mark is
+// removed and the declaration is changed to UTF-8, so the document isn't
+// decoded twice. UTF-8 documents are passed as they are.
+func Charset(reader io.Reader) (io.Reader, error) {
+	e, reader, err := Detect(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	label := e.Label
+	if e.UTF16 != "" {
+		label = "utf-16" + e.UTF16
+	} else if e.UTF8() {
+		return reader, nil
+	}
+	if reader, err = charset.NewReaderLabel(label, reader); err != nil {
+		return nil, fmt.Errorf("stage: can't transcode %q: %v", label, err)
+	}
+	return utf8Declared(reader)
+}
+
+// utf8Declared changes the encoding of the declaration of UTF-8 content.
+func utf8Declared(reader io.Reader) (io.Reader, error) {
+	buffered := bufio.NewReaderSize(reader, HeadSize)
+	head, err := buffered.Peek(HeadSize)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, err
+	}
+	if bytes.HasPrefix(head, utf8BOM) {
+		buffered.Discard(len(utf8BOM))
+		head = head[len(utf8BOM):]
+	}
+	end := bytes.Index(head, []byte("?>"))
+	if !bytes.HasPrefix(head, []byte("<?xml")) || end < 0 {
+		return buffered, nil
+	}
+
+	declaration := declarationEncoding.ReplaceAll(head[:end], []byte(`encoding="UTF-8"`))
+	declaration = append(declaration, "?>"...)
+	if _, err = buffered.Discard(end + 2); err != nil {
+		return nil, err
+	}
+	return io.MultiReader(bytes.NewReader(declaration), buffered), nil
+}
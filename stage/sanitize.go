@@ -0,0 +1,68 @@
+package stage
+
+import (
+	"io"
+	"unicode/utf8"
+)
+
+// Sanitize is a stage which cleans UTF-8 content: byte order marks are removed,
+// invalid UTF-8 sequences are replaced by U+FFFD and control characters which
+// aren't allowed in XML are removed, so they don't break decoding.
+func Sanitize(reader io.Reader) (io.Reader, error) {
+	return &sanitizer{reader: reader, buf: make([]byte, 32*1024)}, nil
+}
+
+type sanitizer struct {
+	reader  io.Reader
+	buf     []byte
+	carry   []byte
+	pending []byte
+	err     error
+}
+
+func (s *sanitizer) Read(p []byte) (int, error) {
+	for len(s.pending) == 0 {
+		if s.err != nil {
+			return 0, s.err
+		}
+		n, err := s.reader.Read(s.buf)
+		s.err = err
+		s.pending = s.clean(s.buf[:n], err != nil)
+	}
+
+	n := copy(p, s.pending)
+	s.pending = s.pending[n:]
+	return n, nil
+}
+
+func (s *sanitizer) clean(data []byte, last bool) []byte {
+	if len(s.carry) > 0 {
+		data = append(s.carry, data...)
+		s.carry = nil
+	}
+
+	cleaned := make([]byte, 0, len(data))
+	for i := 0; i < len(data); {
+		if b := data[i]; b < utf8.RuneSelf {
+			if b >= 0x20 || b == '\t' || b == '\n' || b == '\r' {
+				cleaned = append(cleaned, b)
+			}
+			i++
+			continue
+		}
+
+		if !last && !utf8.FullRune(data[i:]) {
+			s.carry = append([]byte(nil), data[i:]...)
+			break
+		}
+		r, size := utf8.DecodeRune(data[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			cleaned = append(cleaned, "\uFFFD"...)
+		case r != '\uFEFF':
+			cleaned = append(cleaned, data[i:i+size]...)
+		}
+		i += size
+	}
+	return cleaned
+}
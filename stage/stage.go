@@ -0,0 +1,59 @@
+// Package stage provides stages of the pipeline which prepares sitemap
+// documents for decoding, each stage is an io.Reader wrapper:
+//
+//	decompress → charset → sanitize → decode
+//
+// The decode stage is sitemap.Parse and other parsing functions of the sitemap
+// package, which use the default stages by themselves: downloads are
+// decompressed, and charset and sanitize stages are enabled by
+// sitemap.WithTranscoding. Stages can be assembled into custom pipelines,
+// e.g. with an own sanitizer:
+//
+//	reader, err := stage.Chain(file, stage.Decompress, stage.Charset, mySanitizer)
+//	if err != nil {
+//		return err
+//	}
+//	err = sitemap.Parse(reader, consumer)
+package stage
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+)
+
+// Stage is a type represents a stage of the pipeline. It returns a reader
+// which reads data of the given reader transformed by the stage.
+type Stage func(io.Reader) (io.Reader, error)
+
+// Default returns stages which prepare a document of any supported encoding
+// for decoding: Decompress, Charset and Sanitize.
+func Default() []Stage {
+	return []Stage{Decompress, Charset, Sanitize}
+}
+
+// Chain returns a reader of data of the reader passed through stages in order
+// they were given.
+func Chain(reader io.Reader, stages ...Stage) (io.Reader, error) {
+	var err error
+	for _, stage := range stages {
+		if reader, err = stage(reader); err != nil {
+			return nil, err
+		}
+	}
+	return reader, nil
+}
+
+// Decompress is a stage which decompresses the data if it starts with gzip
+// magic bytes, other data is passed as it is.
+func Decompress(reader io.Reader) (io.Reader, error) {
+	buffered := bufio.NewReader(reader)
+	magic, err := buffered.Peek(2)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		return gzip.NewReader(buffered)
+	}
+	return buffered, nil
+}
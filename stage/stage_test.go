@@ -0,0 +1,86 @@
+package stage
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func read(t *testing.T, data string, stages ...Stage) string {
+	reader, err := Chain(strings.NewReader(data), stages...)
+	if err != nil {
+		t.Fatalf("Chain failed with error %s", err)
+	}
+	result, err := ioutil.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("Reading failed with error %s", err)
+	}
+	return string(result)
+}
+
+func TestDecompress(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.Write([]byte("<urlset/>"))
+	gz.Close()
+
+	if result := read(t, buf.String(), Decompress); result != "<urlset/>" {
+		t.Errorf("Expected decompressed data, but given %q", result)
+	}
+	if result := read(t, "<urlset/>", Decompress); result != "<urlset/>" {
+		t.Errorf("Expected plain data as it is, but given %q", result)
+	}
+}
+
+func TestCharset(t *testing.T) {
+	utf16 := func(data string) string {
+		var b strings.Builder
+		b.WriteString("\xfe\xff")
+		for _, c := range data {
+			b.WriteByte(0)
+			b.WriteRune(c)
+		}
+		return b.String()
+	}
+
+	cases := []struct {
+		data     string
+		expected string
+	}{
+		{`<?xml version="1.0" encoding="UTF-8"?><urlset/>`, `<?xml version="1.0" encoding="UTF-8"?><urlset/>`},
+		{utf16(`<?xml version="1.0" encoding="UTF-16"?><urlset/>`), `<?xml version="1.0" encoding="UTF-8"?><urlset/>`},
+		{"<?xml version='1.0' encoding='ISO-8859-1'?><loc>caf\xe9</loc>", `<?xml version='1.0' encoding="UTF-8"?><loc>café</loc>`},
+	}
+	for _, c := range cases {
+		if result := read(t, c.data, Charset); result != c.expected {
+			t.Errorf("Expected %q, but given %q", c.expected, result)
+		}
+	}
+
+	if _, err := Charset(strings.NewReader(`<?xml version="1.0" encoding="unknown"?>`)); err == nil {
+		t.Error("Expected an error of an unknown encoding")
+	}
+}
+
+func TestSanitize(t *testing.T) {
+	data := "\xef\xbb\xbf<loc>a\x01b\xffc\xef\xbb\xbf</loc>\n" + strings.Repeat("é", 40000)
+	expected := "<loc>ab�c</loc>\n" + strings.Repeat("é", 40000)
+	if result := read(t, data, Sanitize); result != expected {
+		t.Errorf("Expected sanitized data, but given %q", result[:50])
+	}
+}
+
+func TestDetect(t *testing.T) {
+	e, reader, err := Detect(strings.NewReader("\xef\xbb\xbf<?xml version=\"1.0\" encoding=\"windows-1251\"?><urlset/>"))
+	if err != nil {
+		t.Fatalf("Detect failed with error %s", err)
+	}
+	if !e.BOM || !e.Declared || e.Label != "windows-1251" || e.UTF8() || e.UTF16 != "" {
+		t.Errorf("Unexpected encoding %+v", e)
+	}
+	if data, _ := ioutil.ReadAll(reader); !strings.HasSuffix(string(data), "<urlset/>") || len(data) < 50 {
+		t.Errorf("Expected the whole document, but given %q", data)
+	}
+}
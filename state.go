@@ -0,0 +1,138 @@
+package sitemap
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// StateStore is an interface of a storage which keeps crawl state between runs,
+// like lastmod histories of URLs. Keys are namespaced by the features which
+// use the store, so a single store can be shared by all of them.
+//
+// Get returns nil value without error if the key is missed.
+// Scan calls the function for each key with the prefix in lexicographical order,
+// starting from the first key which is not less than start. Empty start scans
+// all keys with the prefix, so stores can seek to start instead of skipping.
+//
+// Implementations must be safe for concurrent use.
+type StateStore interface {
+	Get(key string) ([]byte, error)
+	Put(key string, value []byte) error
+	Delete(key string) error
+	Scan(prefix, start string, fn func(key string, value []byte) error) error
+}
+
+// MemoryStateStore is a StateStore which keeps state in memory. Keys are kept
+// sorted, so scans seek to the prefix instead of sorting all keys.
+type MemoryStateStore struct {
+	mu     sync.RWMutex
+	values map[string][]byte
+	keys   []string
+}
+
+// NewMemoryStateStore creates a new empty in-memory state store.
+func NewMemoryStateStore() *MemoryStateStore {
+	return &MemoryStateStore{values: make(map[string][]byte)}
+}
+
+// Get returns a value by the key.
+func (s *MemoryStateStore) Get(key string) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.values[key], nil
+}
+
+// Put sets a value of the key.
+func (s *MemoryStateStore) Put(key string, value []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.values[key]; !ok {
+		i := sort.SearchStrings(s.keys, key)
+		s.keys = append(s.keys, "")
+		copy(s.keys[i+1:], s.keys[i:])
+		s.keys[i] = key
+	}
+	s.values[key] = append([]byte(nil), value...)
+	return nil
+}
+
+// Delete removes the key.
+func (s *MemoryStateStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.values[key]; ok {
+		i := sort.SearchStrings(s.keys, key)
+		s.keys = append(s.keys[:i], s.keys[i+1:]...)
+	}
+	delete(s.values, key)
+	return nil
+}
+
+// Scan iterates keys with the prefix in lexicographical order from the start
+// key. The function must not modify the store.
+func (s *MemoryStateStore) Scan(prefix, start string, fn func(key string, value []byte) error) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if start < prefix {
+		start = prefix
+	}
+	for i := sort.SearchStrings(s.keys, start); i < len(s.keys) && strings.HasPrefix(s.keys[i], prefix); i++ {
+		if err := fn(s.keys[i], s.values[s.keys[i]]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FileStateStore is a StateStore which keeps state in memory and saves it to
+// a JSON file by Save.
+type FileStateStore struct {
+	*MemoryStateStore
+	path string
+}
+
+// OpenFileStateStore loads state from the file. A missed file means empty state.
+func OpenFileStateStore(path string) (*FileStateStore, error) {
+	s := &FileStateStore{MemoryStateStore: NewMemoryStateStore(), path: path}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(data, &s.values); err != nil {
+		return nil, err
+	}
+	for key := range s.values {
+		s.keys = append(s.keys, key)
+	}
+	sort.Strings(s.keys)
+
+	return s, nil
+}
+
+// Save writes state to the file. The file is replaced atomically.
+func (s *FileStateStore) Save() error {
+	s.mu.RLock()
+	data, err := json.Marshal(s.values)
+	s.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	tmp := s.path + ".tmp"
+	if err = ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
@@ -0,0 +1,45 @@
+package sitemap
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMemoryStateStore_Scan(t *testing.T) {
+	store := NewMemoryStateStore()
+	for _, key := range []string{"b/2", "a/1", "b/1", "b/3", "c/1"} {
+		store.Put(key, []byte(key))
+	}
+	store.Put("b/1", []byte("again"))
+	store.Delete("b/3")
+	store.Delete("b/missed")
+
+	scan := func(prefix, start string) []string {
+		var keys []string
+		if err := store.Scan(prefix, start, func(key string, value []byte) error {
+			keys = append(keys, key)
+			return nil
+		}); err != nil {
+			t.Fatalf("Scanning failed with error %s", err)
+		}
+		return keys
+	}
+
+	cases := []struct {
+		prefix   string
+		start    string
+		expected []string
+	}{
+		{"", "", []string{"a/1", "b/1", "b/2", "c/1"}},
+		{"b/", "", []string{"b/1", "b/2"}},
+		{"b/", "b/2", []string{"b/2"}},
+		{"b/", "b/1\x00", []string{"b/2"}},
+		{"b/", "a/", []string{"b/1", "b/2"}},
+		{"b/", "c/", nil},
+	}
+	for _, c := range cases {
+		if keys := scan(c.prefix, c.start); !reflect.DeepEqual(keys, c.expected) {
+			t.Errorf("Expected keys %v of scan %q from %q, but given %v", c.expected, c.prefix, c.start, keys)
+		}
+	}
+}
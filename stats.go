@@ -0,0 +1,284 @@
+package sitemap
+
+import (
+	"container/heap"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// GroupStats is statistics of a group of sitemap entries.
+//
+// Newest and Oldest are lastmod bounds of the group, they are nil if no entry has lastmod.
+// AverageAge is the average age of lastmod of entries which have it.
+type GroupStats struct {
+	Entries          int
+	WithLastModified int
+	Newest           *time.Time
+	Oldest           *time.Time
+	AverageAge       time.Duration
+
+	lastmodSum float64
+}
+
+// Stats is statistics of sitemap entries.
+//
+// Sections contains statistics of sections configured by WithSections, keyed
+// by the section prefix. Each entry is counted in the section with the longest
+// matching prefix, entries out of any section are counted in totals only.
+//
+// Labels contains statistics of labels given by the classifier set by
+// WithClassifier, keyed by the label. Entries with empty labels aren't counted.
+//
+// LongestURLs, OldestEntries and MostImages are top entries by length of URL,
+// age of lastmod and count of images, they are collected if WithTopN is set.
+// The first entry of each of them is the top one.
+type Stats struct {
+	GroupStats
+	Sections map[string]*GroupStats
+	Labels   map[string]*GroupStats
+
+	LongestURLs   []Entry
+	OldestEntries []Entry
+	MostImages    []Entry
+}
+
+// WithSections sets path prefixes of sections, e.g. "/blog/", "/products/",
+// which statistics has a breakdown by.
+func WithSections(prefixes ...string) Option {
+	return func(o *options) {
+		o.sections = append(o.sections, prefixes...)
+	}
+}
+
+// WithTopN enables collecting n top entries of statistics, see Stats.
+func WithTopN(n int) Option {
+	return func(o *options) {
+		o.topN = n
+	}
+}
+
+// StatsCollector collects statistics of entries in a single pass, so it can be
+// used with any consumer:
+//
+//	collector := sitemap.NewStatsCollector(sitemap.WithSections("/blog/"))
+//	err := sitemap.ParseFromSite(url, func(e sitemap.Entry) error {
+//		collector.Add(e)
+//		return nil
+//	})
+//	stats := collector.Stats()
+type StatsCollector struct {
+	o     *options
+	stats Stats
+
+	longest, oldest, images *ranking
+}
+
+// NewStatsCollector creates a new collector. WithClock option changes the source
+// of current time used to compute ages.
+func NewStatsCollector(opts ...Option) *StatsCollector {
+	o := newOptions(opts)
+	c := &StatsCollector{o: o}
+	c.stats.Sections = make(map[string]*GroupStats, len(o.sections))
+	c.stats.Labels = make(map[string]*GroupStats)
+	for _, prefix := range o.sections {
+		c.stats.Sections[prefix] = new(GroupStats)
+	}
+	if o.topN > 0 {
+		c.longest, c.oldest, c.images = newRanking(o.topN), newRanking(o.topN), newRanking(o.topN)
+	}
+	return c
+}
+
+// Add counts the entry.
+func (c *StatsCollector) Add(e Entry) {
+	lastmod := e.GetLastModified()
+	c.stats.add(lastmod)
+	if section := c.section(e.GetLocation()); section != nil {
+		section.add(lastmod)
+	}
+	if label := c.label(e); label != "" {
+		group := c.stats.Labels[label]
+		if group == nil {
+			group = new(GroupStats)
+			c.stats.Labels[label] = group
+		}
+		group.add(lastmod)
+	}
+
+	if c.longest != nil {
+		c.longest.add(e, float64(len(e.GetLocation())))
+		if lastmod != nil {
+			c.oldest.add(e, -float64(lastmod.Unix()))
+		}
+		if ip, ok := e.(ImagesProvider); ok && len(ip.GetImages()) > 0 {
+			c.images.add(e, float64(len(ip.GetImages())))
+		}
+	}
+}
+
+// Stats returns statistics of added entries.
+func (c *StatsCollector) Stats() *Stats {
+	now := c.o.now()
+	stats := c.stats
+	stats.finish(now)
+	stats.Sections = finishGroups(c.stats.Sections, now)
+	stats.Labels = finishGroups(c.stats.Labels, now)
+	if c.longest != nil {
+		stats.LongestURLs = c.longest.entries()
+		stats.OldestEntries = c.oldest.entries()
+		stats.MostImages = c.images.entries()
+	}
+	return &stats
+}
+
+// CollectStats parses the sitemap which provides by the reader and returns
+// statistics of its entries.
+func CollectStats(reader io.Reader, opts ...Option) (*Stats, error) {
+	c := NewStatsCollector(opts...)
+	err := parseDocument(reader, c.o, func(e Entry) error {
+		c.Add(e)
+		return nil
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.Stats(), nil
+}
+
+// label returns the label attached to the entry by parsing or gives it by the classifier.
+func (c *StatsCollector) label(e Entry) string {
+	if ce, ok := e.(ClassifiedEntry); ok && ce.GetLabel() != "" {
+		return ce.GetLabel()
+	}
+	if c.o.classifier != nil {
+		return c.o.classifier(e.GetLocation())
+	}
+	return ""
+}
+
+func (c *StatsCollector) section(location string) *GroupStats {
+	if len(c.stats.Sections) == 0 {
+		return nil
+	}
+
+	u, err := url.Parse(location)
+	if err != nil {
+		return nil
+	}
+
+	longest := ""
+	for prefix := range c.stats.Sections {
+		if len(prefix) > len(longest) && strings.HasPrefix(u.Path, prefix) {
+			longest = prefix
+		}
+	}
+	return c.stats.Sections[longest]
+}
+
+func (g *GroupStats) add(lastmod *time.Time) {
+	g.Entries++
+	if lastmod == nil {
+		return
+	}
+
+	g.WithLastModified++
+	g.lastmodSum += float64(lastmod.Unix())
+	if g.Newest == nil || lastmod.After(*g.Newest) {
+		g.Newest = lastmod
+	}
+	if g.Oldest == nil || lastmod.Before(*g.Oldest) {
+		g.Oldest = lastmod
+	}
+}
+
+func finishGroups(groups map[string]*GroupStats, now time.Time) map[string]*GroupStats {
+	result := make(map[string]*GroupStats, len(groups))
+	for key, group := range groups {
+		g := *group
+		g.finish(now)
+		result[key] = &g
+	}
+	return result
+}
+
+func (g *GroupStats) finish(now time.Time) {
+	if g.WithLastModified == 0 {
+		return
+	}
+	average := time.Unix(int64(g.lastmodSum/float64(g.WithLastModified)), 0)
+	g.AverageAge = now.Sub(average)
+}
+
+// ranking keeps n entries with the highest scores. It is a min-heap, so the
+// lowest of kept entries is replaced when a higher one is added.
+type ranking struct {
+	limit int
+	added int
+	items []rankedEntry
+}
+
+type rankedEntry struct {
+	entry Entry
+	score float64
+	seq   int
+}
+
+func newRanking(limit int) *ranking {
+	return &ranking{limit: limit}
+}
+
+func (r *ranking) add(e Entry, score float64) {
+	r.added++
+	item := rankedEntry{entry: e, score: score, seq: r.added}
+	if len(r.items) < r.limit {
+		heap.Push(r, item)
+		return
+	}
+	if score > r.items[0].score {
+		r.items[0] = item
+		heap.Fix(r, 0)
+	}
+}
+
+// entries returns kept entries from the highest score to the lowest one,
+// entries with equal scores are in order of adding.
+func (r *ranking) entries() []Entry {
+	items := make([]rankedEntry, len(r.items))
+	copy(items, r.items)
+	sorted := &ranking{items: items}
+
+	entries := make([]Entry, len(items))
+	for i := len(entries) - 1; i >= 0; i-- {
+		entries[i] = heap.Pop(sorted).(rankedEntry).entry
+	}
+	return entries
+}
+
+func (r *ranking) Len() int {
+	return len(r.items)
+}
+
+// Less makes later entries lower among entries with equal scores,
+// so the first added ones are kept.
+func (r *ranking) Less(i, j int) bool {
+	if r.items[i].score == r.items[j].score {
+		return r.items[i].seq > r.items[j].seq
+	}
+	return r.items[i].score < r.items[j].score
+}
+
+func (r *ranking) Swap(i, j int) {
+	r.items[i], r.items[j] = r.items[j], r.items[i]
+}
+
+func (r *ranking) Push(x interface{}) {
+	r.items = append(r.items, x.(rankedEntry))
+}
+
+func (r *ranking) Pop() interface{} {
+	last := r.items[len(r.items)-1]
+	r.items = r.items[:len(r.items)-1]
+	return last
+}
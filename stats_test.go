@@ -0,0 +1,95 @@
+package sitemap
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCollectStats_Sections(t *testing.T) {
+	data := `<urlset>
+<url><loc>http://example.com/</loc></url>
+<url><loc>http://example.com/blog/a</loc><lastmod>2019-03-01</lastmod></url>
+<url><loc>http://example.com/blog/b</loc><lastmod>2019-03-03</lastmod></url>
+<url><loc>http://example.com/blog/news/c</loc><lastmod>2019-02-01</lastmod></url>
+<url><loc>http://example.com/products/d</loc></url>
+</urlset>`
+	now := time.Date(2019, 3, 12, 0, 0, 0, 0, time.UTC)
+
+	stats, err := CollectStats(strings.NewReader(data),
+		WithSections("/blog/", "/blog/news/", "/products/", "/docs/"),
+		WithClock(func() time.Time { return now }))
+	if err != nil {
+		t.Fatalf("Collecting failed with error %s", err)
+	}
+
+	if stats.Entries != 5 || stats.WithLastModified != 3 {
+		t.Errorf("Expected 5 entries and 3 with lastmod, but given %d and %d", stats.Entries, stats.WithLastModified)
+	}
+
+	blog := stats.Sections["/blog/"]
+	if blog.Entries != 2 || blog.AverageAge != 10*24*time.Hour ||
+		!blog.Newest.Equal(time.Date(2019, 3, 3, 0, 0, 0, 0, time.UTC)) ||
+		!blog.Oldest.Equal(time.Date(2019, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected stats of /blog/ %+v", blog)
+	}
+	if stats.Sections["/blog/news/"].Entries != 1 {
+		t.Errorf("Expected 1 entry in /blog/news/, but given %d", stats.Sections["/blog/news/"].Entries)
+	}
+
+	products, docs := stats.Sections["/products/"], stats.Sections["/docs/"]
+	if products.Entries != 1 || products.Newest != nil || docs.Entries != 0 {
+		t.Errorf("Unexpected stats of /products/ %+v and /docs/ %+v", products, docs)
+	}
+}
+
+func TestCollectStats_TopN(t *testing.T) {
+	data := `<urlset xmlns:image="http://www.google.com/schemas/sitemap-image/1.1">
+<url><loc>http://example.com/a</loc><lastmod>2019-03-01</lastmod></url>
+<url><loc>http://example.com/long/long/long</loc><lastmod>2018-01-01</lastmod>
+  <image:image><image:loc>http://example.com/1.jpg</image:loc></image:image></url>
+<url><loc>http://example.com/long</loc><lastmod>2017-01-01</lastmod>
+  <image:image><image:loc>http://example.com/1.jpg</image:loc></image:image>
+  <image:image><image:loc>http://example.com/2.jpg</image:loc></image:image></url>
+<url><loc>http://example.com/bb</loc></url>
+<url><loc>http://example.com/cc</loc><lastmod>2019-01-01</lastmod></url>
+</urlset>`
+
+	stats, err := CollectStats(strings.NewReader(data), WithTopN(2))
+	if err != nil {
+		t.Fatalf("Collecting failed with error %s", err)
+	}
+
+	locations := func(entries []Entry) string {
+		var result []string
+		for _, e := range entries {
+			result = append(result, strings.TrimPrefix(e.GetLocation(), "http://example.com/"))
+		}
+		return strings.Join(result, " ")
+	}
+
+	if l := locations(stats.LongestURLs); l != "long/long/long long" {
+		t.Errorf("Unexpected longest URLs %q", l)
+	}
+	if l := locations(stats.OldestEntries); l != "long long/long/long" {
+		t.Errorf("Unexpected oldest entries %q", l)
+	}
+	if l := locations(stats.MostImages); l != "long long/long/long" {
+		t.Errorf("Unexpected entries with most images %q", l)
+	}
+}
+
+func TestRanking(t *testing.T) {
+	r := newRanking(3)
+	for i, score := range []float64{5, 1, 7, 5, 3, 9, 5} {
+		r.add(&sitemapEntry{Location: string(rune('a' + i))}, score)
+	}
+
+	var result string
+	for _, e := range r.entries() {
+		result += e.GetLocation()
+	}
+	if result != "fca" {
+		t.Errorf("Expected ranking fca, but given %s", result)
+	}
+}
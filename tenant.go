@@ -0,0 +1,145 @@
+package sitemap
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Tenant is an independent site of a crawler, like a customer of a service.
+//
+// Consumer is the sink of entries of the tenant. Options are applied to crawls
+// of the tenant after options of the crawler and of hosts, so state stores
+// (see WithConditional and WithDeletions), limits and budgets of tenants are
+// isolated from each other. Weight is the share of the tenant in CrawlTenants,
+// zero weight means 1.
+type Tenant struct {
+	ID       string
+	Sitemaps []string
+	Consumer EntryConsumer
+	Options  []Option
+	Weight   int
+}
+
+// TenantMetrics are totals of crawls of a tenant.
+//
+// Crawls, Entries, Failed, Deleted and Usage are summed over crawls of all
+// sitemaps of the tenant, Failed is the count of failed documents. LastErr is
+// the error of the last crawl which failed, it is nil if none failed.
+type TenantMetrics struct {
+	Crawls    int
+	Entries   int
+	Failed    int
+	Deleted   int
+	Usage     Usage
+	LastCrawl time.Time
+	LastErr   error
+}
+
+type tenantState struct {
+	tenant  Tenant
+	metrics TenantMetrics
+}
+
+// AddTenant adds the tenant or replaces one with the same ID. Metrics of
+// a replaced tenant are kept. It is safe to call it concurrently with crawls.
+func (c *Crawler) AddTenant(tenant Tenant) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if state, ok := c.tenants[tenant.ID]; ok {
+		state.tenant = tenant
+		return
+	}
+	c.tenants[tenant.ID] = &tenantState{tenant: tenant}
+}
+
+// RemoveTenant removes the tenant and its metrics.
+func (c *Crawler) RemoveTenant(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.tenants, id)
+}
+
+// Tenants returns IDs of tenants in no particular order.
+func (c *Crawler) Tenants() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ids := make([]string, 0, len(c.tenants))
+	for id := range c.tenants {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// TenantMetrics returns metrics of the tenant. It returns false if there is
+// no such tenant.
+func (c *Crawler) TenantMetrics(id string) (TenantMetrics, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	state, ok := c.tenants[id]
+	if !ok {
+		return TenantMetrics{}, false
+	}
+	return state.metrics, true
+}
+
+// CrawlTenant crawls sitemaps of the tenant one by one like Crawl does and passes
+// their entries to the consumer of the tenant. It stops on the first error and
+// returns reports of crawled sitemaps.
+func (c *Crawler) CrawlTenant(ctx context.Context, id string) ([]*CrawlReport, error) {
+	c.mu.Lock()
+	state, ok := c.tenants[id]
+	var tenant Tenant
+	if ok {
+		tenant = state.tenant
+	}
+	c.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("sitemap: unknown tenant %q", id)
+	}
+	return c.crawlTenant(ctx, tenant)
+}
+
+// crawlTenant crawls sitemaps of the tenant, the extra options are applied
+// after ones of the tenant.
+func (c *Crawler) crawlTenant(ctx context.Context, tenant Tenant, extra ...Option) ([]*CrawlReport, error) {
+	opts := append(append([]Option(nil), tenant.Options...), extra...)
+
+	var reports []*CrawlReport
+	for _, sitemapURL := range tenant.Sitemaps {
+		report, err := c.crawl(ctx, sitemapURL, tenant.Consumer, opts)
+		reports = append(reports, report)
+		c.record(tenant.ID, report, err)
+		if err != nil {
+			return reports, err
+		}
+	}
+	return reports, nil
+}
+
+func (c *Crawler) record(id string, report *CrawlReport, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	state, ok := c.tenants[id]
+	if !ok {
+		return
+	}
+	m := &state.metrics
+	m.Crawls++
+	m.Entries += report.Entries
+	m.Failed += report.Failed
+	m.Deleted += report.Deleted
+	m.Usage.Requests += report.Usage.Requests
+	m.Usage.Bytes += report.Usage.Bytes
+	m.Usage.ProxyBytes += report.Usage.ProxyBytes
+	m.Usage.Cost += report.Usage.Cost
+	m.LastCrawl = report.Finished
+	if err != nil {
+		m.LastErr = err
+	}
+}
@@ -0,0 +1,69 @@
+package sitemap
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestCrawler_Tenants(t *testing.T) {
+	server := newCrawlServer()
+	defer server.Close()
+
+	crawler := NewCrawler(WithWorkers(1))
+	entries := make(map[string]int)
+	for _, id := range []string{"small", "large"} {
+		id := id
+		crawler.AddTenant(Tenant{
+			ID:       id,
+			Sitemaps: []string{server.URL + "/sitemap.xml", server.URL + "/index.xml"},
+			Consumer: func(e Entry) error {
+				entries[id]++
+				return nil
+			},
+		})
+	}
+	crawler.AddTenant(Tenant{
+		ID:       "limited",
+		Sitemaps: []string{server.URL + "/index.xml"},
+		Consumer: func(e Entry) error {
+			entries["limited"]++
+			return nil
+		},
+		Options: []Option{WithBudget(Budget{MaxRequests: 1})},
+	})
+
+	for _, id := range []string{"small", "large"} {
+		reports, err := crawler.CrawlTenant(context.Background(), id)
+		if err != nil {
+			t.Fatalf("Crawling of %s failed with error %s", id, err)
+		}
+		if len(reports) != 2 {
+			t.Errorf("Expected 2 reports of %s, but given %d", id, len(reports))
+		}
+	}
+	_, err := crawler.CrawlTenant(context.Background(), "limited")
+	var budgetErr *BudgetExceededError
+	if !errors.As(err, &budgetErr) {
+		t.Errorf("Expected BudgetExceededError of the limited tenant, but given %v", err)
+	}
+
+	if entries["small"] != 5 || entries["large"] != 5 || entries["limited"] != 0 {
+		t.Errorf("Unexpected entries of tenants %v", entries)
+	}
+	metrics, ok := crawler.TenantMetrics("small")
+	if !ok || metrics.Crawls != 2 || metrics.Entries != 5 || metrics.Failed != 2 || metrics.Usage.Requests != 5 || metrics.LastErr != nil {
+		t.Errorf("Unexpected metrics of the small tenant %+v", metrics)
+	}
+	if metrics, _ := crawler.TenantMetrics("limited"); metrics.LastErr == nil || metrics.Usage.Requests != 1 {
+		t.Errorf("Unexpected metrics of the limited tenant %+v", metrics)
+	}
+
+	if _, err := crawler.CrawlTenant(context.Background(), "missing"); err == nil {
+		t.Error("Expected an error of an unknown tenant")
+	}
+	crawler.RemoveTenant("large")
+	if _, ok := crawler.TenantMetrics("large"); ok || len(crawler.Tenants()) != 2 {
+		t.Errorf("Expected the tenant to be removed, but given %v", crawler.Tenants())
+	}
+}
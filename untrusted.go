@@ -0,0 +1,83 @@
+package sitemap
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// Limits of ParseUntrusted.
+const (
+	untrustedElementSize = 64 * 1024
+	untrustedDepth       = 64
+)
+
+// UntrustedLimits returns limits which ParseUntrusted always applies. They
+// allow any sitemap within the protocol limits: documents up to MaxFileSize
+// bytes with up to MaxEntries entries, tokens up to 64 KiB and 64 levels of
+// nesting.
+func UntrustedLimits() Limits {
+	return Limits{
+		MaxDecompressedSize: MaxFileSize,
+		MaxEntries:          MaxEntries,
+		MaxElementSize:      untrustedElementSize,
+		MaxDepth:            untrustedDepth,
+	}
+}
+
+// ParseUntrusted parses data which provides by the reader like Parse does, but
+// it is hardened for untrusted third-party sitemaps, e.g. of tenants of
+// a service. It guarantees that
+//
+//   - it never panics, a panic of parsing is returned as an error, panics of
+//     the consumer aren't recovered;
+//   - memory doesn't grow with the input: a document, a token and nesting are
+//     limited by UntrustedLimits, so the decoder never holds more than a token
+//     and its stack, an entry is never larger than the document.
+//
+// Limits set by WithLimits are applied too, the lower one wins. A document
+// which exceeds them is aborted with LimitExceededError. The guarantees are
+// checked by fuzzing, see Fuzz in fuzz.go.
+func ParseUntrusted(reader io.Reader, consumer EntryConsumer, opts ...Option) (err error) {
+	o := untrustedOptions(opts)
+	consuming := false
+	defer func() {
+		if r := recover(); r != nil {
+			if consuming {
+				panic(r)
+			}
+			err = fmt.Errorf("sitemap: parsing of untrusted sitemap panicked: %v", r)
+		}
+	}()
+
+	return parseDocument(reader, o, func(e Entry) error {
+		consuming = true
+		err := consumer(e)
+		consuming = false
+		return err
+	}, nil)
+}
+
+// untrustedOptions returns options of ParseUntrusted.
+func untrustedOptions(opts []Option) *options {
+	o := newOptions(opts)
+	o.limits = o.limits.tighten(UntrustedLimits())
+	return o
+}
+
+// tighten returns limits which are the lower ones of both, zero values mean no limit.
+func (l Limits) tighten(other Limits) Limits {
+	min64 := func(a, b int64) int64 {
+		if a == 0 || (b != 0 && b < a) {
+			return b
+		}
+		return a
+	}
+	l.MaxDownloadSize = min64(l.MaxDownloadSize, other.MaxDownloadSize)
+	l.MaxDecompressedSize = min64(l.MaxDecompressedSize, other.MaxDecompressedSize)
+	l.MaxEntries = int(min64(int64(l.MaxEntries), int64(other.MaxEntries)))
+	l.MaxElementSize = min64(l.MaxElementSize, other.MaxElementSize)
+	l.MaxDepth = int(min64(int64(l.MaxDepth), int64(other.MaxDepth)))
+	l.Deadline = time.Duration(min64(int64(l.Deadline), int64(other.Deadline)))
+	return l
+}
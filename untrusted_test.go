@@ -0,0 +1,109 @@
+package sitemap
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"math/rand"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseUntrusted_Limits(t *testing.T) {
+	cases := []struct {
+		name  string
+		data  string
+		limit LimitKind
+	}{
+		{"deep nesting", "<urlset>" + strings.Repeat("<a>", 100000), LimitDepth},
+		{"huge token", "<urlset><url><loc>" + strings.Repeat("a", 100000) + "</loc></url></urlset>", LimitElementSize},
+		{"too many entries", "<urlset>" + strings.Repeat("<url><loc>http://example.com/</loc></url>", MaxEntries+1) + "</urlset>", LimitEntries},
+	}
+	for _, c := range cases {
+		err := ParseUntrusted(strings.NewReader(c.data), func(e Entry) error { return nil })
+		var limitErr *LimitExceededError
+		if !errors.As(err, &limitErr) || limitErr.Limit != c.limit {
+			t.Errorf("Expected %s limit error of %s, but given %v", c.limit, c.name, err)
+		}
+	}
+
+	err := ParseUntrusted(strings.NewReader("<urlset><url><loc>http://example.com/</loc></url></urlset>"),
+		func(e Entry) error { return nil }, WithLimits(Limits{MaxDecompressedSize: 10}))
+	var limitErr *LimitExceededError
+	if !errors.As(err, &limitErr) || limitErr.Limit != LimitDecompressedSize || limitErr.Max != 10 {
+		t.Errorf("Expected the lower decompressed size limit, but given %v", err)
+	}
+}
+
+func TestParseUntrusted_Valid(t *testing.T) {
+	paths, _ := filepath.Glob("./testdata/*.xml")
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var expected, given int
+		expectedErr := Parse(bytes.NewReader(data), func(e Entry) error { expected++; return nil })
+		err = ParseUntrusted(bytes.NewReader(data), func(e Entry) error { given++; return nil })
+		if given != expected || (err == nil) != (expectedErr == nil) {
+			t.Errorf("Expected %d entries of %s with error %v, but given %d with error %v", expected, path, expectedErr, given, err)
+		}
+	}
+}
+
+// TestParseUntrusted_Mutations is a light fuzzing of ParseUntrusted, use Fuzz
+// with go-fuzz for the deep one.
+func TestParseUntrusted_Mutations(t *testing.T) {
+	paths, _ := filepath.Glob("./testdata/*.xml")
+	random := rand.New(rand.NewSource(1))
+	special := []byte("<>/!?&;\"'=:\x00\xff\n")
+
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 200; i++ {
+			mutated := append([]byte(nil), data...)
+			for j := random.Intn(8); j >= 0; j-- {
+				k := random.Intn(len(mutated))
+				switch random.Intn(3) {
+				case 0:
+					mutated[k] = special[random.Intn(len(special))]
+				case 1:
+					mutated = append(mutated[:k], mutated[k+random.Intn(len(mutated)-k):]...)
+				default:
+					mutated = append(mutated[:k], append(append([]byte(nil), mutated[random.Intn(len(mutated)):]...), mutated[k:]...)...)
+				}
+				if len(mutated) == 0 {
+					mutated = []byte("<")
+				}
+			}
+
+			func() {
+				defer func() {
+					if r := recover(); r != nil {
+						t.Fatalf("Parsing of mutated %s panicked: %v\n%q", path, r, mutated)
+					}
+				}()
+				parseDocument(bytes.NewReader(mutated), untrustedOptions(nil), func(e Entry) error {
+					e.GetLastModified()
+					return nil
+				}, nil)
+			}()
+		}
+	}
+}
+
+func TestParseUntrusted_ConsumerPanic(t *testing.T) {
+	defer func() {
+		if r := recover(); r != "consumer" {
+			t.Errorf("Expected the panic of the consumer, but given %v", r)
+		}
+	}()
+	ParseUntrusted(strings.NewReader("<urlset><url><loc>http://example.com/</loc></url></urlset>"), func(e Entry) error {
+		panic("consumer")
+	})
+}
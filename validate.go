@@ -0,0 +1,281 @@
+package sitemap
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/frase-io/gopher-parse-sitemap/stage"
+)
+
+// Namespace is the XML namespace of sitemaps and sitemap indexes.
+const Namespace = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+// Issue codes of Validate.
+const (
+	IssueMissingLocation   IssueCode = "missing-loc"        // An element has no loc
+	IssueInvalidLocation   IssueCode = "invalid-loc"        // A loc isn't an absolute http(s) URL or is too long
+	IssueLocationOutOfPath IssueCode = "loc-out-of-path"    // A loc isn't under the path of the sitemap
+	IssueInvalidLastmod    IssueCode = "invalid-lastmod"    // A lastmod isn't a W3C datetime
+	IssueInvalidChangefreq IssueCode = "invalid-changefreq" // A changefreq isn't one of Frequency constants
+	IssueInvalidPriority   IssueCode = "invalid-priority"   // A priority isn't a number from 0.0 to 1.0
+	IssueTooManyEntries    IssueCode = "too-many-entries"   // A file has more than MaxEntries entries
+	IssueFileTooLarge      IssueCode = "file-too-large"     // A file is larger than MaxFileSize
+	IssueWrongNamespace    IssueCode = "wrong-namespace"    // The root element has a wrong namespace
+)
+
+// w3cDatetime matches formats of https://www.w3.org/TR/NOTE-datetime.
+var w3cDatetime = regexp.MustCompile(`^\d{4}(-\d{2}(-\d{2}(T\d{2}:\d{2}(:\d{2}(\.\d+)?)?(Z|[+-]\d{2}:\d{2}))?)?)?$`)
+
+// WithSitemapURL sets URL of the parsed sitemap, so Validate can check that
+// its URLs are under the sitemap path and relative locations can be resolved,
+// see WithURLNormalization. *FromSite functions set it automatically.
+func WithSitemapURL(sitemapURL string) Option {
+	return func(o *options) {
+		o.sitemapURL = sitemapURL
+	}
+}
+
+// Validate checks the sitemap or the sitemap index which provides by the reader
+// against the sitemaps protocol and returns found violations in order of their
+// positions. Gzipped data is decompressed. Unlike Parse it doesn't stop on invalid
+// values, however it returns ParseError with issues found so far for malformed XML.
+// Elements of Google extensions are checked too, see WithCoverage for
+// the overview of extensions.
+func Validate(reader io.Reader, opts ...Option) ([]Issue, error) {
+	v := &validator{o: newOptions(opts), coverage: newCoverage()}
+	if v.o.coverage != nil {
+		defer func() { *v.o.coverage = *v.coverage }()
+	}
+	if v.o.sitemapURL != "" {
+		if u, err := url.Parse(v.o.sitemapURL); err == nil {
+			v.scope = u
+		}
+	}
+
+	decompressed, err := stage.Decompress(reader)
+	if err != nil {
+		return nil, err
+	}
+	content, err := v.checkedContent(decompressed)
+	if err != nil || content == nil {
+		return v.issues, err
+	}
+	counter := &countingReader{reader: content}
+
+	err = parseLoop(counter, v.element)
+	v.finish()
+	if err != nil {
+		return v.issues, err
+	}
+
+	if counter.count > MaxFileSize {
+		v.report(Issue{
+			Code:     IssueFileTooLarge,
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("file is %d bytes, the limit is %d bytes", counter.count, MaxFileSize),
+		})
+	}
+	return v.issues, nil
+}
+
+// ValidateFromFile reads sitemap from a file and checks it like Validate does.
+func ValidateFromFile(sitemapPath string, opts ...Option) ([]Issue, error) {
+	sitemapFile, err := os.OpenFile(sitemapPath, os.O_RDONLY, os.ModeExclusive)
+	if err != nil {
+		return nil, err
+	}
+	defer sitemapFile.Close()
+
+	issues, err := Validate(sitemapFile, opts...)
+	return issues, withSource(err, sitemapPath)
+}
+
+// ValidateFromSite downloads sitemap from a site and checks it like Validate does.
+// It supports conditional fetching like ParseFromSite does.
+func ValidateFromSite(sitemapURL string, opts ...Option) ([]Issue, error) {
+	o := newOptions(opts)
+	res, err := o.getConditional(context.Background(), sitemapURL)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	issues, err := Validate(res.Body, append([]Option{WithSitemapURL(sitemapURL)}, opts...)...)
+	if err != nil {
+		return issues, withSource(err, sitemapURL)
+	}
+	return issues, o.storeValidators(sitemapURL, res)
+}
+
+// rawElement is an url or a sitemap element with values as they are.
+type rawElement struct {
+	Location        *string `xml:"loc"`
+	LastModified    *string `xml:"lastmod"`
+	ChangeFrequency *string `xml:"changefreq"`
+	Priority        *string `xml:"priority"`
+
+	Images []rawImage `xml:"image"`
+	Videos []rawVideo `xml:"video"`
+	News   []rawNews  `xml:"news"`
+	Links  []rawLink  `xml:"link"`
+	Mobile []struct{} `xml:"mobile"`
+}
+
+type validator struct {
+	o        *options
+	scope    *url.URL
+	checker  *utf8Checker
+	issues   []Issue
+	coverage *Coverage
+	root     bool
+	entries  int
+	line     int
+	offset   int64
+}
+
+func (v *validator) report(issue Issue) {
+	if issue.Line == 0 {
+		issue.Line, issue.Offset = v.line, v.offset
+	}
+	v.issues = append(v.issues, issue)
+}
+
+// finish adds issues of the encoding checker in order of positions.
+func (v *validator) finish() {
+	if len(v.checker.issues) == 0 {
+		return
+	}
+	v.issues = append(v.issues, v.checker.issues...)
+	sort.SliceStable(v.issues, func(i, j int) bool {
+		return v.issues[i].Offset < v.issues[j].Offset
+	})
+}
+
+func (v *validator) element(decoder *xml.Decoder, se *xml.StartElement) error {
+	v.line, _ = decoder.InputPos()
+	v.offset = decoder.InputOffset()
+
+	if !v.root {
+		v.root = true
+		if se.Name.Space != Namespace {
+			v.report(Issue{
+				Code:     IssueWrongNamespace,
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("%s has namespace %q, expected %q", se.Name.Local, se.Name.Space, Namespace),
+			})
+		}
+		return nil
+	}
+
+	isIndex := se.Name.Local == "sitemap"
+	if se.Name.Local != "url" && !isIndex {
+		return nil
+	}
+
+	raw := new(rawElement)
+	if err := decoder.DecodeElement(raw, se); err != nil {
+		return err
+	}
+
+	v.entries++
+	if v.entries == MaxEntries+1 {
+		v.report(Issue{
+			Code:     IssueTooManyEntries,
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("file has more than %d entries", MaxEntries),
+		})
+	}
+
+	v.check(raw, isIndex)
+	if !isIndex {
+		v.checkExtensions(raw)
+	}
+	return nil
+}
+
+func (v *validator) check(raw *rawElement, isIndex bool) {
+	if raw.Location == nil || strings.TrimSpace(*raw.Location) == "" {
+		v.report(Issue{Code: IssueMissingLocation, Severity: SeverityError, Message: "element has no loc"})
+		return
+	}
+	location := strings.TrimSpace(*raw.Location)
+
+	u, err := url.Parse(location)
+	switch {
+	case err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "":
+		v.report(Issue{Code: IssueInvalidLocation, Severity: SeverityError, Location: location,
+			Message: "loc isn't an absolute http or https URL"})
+	case len(location) > maxURLLength:
+		v.report(Issue{Code: IssueInvalidLocation, Severity: SeverityError, Location: location,
+			Message: fmt.Sprintf("loc is longer than %d characters", maxURLLength)})
+	case v.scope != nil && !isIndex && !inScope(v.scope, u):
+		v.report(Issue{Code: IssueLocationOutOfPath, Severity: SeverityError, Location: location,
+			Message: "loc isn't under the path of the sitemap " + v.scope.String()})
+	}
+
+	if raw.LastModified != nil && !isW3CDatetime(strings.TrimSpace(*raw.LastModified)) {
+		v.report(Issue{Code: IssueInvalidLastmod, Severity: SeverityError, Location: location,
+			Message: fmt.Sprintf("lastmod %q isn't a W3C datetime", *raw.LastModified)})
+	}
+	if isIndex {
+		return
+	}
+
+	if raw.ChangeFrequency != nil && !isFrequency(strings.TrimSpace(*raw.ChangeFrequency)) {
+		v.report(Issue{Code: IssueInvalidChangefreq, Severity: SeverityError, Location: location,
+			Message: fmt.Sprintf("changefreq %q isn't valid", *raw.ChangeFrequency)})
+	}
+	if raw.Priority != nil {
+		priority, err := strconv.ParseFloat(strings.TrimSpace(*raw.Priority), 32)
+		if err != nil || priority < 0 || priority > 1 {
+			v.report(Issue{Code: IssueInvalidPriority, Severity: SeverityError, Location: location,
+				Message: fmt.Sprintf("priority %q isn't a number from 0.0 to 1.0", *raw.Priority)})
+		}
+	}
+}
+
+// isW3CDatetime reports whether the value has W3C datetime format and its date exists.
+func isW3CDatetime(value string) bool {
+	if !w3cDatetime.MatchString(value) {
+		return false
+	}
+	if len(value) < len("2006-01-02") {
+		return true
+	}
+	_, err := time.Parse("2006-01-02", value[:len("2006-01-02")])
+	return err == nil
+}
+
+// inScope reports whether the URL is under the directory of the sitemap URL.
+func inScope(sitemapURL, u *url.URL) bool {
+	if !strings.EqualFold(sitemapURL.Scheme, u.Scheme) || !strings.EqualFold(sitemapURL.Host, u.Host) {
+		return false
+	}
+
+	dir := path.Dir(sitemapURL.Path)
+	if !strings.HasSuffix(dir, "/") {
+		dir += "/"
+	}
+	return strings.HasPrefix(u.Path, dir) || u.Path+"/" == dir
+}
+
+type countingReader struct {
+	reader io.Reader
+	count  int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.count += int64(n)
+	return n, err
+}
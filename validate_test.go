@@ -0,0 +1,184 @@
+package sitemap
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidateFromFile(t *testing.T) {
+	issues, err := ValidateFromFile("./testdata/sitemap-invalid.xml",
+		WithSitemapURL("http://example.com/blog/sitemap.xml"))
+	if err != nil {
+		t.Fatalf("Validation failed with error %s", err)
+	}
+
+	expected := []struct {
+		code     IssueCode
+		location string
+		line     int
+	}{
+		{IssueInvalidLastmod, "http://example.com/blog/first", 3},
+		{IssueMissingLocation, "", 9},
+		{IssueInvalidLocation, "/blog/relative", 12},
+		{IssueLocationOutOfPath, "http://example.com/shop/item", 15},
+		{IssueInvalidLastmod, "http://example.com/blog/second", 18},
+		{IssueInvalidChangefreq, "http://example.com/blog/second", 18},
+		{IssueInvalidPriority, "http://example.com/blog/second", 18},
+	}
+	if len(issues) != len(expected) {
+		t.Fatalf("Expected %d issues, but given %d: %v", len(expected), len(issues), issues)
+	}
+	for i, issue := range issues {
+		e := expected[i]
+		if issue.Code != e.code || issue.Location != e.location || issue.Line != e.line {
+			t.Errorf("Expected issue %s of %q at line %d, but given %s of %q at line %d",
+				e.code, e.location, e.line, issue.Code, issue.Location, issue.Line)
+		}
+		if issue.Severity != SeverityError || issue.Offset == 0 {
+			t.Errorf("Unexpected severity or offset of issue %v", issue)
+		}
+	}
+}
+
+func TestValidate_Limits(t *testing.T) {
+	var b strings.Builder
+	b.WriteString(xmlHeader + `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`)
+	for i := 0; i <= MaxEntries; i++ {
+		fmt.Fprintf(&b, "<url><loc>http://example.com/%d</loc></url>", i)
+	}
+	b.WriteString("<!--")
+	b.WriteString(strings.Repeat(" ", MaxFileSize))
+	b.WriteString("--></urlset>")
+
+	issues, err := Validate(strings.NewReader(b.String()))
+	if err != nil {
+		t.Fatalf("Validation failed with error %s", err)
+	}
+	if len(issues) != 2 || issues[0].Code != IssueTooManyEntries || issues[1].Code != IssueFileTooLarge {
+		t.Errorf("Expected too-many-entries and file-too-large issues, but given %v", issues)
+	}
+}
+
+func TestValidateFromSite(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, xmlHeader+`<urlset xmlns="http://www.google.com/schemas/sitemap/0.84">`+
+			`<url><loc>http://%s/</loc></url><url><loc>http://other.test/</loc></url></urlset>`, r.Host)
+	}))
+	defer server.Close()
+
+	issues, err := ValidateFromSite(server.URL + "/sitemap.xml")
+	if err != nil {
+		t.Fatalf("Validation failed with error %s", err)
+	}
+	if len(issues) != 2 || issues[0].Code != IssueWrongNamespace || issues[1].Code != IssueLocationOutOfPath ||
+		issues[1].Location != "http://other.test/" {
+		t.Errorf("Expected wrong-namespace and loc-out-of-path issues, but given %v", issues)
+	}
+}
+
+func TestValidate_Encoding(t *testing.T) {
+	utf16 := func(data string) string {
+		var b strings.Builder
+		b.WriteString("\xff\xfe")
+		for _, c := range data {
+			b.WriteRune(c)
+			b.WriteByte(0)
+		}
+		return b.String()
+	}
+
+	cases := []struct {
+		name     string
+		data     string
+		expected []IssueCode
+	}{
+		{"valid", xmlHeader + `<urlset xmlns="` + Namespace + `"></urlset>`, nil},
+		{"bom", "\xef\xbb\xbf" + xmlHeader + `<urlset xmlns="` + Namespace + `"></urlset>`, nil},
+		{"no declaration", `<urlset xmlns="` + Namespace + `"></urlset>`, []IssueCode{IssueMissingDeclaration}},
+		{"utf-16", utf16(`<?xml version="1.0" encoding="UTF-16"?><urlset/>`), []IssueCode{IssueUTF16Encoding}},
+		{"utf-16 declared utf-8", utf16(xmlHeader + `<urlset/>`), []IssueCode{IssueUTF16Encoding, IssueEncodingMismatch}},
+		{"declared utf-16", `<?xml version="1.0" encoding="UTF-16"?><urlset/>`, []IssueCode{IssueEncodingMismatch}},
+		{"latin-1", `<?xml version="1.0" encoding="ISO-8859-1"?><urlset xmlns="` + Namespace + `"></urlset>`, []IssueCode{IssueNonUTF8Encoding}},
+		{"stray bom", xmlHeader + `<urlset xmlns="` + Namespace + `">` + "\n\xef\xbb\xbf</urlset>", []IssueCode{IssueStrayBOM}},
+	}
+	for _, c := range cases {
+		issues, err := Validate(strings.NewReader(c.data))
+		if err != nil {
+			t.Errorf("Validation of %s failed with error %s", c.name, err)
+			continue
+		}
+		var codes []IssueCode
+		for _, issue := range issues {
+			codes = append(codes, issue.Code)
+		}
+		if !reflect.DeepEqual(codes, c.expected) {
+			t.Errorf("Expected issues %v of %s, but given %v", c.expected, c.name, issues)
+		}
+	}
+
+	issues, err := Validate(strings.NewReader(xmlHeader + "<urlset><url><loc>http://example.com/\xff</loc></url></urlset>"))
+	if err == nil || len(issues) == 0 || issues[len(issues)-1].Code != IssueEncodingMismatch {
+		t.Errorf("Expected parse error and encoding-mismatch issue of invalid UTF-8, but given %v and %v", err, issues)
+	}
+}
+
+func TestValidate_Coverage(t *testing.T) {
+	var coverage Coverage
+	issues, err := ValidateFromFile("./testdata/sitemap-extensions.xml", WithCoverage(&coverage))
+	if err != nil {
+		t.Fatalf("Validation failed with error %s", err)
+	}
+	for _, issue := range issues {
+		if issue.Code != IssueInvalidLocation {
+			t.Errorf("Unexpected issue %v", issue)
+		}
+	}
+	expected := []ExtensionCoverage{
+		{Extension: ExtensionImage, Entries: 1, Elements: 2},
+		{Extension: ExtensionVideo, Entries: 1, Elements: 1},
+		{Extension: ExtensionNews, Entries: 1, Elements: 1},
+		{Extension: ExtensionXHTML},
+		{Extension: ExtensionMobile},
+	}
+	if coverage.Entries != 3 || !reflect.DeepEqual(coverage.Extensions, expected) {
+		t.Errorf("Unexpected coverage %+v", coverage)
+	}
+
+	data := xmlHeader + `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"
+		xmlns:image="http://www.google.com/schemas/sitemap-image/1.1"
+		xmlns:video="http://www.google.com/schemas/sitemap-video/1.1"
+		xmlns:xhtml="http://www.w3.org/1999/xhtml"
+		xmlns:mobile="http://www.google.com/schemas/sitemap-mobile/1.0">
+	<url><loc>http://example.com/a</loc><image:image><image:loc>/relative.jpg</image:loc></image:image>
+		<video:video><video:title>Title</video:title><video:duration>0</video:duration></video:video>
+		<xhtml:link rel="alternate" hreflang="de" href="http://example.com/de/a"/>
+		<xhtml:link rel="alternate" href="http://example.com/fr/a"/><mobile:mobile/></url>
+	<url><loc>http://example.com/b</loc><xhtml:link rel="alternate" hreflang="en" href="http://example.com/b"/></url>
+	</urlset>`
+	issues, err = Validate(strings.NewReader(data), WithCoverage(&coverage))
+	if err != nil {
+		t.Fatalf("Validation failed with error %s", err)
+	}
+
+	var codes []string
+	for _, issue := range issues {
+		codes = append(codes, issue.Code)
+	}
+	expectedCodes := "invalid-image invalid-video invalid-video invalid-video invalid-video invalid-alternate deprecated-mobile"
+	if strings.Join(codes, " ") != expectedCodes {
+		t.Errorf("Unexpected issues %v", issues)
+	}
+	if c := coverage.Get(ExtensionVideo); c.Entries != 1 || c.Errors != 4 {
+		t.Errorf("Unexpected video coverage %+v", c)
+	}
+	if c := coverage.Get(ExtensionXHTML); c.Entries != 2 || c.Elements != 3 || c.Errors != 1 {
+		t.Errorf("Unexpected xhtml coverage %+v", c)
+	}
+	if c := coverage.Get(ExtensionMobile); c.Entries != 1 || c.Errors != 0 || c.Warnings != 1 {
+		t.Errorf("Unexpected mobile coverage %+v", c)
+	}
+}
@@ -0,0 +1,642 @@
+package sitemap
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Protocol limits of a single sitemap or sitemap index file.
+// See https://www.sitemaps.org/protocol.html for details.
+const (
+	MaxEntries  = 50000            // Max count of URLs in a file
+	MaxFileSize = 50 * 1024 * 1024 // Max uncompressed size of a file in bytes
+)
+
+// ErrSitemapFull is returned by writers when the next entry doesn't fit the
+// protocol limits of the current file.
+var ErrSitemapFull = errors.New("sitemap: the file has reached the protocol limits")
+
+// ErrWriterClosed is returned by writers when an entry is written after Close.
+var ErrWriterClosed = errors.New("sitemap: the writer is closed")
+
+const (
+	xmlHeader    = "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n"
+	urlsetHeader = xmlHeader + "<urlset xmlns=\"http://www.sitemaps.org/schemas/sitemap/0.9\">\n"
+	urlsetFooter = "</urlset>\n"
+	indexHeader  = xmlHeader + "<sitemapindex xmlns=\"http://www.sitemaps.org/schemas/sitemap/0.9\">\n"
+	indexFooter  = "</sitemapindex>\n"
+	maxURLLength = 2048
+)
+
+// LastmodGranularity is a type represents a precision of lastmod values of writers.
+type LastmodGranularity = string
+
+// Lastmod granularities constants set.
+const (
+	LastmodSeconds LastmodGranularity = "seconds" // 2006-01-02T15:04:05+07:00, the default
+	LastmodMinutes LastmodGranularity = "minutes" // 2006-01-02T15:04+07:00
+	LastmodDate    LastmodGranularity = "date"    // 2006-01-02
+)
+
+var lastmodLayouts = map[LastmodGranularity]string{
+	LastmodSeconds: time.RFC3339,
+	LastmodMinutes: "2006-01-02T15:04Z07:00",
+	LastmodDate:    "2006-01-02",
+}
+
+// WithLastmodGranularity sets the precision of lastmod values of writers, so
+// all values of a file have the same one. Dates are dates in the location of
+// the time, see WithLastmodUTC.
+func WithLastmodGranularity(granularity LastmodGranularity) Option {
+	return func(o *options) {
+		o.lastmodGranularity = granularity
+	}
+}
+
+// WithLastmodUTC makes writers convert lastmod values to UTC, so all values of
+// a file have the same offset and dates of date-only values are UTC dates.
+func WithLastmodUTC() Option {
+	return func(o *options) {
+		o.lastmodUTC = true
+	}
+}
+
+// TagPolicy is a type represents a policy of writers for optional changefreq
+// and priority tags.
+type TagPolicy = string
+
+// Tag policies constants set.
+const (
+	TagsExplicit TagPolicy = "explicit" // Tags are written when they are set, the default
+	TagsOmit     TagPolicy = "omit"     // Tags are never written
+	TagsDefaults TagPolicy = "defaults" // Unset tags are written with defaults of WithTagDefaults
+)
+
+// WithTagPolicy sets the policy of writers for changefreq and priority tags.
+// Search engines like Google ignore both of them, and they take about 30% of
+// the size of a typical sitemap, so TagsOmit minimizes files deliberately.
+func WithTagPolicy(policy TagPolicy) Option {
+	return func(o *options) {
+		o.tagPolicy = policy
+	}
+}
+
+// WithTagDefaults sets TagsDefaults policy of writers with the change frequency
+// and the priority of entries which have none. An empty change frequency is
+// still omitted. With WithTagPolicy(TagsDefaults) the priority is 0.5 like
+// the protocol defines.
+func WithTagDefaults(changefreq Frequency, priority float32) Option {
+	return func(o *options) {
+		o.tagPolicy = TagsDefaults
+		o.defaultChangefreq, o.defaultPriority = changefreq, &priority
+	}
+}
+
+// Priority returns a pointer of the priority for WriteEntry of writers.
+func Priority(value float32) *float32 {
+	return &value
+}
+
+// Writer is a streaming writer of a sitemap file. It writes each entry to the
+// underlying writer immediately and guarantees the result fits the protocol limits.
+type Writer struct {
+	doc        *document
+	policy     TagPolicy
+	changefreq Frequency
+	priority   *float32
+}
+
+// NewWriter creates a new sitemap writer. Gzip compression is enabled by WithGzip
+// option. You must call Close to finish the document.
+func NewWriter(w io.Writer, opts ...Option) *Writer {
+	o := newOptions(opts)
+	policy := o.tagPolicy
+	if policy == "" && o.compact {
+		policy = TagsOmit
+	}
+	return &Writer{
+		doc:        newDocument(w, o, urlsetHeader, urlsetFooter),
+		policy:     policy,
+		changefreq: o.defaultChangefreq,
+		priority:   o.defaultPriority,
+	}
+}
+
+// WriteEntry writes an URL element. The lastmod can be nil, it is formatted
+// by WithLastmodGranularity and WithLastmodUTC and must be a non-zero time of
+// years 1-9999. A nil priority and an empty change frequency are unset, they
+// are written by the policy of WithTagPolicy, see Priority for pointers of
+// priorities. If the element doesn't fit the protocol limits, nothing is written
+// and ErrSitemapFull is returned.
+func (w *Writer) WriteEntry(loc string, lastmod *time.Time, changefreq Frequency, priority *float32) error {
+	if err := validateEntry(loc, changefreq, priority); err != nil {
+		return err
+	}
+	value, err := w.doc.lastmod(lastmod)
+	if err != nil {
+		return err
+	}
+	given, givenPriority := changefreq, priority
+	if changefreq, priority, err = w.tags(changefreq, priority); err != nil {
+		return err
+	}
+
+	b := w.doc.element()
+	w.doc.open(b, "url")
+	w.doc.tag(b, "loc", loc)
+	if value != "" {
+		w.doc.tag(b, "lastmod", value)
+	}
+	if changefreq != "" {
+		w.doc.tag(b, "changefreq", changefreq)
+	} else if given != "" {
+		w.doc.omit("changefreq", given)
+	}
+	if priority != nil {
+		w.doc.tag(b, "priority", formatPriority(*priority))
+	} else if givenPriority != nil {
+		w.doc.omit("priority", formatPriority(*givenPriority))
+	}
+	w.doc.end(b, "url")
+
+	return w.doc.flush()
+}
+
+// Close writes the end of the document and flushes compressed data.
+// It doesn't close the underlying writer.
+func (w *Writer) Close() error {
+	return w.doc.close()
+}
+
+// Stats returns sizes of the document written so far, they are final after Close.
+func (w *Writer) Stats() WriteStats {
+	return w.doc.stats()
+}
+
+// tags returns the change frequency and the priority which are written by the
+// policy, empty and nil ones are omitted.
+func (w *Writer) tags(changefreq Frequency, priority *float32) (Frequency, *float32, error) {
+	switch w.policy {
+	case "", TagsExplicit:
+		return changefreq, priority, nil
+	case TagsOmit:
+		return "", nil, nil
+	case TagsDefaults:
+		if changefreq == "" {
+			changefreq = w.changefreq
+		}
+		if priority == nil {
+			priority = w.priority
+		}
+		if priority == nil {
+			priority = Priority(0.5)
+		}
+		return changefreq, priority, validateTags(changefreq, priority)
+	}
+	return "", nil, fmt.Errorf("sitemap: unknown tag policy %q", w.policy)
+}
+
+// IndexWriter is a streaming writer of a sitemap index file.
+type IndexWriter struct {
+	doc *document
+}
+
+// NewIndexWriter creates a new sitemap index writer. Gzip compression is enabled
+// by WithGzip option. You must call Close to finish the document.
+func NewIndexWriter(w io.Writer, opts ...Option) *IndexWriter {
+	return &IndexWriter{doc: newDocument(w, newOptions(opts), indexHeader, indexFooter)}
+}
+
+// WriteEntry writes a sitemap element. The lastmod can be nil. If the element
+// doesn't fit the protocol limits, nothing is written and ErrSitemapFull is returned.
+func (w *IndexWriter) WriteEntry(loc string, lastmod *time.Time) error {
+	if err := validateLocation(loc); err != nil {
+		return err
+	}
+	value, err := w.doc.lastmod(lastmod)
+	if err != nil {
+		return err
+	}
+
+	b := w.doc.element()
+	w.doc.open(b, "sitemap")
+	w.doc.tag(b, "loc", loc)
+	if value != "" {
+		w.doc.tag(b, "lastmod", value)
+	}
+	w.doc.end(b, "sitemap")
+
+	return w.doc.flush()
+}
+
+// Close writes the end of the document and flushes compressed data.
+// It doesn't close the underlying writer.
+func (w *IndexWriter) Close() error {
+	return w.doc.close()
+}
+
+// Stats returns sizes of the document written so far, they are final after Close.
+func (w *IndexWriter) Stats() WriteStats {
+	return w.doc.stats()
+}
+
+// FileCreator is a type represents a function which creates a file with the given name.
+type FileCreator func(name string) (io.WriteCloser, error)
+
+// DirFileCreator returns FileCreator which creates files in the directory.
+func DirFileCreator(dir string) FileCreator {
+	return func(name string) (io.WriteCloser, error) {
+		return os.Create(filepath.Join(dir, name))
+	}
+}
+
+// SplitWriter writes entries to a set of sitemap files named sitemap-1.xml,
+// sitemap-2.xml and so on, starting a new file each time the current one reaches
+// the protocol limits. On Close it writes sitemap-index.xml which refers to
+// all written sitemaps by baseURL joined with their names. With WithGzip option
+// all files are compressed and get the .gz suffix. Lastmod of the sitemaps in
+// the index is the time of Close by the clock of WithClock.
+type SplitWriter struct {
+	create  FileCreator
+	baseURL string
+	opts    []Option
+	now     func() time.Time
+	suffix  string
+	names   []string
+	file    io.WriteCloser
+	writer  *Writer
+	stats   WriteStats
+	closed  bool
+}
+
+// NewSplitWriter creates a new SplitWriter. The baseURL should end with a slash.
+func NewSplitWriter(create FileCreator, baseURL string, opts ...Option) *SplitWriter {
+	o := newOptions(opts)
+	suffix := ".xml"
+	if o.gzip {
+		suffix += ".gz"
+	}
+	return &SplitWriter{create: create, baseURL: baseURL, opts: opts, now: o.now, suffix: suffix}
+}
+
+// WriteEntry writes an URL element to the current sitemap file like Writer does.
+func (s *SplitWriter) WriteEntry(loc string, lastmod *time.Time, changefreq Frequency, priority *float32) error {
+	if s.closed {
+		return ErrWriterClosed
+	}
+	if s.writer == nil {
+		if err := s.next(); err != nil {
+			return err
+		}
+	}
+
+	err := s.writer.WriteEntry(loc, lastmod, changefreq, priority)
+	if err != ErrSitemapFull {
+		return err
+	}
+
+	if err = s.next(); err != nil {
+		return err
+	}
+	return s.writer.WriteEntry(loc, lastmod, changefreq, priority)
+}
+
+// Files returns names of sitemap files written so far, excluding the index.
+func (s *SplitWriter) Files() []string {
+	return s.names
+}
+
+// Stats returns total sizes of files written so far, including the index
+// after Close.
+func (s *SplitWriter) Stats() WriteStats {
+	stats := s.stats
+	if s.writer != nil {
+		stats.add(s.writer.Stats())
+	}
+	return stats
+}
+
+// Close finishes the current sitemap file and writes the index.
+func (s *SplitWriter) Close() error {
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+
+	if err := s.finish(); err != nil {
+		return err
+	}
+
+	file, err := s.create("sitemap-index" + s.suffix)
+	if err != nil {
+		return err
+	}
+
+	now := s.now()
+	index := NewIndexWriter(file, s.opts...)
+	for _, name := range s.names {
+		if err = index.WriteEntry(s.baseURL+name, &now); err != nil {
+			file.Close()
+			return err
+		}
+	}
+	if err = index.Close(); err != nil {
+		file.Close()
+		return err
+	}
+	s.stats.add(index.Stats())
+
+	return file.Close()
+}
+
+func (s *SplitWriter) next() error {
+	if err := s.finish(); err != nil {
+		return err
+	}
+	if len(s.names) == MaxEntries {
+		return fmt.Errorf("sitemap: more than %d sitemap files are required: %w", MaxEntries, ErrSitemapFull)
+	}
+
+	name := "sitemap-" + strconv.Itoa(len(s.names)+1) + s.suffix
+	file, err := s.create(name)
+	if err != nil {
+		return err
+	}
+
+	s.names = append(s.names, name)
+	s.file = file
+	s.writer = NewWriter(file, s.opts...)
+	return nil
+}
+
+func (s *SplitWriter) finish() error {
+	if s.writer == nil {
+		return nil
+	}
+
+	err := s.writer.Close()
+	closeErr := s.file.Close()
+	s.stats.add(s.writer.Stats())
+	s.writer, s.file = nil, nil
+
+	if err != nil {
+		return err
+	}
+	return closeErr
+}
+
+// document writes a XML document element by element keeping track of the limits.
+//
+// original is the size of the document in the default layout, pending is the
+// one of the buffered element. compressed counts gzip data.
+type document struct {
+	out          io.Writer
+	gz           *gzip.Writer
+	compressed   *countingWriter
+	err          error
+	header       string
+	footer       string
+	prettyHeader int
+	prettyFooter int
+	buf          bytes.Buffer
+	layout       string
+	utc          bool
+	compact      bool
+	maxEntries   int
+	maxSize      int
+	entries      int
+	size         int
+	original     int
+	pending      int
+	started      bool
+	closed       bool
+}
+
+func newDocument(w io.Writer, o *options, header, footer string) *document {
+	d := &document{
+		out:          w,
+		header:       header,
+		footer:       footer,
+		prettyHeader: len(header),
+		prettyFooter: len(footer),
+		layout:       lastmodLayouts[o.lastmodGranularity],
+		utc:          o.lastmodUTC,
+		compact:      o.compact,
+		maxEntries:   MaxEntries,
+		maxSize:      MaxFileSize,
+	}
+	if o.lastmodGranularity == "" {
+		d.layout = lastmodLayouts[LastmodSeconds]
+	}
+	if d.compact {
+		d.header = strings.Replace(header, "\n", "", -1)
+		d.footer = strings.TrimSuffix(footer, "\n")
+	}
+	if o.gzip {
+		d.compressed = &countingWriter{writer: w}
+		d.gz, d.err = gzip.NewWriterLevel(d.compressed, o.gzipLevel)
+		d.out = d.gz
+	}
+	return d
+}
+
+// stats returns sizes of the document.
+func (d *document) stats() WriteStats {
+	stats := WriteStats{Entries: d.entries, Size: int64(d.size), Original: int64(d.original)}
+	if d.compressed != nil {
+		stats.Compressed = d.compressed.count
+	}
+	return stats
+}
+
+// lastmod validates and formats the lastmod value, it is empty for nil.
+func (d *document) lastmod(lastmod *time.Time) (string, error) {
+	if lastmod == nil {
+		return "", nil
+	}
+	if d.layout == "" {
+		return "", errors.New("sitemap: unknown lastmod granularity")
+	}
+	value := *lastmod
+	if d.utc {
+		value = value.UTC()
+	}
+	if value.IsZero() {
+		return "", errors.New("sitemap: lastmod is zero time")
+	}
+	if year := value.Year(); year < 1 || year > 9999 {
+		return "", fmt.Errorf("sitemap: lastmod year %d is out of range 1-9999", year)
+	}
+	return value.Format(d.layout), nil
+}
+
+// element resets and returns the buffer for the next element.
+func (d *document) element() *bytes.Buffer {
+	d.buf.Reset()
+	d.pending = 0
+	return &d.buf
+}
+
+// open, tag and end write lines of the element, indented unless the document
+// is compact. They count the size of the lines in the default layout.
+func (d *document) open(b *bytes.Buffer, name string) {
+	d.line(b, "  ", "<"+name+">")
+}
+
+func (d *document) end(b *bytes.Buffer, name string) {
+	d.line(b, "  ", "</"+name+">")
+}
+
+func (d *document) tag(b *bytes.Buffer, name, value string) {
+	n := b.Len()
+	if !d.compact {
+		b.WriteString("    ")
+	}
+	b.WriteByte('<')
+	b.WriteString(name)
+	b.WriteByte('>')
+	xml.EscapeText(b, []byte(value))
+	b.WriteString("</")
+	b.WriteString(name)
+	b.WriteByte('>')
+	if !d.compact {
+		b.WriteByte('\n')
+	}
+	d.pending += b.Len() - n
+	if d.compact {
+		d.pending += len("    \n")
+	}
+}
+
+func (d *document) line(b *bytes.Buffer, indent, text string) {
+	if !d.compact {
+		b.WriteString(indent)
+	}
+	b.WriteString(text)
+	if !d.compact {
+		b.WriteByte('\n')
+	}
+	d.pending += len(indent) + len(text) + 1
+}
+
+// omit counts the size of the tag which is omitted by the tag policy, the
+// value doesn't need escaping.
+func (d *document) omit(name, value string) {
+	d.pending += len("    <"+name+"></"+name+">\n") + len(value)
+}
+
+// flush writes the buffered element if it fits the limits.
+func (d *document) flush() error {
+	if d.closed {
+		return ErrWriterClosed
+	}
+	if d.err != nil {
+		return d.err
+	}
+
+	size := d.size + d.buf.Len() + len(d.footer)
+	if !d.started {
+		size += len(d.header)
+	}
+	if d.entries >= d.maxEntries || size > d.maxSize {
+		return ErrSitemapFull
+	}
+
+	if err := d.start(); err != nil {
+		return err
+	}
+
+	n, err := d.out.Write(d.buf.Bytes())
+	d.size += n
+	if err != nil {
+		return err
+	}
+
+	d.original += d.pending
+	d.entries++
+	return nil
+}
+
+func (d *document) start() error {
+	if d.started {
+		return nil
+	}
+	d.started = true
+
+	n, err := io.WriteString(d.out, d.header)
+	d.size += n
+	d.original += d.prettyHeader
+	return err
+}
+
+func (d *document) close() error {
+	if d.closed {
+		return nil
+	}
+	d.closed = true
+
+	if d.err != nil {
+		return d.err
+	}
+	if err := d.start(); err != nil {
+		return err
+	}
+	n, err := io.WriteString(d.out, d.footer)
+	d.size += n
+	d.original += d.prettyFooter
+	if err != nil {
+		return err
+	}
+	if d.gz != nil {
+		return d.gz.Close()
+	}
+	return nil
+}
+
+func formatPriority(priority float32) string {
+	return strconv.FormatFloat(float64(priority), 'f', -1, 32)
+}
+
+func validateLocation(loc string) error {
+	if loc == "" {
+		return errors.New("sitemap: location is empty")
+	}
+	if len(loc) > maxURLLength {
+		return fmt.Errorf("sitemap: location is longer than %d characters", maxURLLength)
+	}
+	return nil
+}
+
+func validateEntry(loc string, changefreq Frequency, priority *float32) error {
+	if err := validateLocation(loc); err != nil {
+		return err
+	}
+	return validateTags(changefreq, priority)
+}
+
+func validateTags(changefreq Frequency, priority *float32) error {
+	if changefreq != "" && !isFrequency(changefreq) {
+		return fmt.Errorf("sitemap: invalid change frequency %q", changefreq)
+	}
+	if priority != nil && (*priority < 0 || *priority > 1) {
+		return fmt.Errorf("sitemap: priority %v is out of range 0.0-1.0", *priority)
+	}
+	return nil
+}
+
+func isFrequency(value Frequency) bool {
+	switch value {
+	case Always, Hourly, Daily, Weekly, Monthly, Yearly, Never:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,239 @@
+package sitemap
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+)
+
+type memoryFile struct {
+	bytes.Buffer
+}
+
+func (f *memoryFile) Close() error {
+	return nil
+}
+
+func memoryFileCreator(files map[string]*memoryFile) FileCreator {
+	return func(name string) (io.WriteCloser, error) {
+		f := new(memoryFile)
+		files[name] = f
+		return f, nil
+	}
+}
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	lastmod := time.Date(2015, 5, 7, 19, 13, 9, 0, time.UTC)
+
+	w := NewWriter(&buf)
+	if err := w.WriteEntry("http://HOST/?a=1&b=2", &lastmod, Monthly, Priority(0.9)); err != nil {
+		t.Fatalf("Writing failed with error %s", err)
+	}
+	if err := w.WriteEntry("http://HOST/tools/", nil, "", nil); err != nil {
+		t.Fatalf("Writing failed with error %s", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Closing failed with error %s", err)
+	}
+
+	expected := `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url>
+    <loc>http://HOST/?a=1&amp;b=2</loc>
+    <lastmod>2015-05-07T19:13:09Z</lastmod>
+    <changefreq>monthly</changefreq>
+    <priority>0.9</priority>
+  </url>
+  <url>
+    <loc>http://HOST/tools/</loc>
+  </url>
+</urlset>
+`
+	if buf.String() != expected {
+		t.Errorf("Unexpected result\n%s", buf.String())
+	}
+
+	var locations []string
+	err := Parse(&buf, func(e Entry) error {
+		locations = append(locations, e.GetLocation())
+		return nil
+	})
+	if err != nil || strings.Join(locations, " ") != "http://HOST/?a=1&b=2 http://HOST/tools/" {
+		t.Errorf("Written sitemap was parsed wrong %v %s", locations, err)
+	}
+}
+
+func TestWriter_Validation(t *testing.T) {
+	w := NewWriter(ioutil.Discard)
+	if w.WriteEntry("", nil, "", nil) == nil {
+		t.Error("Empty location was written")
+	}
+	if w.WriteEntry("http://HOST/", nil, "sometimes", nil) == nil {
+		t.Error("Invalid change frequency was written")
+	}
+	if w.WriteEntry("http://HOST/", nil, "", Priority(1.5)) == nil {
+		t.Error("Invalid priority was written")
+	}
+}
+
+func TestWriter_LastmodGranularity(t *testing.T) {
+	lastmod := time.Date(2015, 5, 7, 23, 13, 9, 500, time.FixedZone("", -5*60*60))
+	for _, test := range []struct {
+		opts     []Option
+		expected string
+	}{
+		{nil, "2015-05-07T23:13:09-05:00"},
+		{[]Option{WithLastmodGranularity(LastmodMinutes)}, "2015-05-07T23:13-05:00"},
+		{[]Option{WithLastmodGranularity(LastmodDate)}, "2015-05-07"},
+		{[]Option{WithLastmodGranularity(LastmodDate), WithLastmodUTC()}, "2015-05-08"},
+		{[]Option{WithLastmodUTC()}, "2015-05-08T04:13:09Z"},
+	} {
+		var buf bytes.Buffer
+		w := NewWriter(&buf, test.opts...)
+		if err := w.WriteEntry("http://HOST/", &lastmod, "", nil); err != nil {
+			t.Fatalf("Writing failed with error %s", err)
+		}
+		w.Close()
+		if !strings.Contains(buf.String(), "<lastmod>"+test.expected+"</lastmod>") {
+			t.Errorf("Expected lastmod %s, but given\n%s", test.expected, buf.String())
+		}
+	}
+
+	w := NewIndexWriter(ioutil.Discard)
+	if w.WriteEntry("http://HOST/sitemap.xml", new(time.Time)) == nil {
+		t.Error("Zero lastmod was written")
+	}
+	future := time.Date(10000, 1, 1, 0, 0, 0, 0, time.UTC)
+	if w.WriteEntry("http://HOST/sitemap.xml", &future) == nil {
+		t.Error("Lastmod out of range was written")
+	}
+	if NewWriter(ioutil.Discard, WithLastmodGranularity("hours")).WriteEntry("http://HOST/", &lastmod, "", nil) == nil {
+		t.Error("Lastmod of unknown granularity was written")
+	}
+}
+
+func TestWriter_TagPolicy(t *testing.T) {
+	a, b := "<url> <loc>http://HOST/a</loc>", "</url> <url> <loc>http://HOST/b</loc>"
+	for _, test := range []struct {
+		opts     []Option
+		expected string
+	}{
+		{nil, a + " <changefreq>daily</changefreq> " + b + " <priority>0.8</priority> </url>"},
+		{[]Option{WithTagPolicy(TagsOmit)}, a + " " + b + " </url>"},
+		{[]Option{WithTagPolicy(TagsDefaults)},
+			a + " <changefreq>daily</changefreq> <priority>0.5</priority> " + b + " <priority>0.8</priority> </url>"},
+		{[]Option{WithTagDefaults(Weekly, 0.3)}, a + " <changefreq>daily</changefreq> <priority>0.3</priority> " +
+			b + " <changefreq>weekly</changefreq> <priority>0.8</priority> </url>"},
+	} {
+		var buf bytes.Buffer
+		w := NewWriter(&buf, test.opts...)
+		if err := w.WriteEntry("http://HOST/a", nil, Daily, nil); err != nil {
+			t.Fatalf("Writing failed with error %s", err)
+		}
+		if err := w.WriteEntry("http://HOST/b", nil, "", Priority(0.8)); err != nil {
+			t.Fatalf("Writing failed with error %s", err)
+		}
+		w.Close()
+		if result := strings.Join(strings.Fields(buf.String()), " "); !strings.Contains(result, test.expected) {
+			t.Errorf("Expected %s, but given %s", test.expected, result)
+		}
+	}
+
+	if NewWriter(ioutil.Discard, WithTagDefaults("sometimes", 0)).WriteEntry("http://HOST/", nil, "", nil) == nil {
+		t.Error("Invalid default change frequency was written")
+	}
+}
+
+func TestWriter_Limit(t *testing.T) {
+	w := NewWriter(ioutil.Discard)
+	for i := 0; i < MaxEntries; i++ {
+		if err := w.WriteEntry(fmt.Sprintf("http://HOST/%d", i), nil, "", nil); err != nil {
+			t.Fatalf("Writing failed with error %s", err)
+		}
+	}
+	if err := w.WriteEntry("http://HOST/last", nil, "", nil); err != ErrSitemapFull {
+		t.Errorf("Expected ErrSitemapFull, but given %v", err)
+	}
+}
+
+func TestSplitWriter(t *testing.T) {
+	files := make(map[string]*memoryFile)
+	w := NewSplitWriter(memoryFileCreator(files), "http://HOST/", WithGzip())
+	for i := 0; i < MaxEntries+1; i++ {
+		if err := w.WriteEntry(fmt.Sprintf("http://HOST/%d", i), nil, "", nil); err != nil {
+			t.Fatalf("Writing failed with error %s", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Closing failed with error %s", err)
+	}
+
+	if len(files) != 3 || strings.Join(w.Files(), " ") != "sitemap-1.xml.gz sitemap-2.xml.gz" {
+		t.Fatalf("Unexpected files %v", w.Files())
+	}
+	compressed := 0
+	for _, f := range files {
+		compressed += f.Len()
+	}
+	if stats := w.Stats(); stats.Entries != MaxEntries+3 || stats.Compressed != int64(compressed) || stats.Original != stats.Size {
+		t.Errorf("Unexpected stats %+v of %d compressed bytes", stats, compressed)
+	}
+
+	counts := make(map[string]int)
+	for _, name := range w.Files() {
+		reader, err := gzip.NewReader(files[name])
+		if err != nil {
+			t.Fatalf("Can't decompress %s due to %s", name, err)
+		}
+		err = Parse(reader, func(e Entry) error {
+			counts[name]++
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Can't parse %s due to %s", name, err)
+		}
+	}
+	if counts["sitemap-1.xml.gz"] != MaxEntries || counts["sitemap-2.xml.gz"] != 1 {
+		t.Errorf("Unexpected entries count %v", counts)
+	}
+
+	reader, err := gzip.NewReader(files["sitemap-index.xml.gz"])
+	if err != nil {
+		t.Fatalf("Can't decompress index due to %s", err)
+	}
+	var sitemaps []string
+	err = ParseIndex(reader, func(e IndexEntry) error {
+		sitemaps = append(sitemaps, e.GetLocation())
+		return nil
+	})
+	if err != nil || strings.Join(sitemaps, " ") != "http://HOST/sitemap-1.xml.gz http://HOST/sitemap-2.xml.gz" {
+		t.Errorf("Unexpected index %v %s", sitemaps, err)
+	}
+}
+
+func TestSplitWriter_Clock(t *testing.T) {
+	now := time.Date(2020, 5, 1, 10, 30, 0, 0, time.UTC)
+	files := make(map[string]*memoryFile)
+	w := NewSplitWriter(memoryFileCreator(files), "http://HOST/", WithClock(func() time.Time { return now }))
+	if err := w.WriteEntry("http://HOST/", nil, "", nil); err != nil {
+		t.Fatalf("Writing failed with error %s", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Closing failed with error %s", err)
+	}
+
+	var lastmod *time.Time
+	err := ParseIndex(files["sitemap-index.xml"], func(e IndexEntry) error {
+		lastmod = e.GetLastModified()
+		return nil
+	})
+	if err != nil || lastmod == nil || !lastmod.Equal(now) {
+		t.Errorf("Expected lastmod %s of the clock, but given %v with error %v", now, lastmod, err)
+	}
+}